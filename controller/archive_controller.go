@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type IArchiveController interface {
+	// 組織をバックアップする
+	BackupOrganization(c echo.Context) error
+	// バックアップを新しい組織として復元する
+	RestoreOrganization(c echo.Context) error
+}
+
+type archiveController struct {
+	au usecase.IArchiveUseCase
+}
+
+func NewArchiveController(au usecase.IArchiveUseCase) IArchiveController {
+	return &archiveController{au}
+}
+
+func (ac *archiveController) BackupOrganization(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("organizationId")
+	organizationId, _ := strconv.Atoi(id)
+
+	archiveRes, err := ac.au.BackupOrganization(uint(userId.(float64)), uint(organizationId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	filename := fmt.Sprintf("organization-%d-%s.json", organizationId, archiveRes.ExportedAt.Format("20060102150405"))
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.JSON(http.StatusOK, archiveRes)
+}
+
+func (ac *archiveController) RestoreOrganization(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	archive := model.OrganizationArchive{}
+	if err := c.Bind(&archive); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	restoreRes, err := ac.au.RestoreOrganization(uint(userId.(float64)), archive)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, restoreRes)
+}
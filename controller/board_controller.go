@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type IBoardController interface {
+	// チームのタスクをステータスごとの列にまとめて取得する
+	GetBoard(c echo.Context) error
+	// カードのステータスと列内の位置を変更する
+	MoveTask(c echo.Context) error
+}
+
+type boardController struct {
+	bu usecase.IBoardUseCase
+}
+
+func NewBoardController(bu usecase.IBoardUseCase) IBoardController {
+	return &boardController{bu}
+}
+
+func (bc *boardController) GetBoard(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	boardRes, err := bc.bu.GetBoard(uint(userId.(float64)), uint(teamId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, boardRes)
+}
+
+func (bc *boardController) MoveTask(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+
+	move := model.TaskMove{}
+	if err := c.Bind(&move); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	taskRes, err := bc.bu.MoveTask(uint(userId.(float64)), uint(taskId), move)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, taskRes)
+}
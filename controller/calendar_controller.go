@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+type ICalendarController interface {
+	// チームまたは自分の担当タスクの期限をiCalendar形式で出力する
+	ExportCalendar(c echo.Context) error
+	// 購読用トークンを発行する
+	CreateCalendarToken(c echo.Context) error
+	// 購読用トークンの一覧を取得する
+	GetCalendarTokens(c echo.Context) error
+	// 購読用トークンを無効にする
+	RevokeCalendarToken(c echo.Context) error
+	// 購読用トークンでカレンダーを取得する(JWTなし)
+	GetCalendarFeed(c echo.Context) error
+}
+
+type calendarController struct {
+	cu usecase.ICalendarUseCase
+}
+
+func NewCalendarController(cu usecase.ICalendarUseCase) ICalendarController {
+	return &calendarController{cu}
+}
+
+func (cc *calendarController) ExportCalendar(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	teamId, _ := strconv.Atoi(c.QueryParam("team_id"))
+
+	calendar, err := cc.cu.ExportCalendar(uint(userId.(float64)), uint(teamId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="tasks.ics"`)
+	return c.Blob(http.StatusOK, calendarContentType, calendar)
+}
+
+func (cc *calendarController) CreateCalendarToken(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	body := struct {
+		TeamId uint `json:"team_id"`
+	}{}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	calendarTokenRes, err := cc.cu.CreateCalendarToken(uint(userId.(float64)), body.TeamId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, calendarTokenRes)
+}
+
+func (cc *calendarController) GetCalendarTokens(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	calendarTokensRes, err := cc.cu.GetCalendarTokens(uint(userId.(float64)))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, calendarTokensRes)
+}
+
+func (cc *calendarController) RevokeCalendarToken(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("tokenId")
+	calendarTokenId, _ := strconv.Atoi(id)
+
+	calendarTokenRes, err := cc.cu.RevokeCalendarToken(uint(userId.(float64)), uint(calendarTokenId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, calendarTokenRes)
+}
+
+func (cc *calendarController) GetCalendarFeed(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	calendar, err := cc.cu.GetCalendarFeed(token)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, err.Error())
+	}
+
+	return c.Blob(http.StatusOK, calendarContentType, calendar)
+}
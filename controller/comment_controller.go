@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type ICommentController interface {
+	// タスクのコメント一覧を取得する
+	GetComments(c echo.Context) error
+	// コメントを投稿する
+	CreateComment(c echo.Context) error
+	// コメントを編集する
+	UpdateComment(c echo.Context) error
+	// コメントを削除する
+	DeleteComment(c echo.Context) error
+}
+
+type commentController struct {
+	cu usecase.ICommentUseCase
+}
+
+func NewCommentController(cu usecase.ICommentUseCase) ICommentController {
+	return &commentController{cu}
+}
+
+func (cc *commentController) GetComments(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+
+	commentsRes, err := cc.cu.GetComments(uint(userId.(float64)), uint(taskId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, commentsRes)
+}
+
+func (cc *commentController) CreateComment(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+
+	comment := model.TaskComment{}
+	if err := c.Bind(&comment); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	commentRes, err := cc.cu.CreateComment(comment, uint(userId.(float64)), uint(taskId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, commentRes)
+}
+
+func (cc *commentController) UpdateComment(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	taskId, _ := strconv.Atoi(c.Param("taskId"))
+	commentId, _ := strconv.Atoi(c.Param("commentId"))
+
+	comment := model.TaskComment{}
+	if err := c.Bind(&comment); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	commentRes, err := cc.cu.UpdateComment(comment, uint(userId.(float64)), uint(taskId), uint(commentId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, commentRes)
+}
+
+func (cc *commentController) DeleteComment(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	taskId, _ := strconv.Atoi(c.Param("taskId"))
+	commentId, _ := strconv.Atoi(c.Param("commentId"))
+
+	if err := cc.cu.DeleteComment(uint(userId.(float64)), uint(taskId), uint(commentId)); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"encoding/json"
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type ICsvController interface {
+	// 条件に合うタスクをCSVで出力する
+	ExportTasks(c echo.Context) error
+	// CSVからチームにタスクを取り込む
+	ImportTasks(c echo.Context) error
+}
+
+type csvController struct {
+	cu usecase.ICsvUseCase
+}
+
+func NewCsvController(cu usecase.ICsvUseCase) ICsvController {
+	return &csvController{cu}
+}
+
+func (cc *csvController) ExportTasks(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	query, err := bindTaskQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	csv, err := cc.cu.ExportTasks(uint(userId.(float64)), query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="tasks.csv"`)
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", csv)
+}
+
+// multipart/form-dataで file(CSVファイル)、mapping({"title": "件名", "dead_line": "期限"} 省略可)、
+// dry_run(trueなら検証結果のみ返す)を受け取る
+func (cc *csvController) ImportTasks(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	mapping := model.CsvColumnMapping{}
+	if v := c.FormValue("mapping"); v != "" {
+		if err := json.Unmarshal([]byte(v), &mapping); err != nil {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+	}
+	dryRun, _ := strconv.ParseBool(c.FormValue("dry_run"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	defer file.Close()
+
+	importRes, err := cc.cu.ImportTasks(uint(userId.(float64)), uint(teamId), file, mapping, dryRun)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	if len(importRes.Errors) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, importRes)
+	}
+	if importRes.DryRun {
+		return c.JSON(http.StatusOK, importRes)
+	}
+
+	return c.JSON(http.StatusCreated, importRes)
+}
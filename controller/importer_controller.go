@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"encoding/json"
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type IImporterController interface {
+	// Trelloのボードのエクスポートを取り込む
+	ImportTrello(c echo.Context) error
+	// GitHub IssuesのJSONダンプを取り込む
+	ImportGitHubIssues(c echo.Context) error
+}
+
+type importerController struct {
+	iu usecase.IImporterUseCase
+}
+
+func NewImporterController(iu usecase.IImporterUseCase) IImporterController {
+	return &importerController{iu}
+}
+
+func (ic *importerController) ImportTrello(c echo.Context) error {
+	return ic.importFile(c, ic.iu.ImportTrello)
+}
+
+func (ic *importerController) ImportGitHubIssues(c echo.Context) error {
+	return ic.importFile(c, ic.iu.ImportGitHubIssues)
+}
+
+// multipart/form-dataで file(エクスポートしたJSON)と options(model.ImportOptionsのJSON、省略可)を受け取る
+func (ic *importerController) importFile(c echo.Context, importer func(userId uint, teamId uint, file io.Reader, options model.ImportOptions) (model.ImportReport, error)) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	options := model.ImportOptions{}
+	if v := c.FormValue("options"); v != "" {
+		if err := json.Unmarshal([]byte(v), &options); err != nil {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	defer file.Close()
+
+	reportRes, err := importer(uint(userId.(float64)), uint(teamId), file, options)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	if reportRes.DryRun {
+		return c.JSON(http.StatusOK, reportRes)
+	}
+
+	return c.JSON(http.StatusCreated, reportRes)
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type IMentionController interface {
+	// メンションの入力候補を取得する
+	GetMentionCandidates(c echo.Context) error
+	// 自分へのメンション一覧を取得する
+	GetMyMentions(c echo.Context) error
+}
+
+type mentionController struct {
+	mu usecase.IMentionUseCase
+}
+
+func NewMentionController(mu usecase.IMentionUseCase) IMentionController {
+	return &mentionController{mu}
+}
+
+func (mc *mentionController) GetMentionCandidates(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+
+	candidatesRes, err := mc.mu.GetMentionCandidates(uint(userId.(float64)), uint(taskId), c.QueryParam("q"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, candidatesRes)
+}
+
+func (mc *mentionController) GetMyMentions(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	mentionsRes, err := mc.mu.GetMyMentions(uint(userId.(float64)))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, mentionsRes)
+}
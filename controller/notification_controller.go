@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type INotificationController interface {
+	// 受信箱の通知を取得する
+	GetNotifications(c echo.Context) error
+	// 未読件数を取得する
+	GetUnreadCount(c echo.Context) error
+	// 通知を既読にする
+	MarkRead(c echo.Context) error
+	// すべての通知を既読にする
+	MarkAllRead(c echo.Context) error
+	// 受け取り設定を取得する
+	GetPreferences(c echo.Context) error
+	// 受け取り設定を更新する
+	UpdatePreferences(c echo.Context) error
+	// メールの設定を取得する
+	GetEmailSetting(c echo.Context) error
+	// メールの設定を更新する
+	UpdateEmailSetting(c echo.Context) error
+}
+
+type notificationController struct {
+	nu usecase.INotificationUseCase
+}
+
+func NewNotificationController(nu usecase.INotificationUseCase) INotificationController {
+	return &notificationController{nu}
+}
+
+func (nc *notificationController) GetNotifications(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	perPage, _ := strconv.Atoi(c.QueryParam("per_page"))
+	unreadOnly := c.QueryParam("unread") == "true"
+
+	notificationsRes, err := nc.nu.GetNotifications(uint(userId.(float64)), page, perPage, unreadOnly)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, notificationsRes)
+}
+
+func (nc *notificationController) GetUnreadCount(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	count, err := nc.nu.GetUnreadCount(uint(userId.(float64)))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]int64{"unread_count": count})
+}
+
+func (nc *notificationController) MarkRead(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("notificationId")
+	notificationId, _ := strconv.Atoi(id)
+
+	notificationRes, err := nc.nu.MarkRead(uint(userId.(float64)), uint(notificationId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, notificationRes)
+}
+
+func (nc *notificationController) MarkAllRead(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	if err := nc.nu.MarkAllRead(uint(userId.(float64))); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (nc *notificationController) GetPreferences(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	preferencesRes, err := nc.nu.GetPreferences(uint(userId.(float64)))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, preferencesRes)
+}
+
+func (nc *notificationController) UpdatePreferences(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	preferences := make([]model.NotificationPreferenceUpdate, 0)
+	if err := c.Bind(&preferences); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	preferencesRes, err := nc.nu.UpdatePreferences(uint(userId.(float64)), preferences)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, preferencesRes)
+}
+
+func (nc *notificationController) GetEmailSetting(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	settingRes, err := nc.nu.GetEmailSetting(uint(userId.(float64)))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, settingRes)
+}
+
+func (nc *notificationController) UpdateEmailSetting(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	setting := model.EmailSettingResponse{}
+	if err := c.Bind(&setting); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	settingRes, err := nc.nu.UpdateEmailSetting(uint(userId.(float64)), setting)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, settingRes)
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"errors"
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type IPasswordController interface {
+	// パスワード再設定用のメールを送る
+	ForgotPassword(c echo.Context) error
+	// パスワードを再設定する
+	ResetPassword(c echo.Context) error
+	// ログインしているユーザーのパスワードを変更する
+	ChangePassword(c echo.Context) error
+}
+
+type passwordController struct {
+	pu usecase.IPasswordUseCase
+}
+
+func NewPasswordController(pu usecase.IPasswordUseCase) IPasswordController {
+	return &passwordController{pu}
+}
+
+func (pc *passwordController) ForgotPassword(c echo.Context) error {
+	request := model.ForgotPasswordRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if err := pc.pu.ForgotPassword(request, sessionClient(c)); err != nil {
+		if errors.Is(err, usecase.ErrTooManyRequests) {
+			return c.JSON(http.StatusTooManyRequests, err.Error())
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	// 登録されていないメールアドレスでも同じ応答を返す
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (pc *passwordController) ResetPassword(c echo.Context) error {
+	request := model.ResetPasswordRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if err := pc.pu.ResetPassword(request); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (pc *passwordController) ChangePassword(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	request := model.ChangePasswordRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if err := pc.pu.ChangePassword(uint(userId.(float64)), currentSessionId(claims), request); err != nil {
+		return c.JSON(accountErrorStatus(err), err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// パスワードの確認や回数制限で失敗した場合のステータスコード
+func accountErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidPassword):
+		return http.StatusForbidden
+	case errors.Is(err, usecase.ErrTooManyRequests):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type IProjectController interface {
+	// プロジェクトを作成する
+	CreateProject(c echo.Context) error
+	// チームのプロジェクト一覧を取得する
+	GetTeamProjects(c echo.Context) error
+	// プロジェクトを取得する
+	GetProject(c echo.Context) error
+	// プロジェクトを更新する
+	UpdateProject(c echo.Context) error
+	// 共有するチームを置き換える
+	UpdateProjectTeams(c echo.Context) error
+	// プロジェクトを削除する
+	DeleteProject(c echo.Context) error
+	// プロジェクトのタスクを検索する
+	GetProjectTasks(c echo.Context) error
+	// プロジェクトにタスクを追加する
+	AddProjectTasks(c echo.Context) error
+	// プロジェクトからタスクを外す
+	RemoveProjectTask(c echo.Context) error
+	// プロジェクトの進捗を取得する
+	GetProjectProgress(c echo.Context) error
+}
+
+type projectController struct {
+	pu usecase.IProjectUseCase
+}
+
+func NewProjectController(pu usecase.IProjectUseCase) IProjectController {
+	return &projectController{pu}
+}
+
+func (pc *projectController) CreateProject(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	project := model.Project{}
+	if err := c.Bind(&project); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	projectRes, err := pc.pu.CreateProject(uint(userId.(float64)), project)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, projectRes)
+}
+
+func (pc *projectController) GetTeamProjects(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	projectsRes, err := pc.pu.GetTeamProjects(uint(userId.(float64)), uint(teamId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, projectsRes)
+}
+
+func (pc *projectController) GetProject(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("projectId")
+	projectId, _ := strconv.Atoi(id)
+
+	projectRes, err := pc.pu.GetProject(uint(userId.(float64)), uint(projectId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, projectRes)
+}
+
+func (pc *projectController) UpdateProject(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("projectId")
+	projectId, _ := strconv.Atoi(id)
+
+	project := model.Project{}
+	if err := c.Bind(&project); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	projectRes, err := pc.pu.UpdateProject(uint(userId.(float64)), uint(projectId), project)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, projectRes)
+}
+
+func (pc *projectController) UpdateProjectTeams(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("projectId")
+	projectId, _ := strconv.Atoi(id)
+
+	request := model.ProjectTeamsRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	projectRes, err := pc.pu.UpdateProjectTeams(uint(userId.(float64)), uint(projectId), request)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, projectRes)
+}
+
+func (pc *projectController) DeleteProject(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("projectId")
+	projectId, _ := strconv.Atoi(id)
+
+	if err := pc.pu.DeleteProject(uint(userId.(float64)), uint(projectId)); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (pc *projectController) GetProjectTasks(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("projectId")
+	projectId, _ := strconv.Atoi(id)
+
+	query, err := bindTaskQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	tasksRes, err := pc.pu.GetProjectTasks(uint(userId.(float64)), uint(projectId), query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, tasksRes)
+}
+
+func (pc *projectController) AddProjectTasks(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("projectId")
+	projectId, _ := strconv.Atoi(id)
+
+	request := model.ProjectTasksRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	progressRes, err := pc.pu.AddProjectTasks(uint(userId.(float64)), uint(projectId), request)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, progressRes)
+}
+
+func (pc *projectController) RemoveProjectTask(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("projectId")
+	projectId, _ := strconv.Atoi(id)
+	tid := c.Param("taskId")
+	taskId, _ := strconv.Atoi(tid)
+
+	progressRes, err := pc.pu.RemoveProjectTask(uint(userId.(float64)), uint(projectId), uint(taskId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, progressRes)
+}
+
+func (pc *projectController) GetProjectProgress(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("projectId")
+	projectId, _ := strconv.Atoi(id)
+
+	progressRes, err := pc.pu.GetProjectProgress(uint(userId.(float64)), uint(projectId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, progressRes)
+}
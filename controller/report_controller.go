@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type IReportController interface {
+	// チームのベロシティを取得する
+	GetVelocity(c echo.Context) error
+	// チームのタスクの統計を取得する
+	GetTeamStats(c echo.Context) error
+	// 組織のタスクの統計を取得する
+	GetOrganizationStats(c echo.Context) error
+	// チームのメンバーごとの負荷を取得する
+	GetWorkload(c echo.Context) error
+	// チームのリードタイムとサイクルタイムを取得する
+	GetCycleTime(c echo.Context) error
+	// チームの累積フロー図のデータを取得する
+	GetCumulativeFlow(c echo.Context) error
+}
+
+type reportController struct {
+	ru usecase.IReportUseCase
+}
+
+func NewReportController(ru usecase.IReportUseCase) IReportController {
+	return &reportController{ru}
+}
+
+func (rc *reportController) GetVelocity(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	query := model.VelocityQuery{Period: c.QueryParam("period")}
+	if v := c.QueryParam("count"); v != "" {
+		count, err := strconv.Atoi(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "count must be a number")
+		}
+		query.Count = count
+	}
+	velocityRes, err := rc.ru.GetVelocity(uint(userId.(float64)), uint(teamId), query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, velocityRes)
+}
+
+func (rc *reportController) GetTeamStats(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	statsRes, err := rc.ru.GetTeamStats(uint(userId.(float64)), uint(teamId), bindStatsQuery(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, statsRes)
+}
+
+func (rc *reportController) GetOrganizationStats(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("organizationId")
+	organizationId, _ := strconv.Atoi(id)
+
+	statsRes, err := rc.ru.GetOrganizationStats(uint(userId.(float64)), uint(organizationId), bindStatsQuery(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, statsRes)
+}
+
+func (rc *reportController) GetWorkload(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	workloadRes, err := rc.ru.GetWorkload(uint(userId.(float64)), uint(teamId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, workloadRes)
+}
+
+func (rc *reportController) GetCycleTime(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	cycleTimeRes, err := rc.ru.GetCycleTime(uint(userId.(float64)), uint(teamId), bindStatsQuery(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, cycleTimeRes)
+}
+
+func (rc *reportController) GetCumulativeFlow(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	flowRes, err := rc.ru.GetCumulativeFlow(uint(userId.(float64)), uint(teamId), bindStatsQuery(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, flowRes)
+}
+
+func bindStatsQuery(c echo.Context) model.StatsQuery {
+	return model.StatsQuery{
+		From: c.QueryParam("from"),
+		To: c.QueryParam("to"),
+		Interval: c.QueryParam("interval"),
+	}
+}
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+const refreshTokenCookie = "refreshToken"
+
+type ISessionController interface {
+	// リフレッシュトークンでトークンを取り直す
+	Refresh(c echo.Context) error
+	// ログイン中のセッション一覧を取得する
+	GetSessions(c echo.Context) error
+	// セッションを失効させる
+	RevokeSession(c echo.Context) error
+	// すべてのセッションを失効させる
+	RevokeAllSessions(c echo.Context) error
+	// 組織のユーザーを強制的にログアウトさせる
+	ForceLogout(c echo.Context) error
+	// 無効にされたアクセストークンを拒否するミドルウェア。JWTの検証の後に使う
+	CheckRevoked(next echo.HandlerFunc) echo.HandlerFunc
+}
+
+type sessionController struct {
+	su usecase.ISessionUseCase
+}
+
+func NewSessionController(su usecase.ISessionUseCase) ISessionController {
+	return &sessionController{su}
+}
+
+func (sc *sessionController) Refresh(c echo.Context) error {
+	cookie, err := c.Cookie(refreshTokenCookie)
+	if err != nil || cookie.Value == "" {
+		return c.JSON(http.StatusUnauthorized, "missing refresh token")
+	}
+	tokens, err := sc.su.Refresh(cookie.Value, sessionClient(c))
+	if err != nil {
+		clearAuthCookies(c)
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	setAuthCookies(c, tokens)
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (sc *sessionController) GetSessions(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	sessionsRes, err := sc.su.GetSessions(uint(userId.(float64)), currentSessionId(claims))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, sessionsRes)
+}
+
+func (sc *sessionController) RevokeSession(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("sessionId")
+	sessionId, _ := strconv.Atoi(id)
+
+	if err := sc.su.RevokeSession(uint(userId.(float64)), uint(sessionId)); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	if uint(sessionId) == currentSessionId(claims) {
+		clearAuthCookies(c)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (sc *sessionController) RevokeAllSessions(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	if err := sc.su.RevokeAllSessions(uint(userId.(float64)), 0, model.SessionRevokedByUser); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	clearAuthCookies(c)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (sc *sessionController) ForceLogout(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("organizationId")
+	organizationId, _ := strconv.Atoi(id)
+	id = c.Param("userId")
+	targetUserId, _ := strconv.Atoi(id)
+
+	if err := sc.su.ForceLogout(uint(userId.(float64)), uint(organizationId), uint(targetUserId)); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (sc *sessionController) CheckRevoked(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, ok := c.Get("user").(*jwt.Token)
+		if !ok {
+			return echo.ErrUnauthorized
+		}
+		claims := user.Claims.(jwt.MapClaims)
+		// jti のないトークン(導入前に発行したもの)は無効にできないため受け付けない
+		jti, ok := claims["jti"].(string)
+		if !ok || jti == "" {
+			return echo.ErrUnauthorized
+		}
+		revoked, err := sc.su.IsTokenRevoked(jti)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, err.Error())
+		}
+		if revoked {
+			return echo.ErrUnauthorized
+		}
+		return next(c)
+	}
+}
+
+func sessionClient(c echo.Context) model.SessionClient {
+	return model.SessionClient{
+		UserAgent: c.Request().UserAgent(),
+		IPAddress: c.RealIP(),
+	}
+}
+
+// セッション導入前に発行したトークンには session_id がないため0を返す
+func currentSessionId(claims jwt.MapClaims) uint {
+	if v, ok := claims["session_id"].(float64); ok {
+		return uint(v)
+	}
+	return 0
+}
+
+func setAuthCookies(c echo.Context, tokens model.AuthTokens) {
+	c.SetCookie(authCookie("jwtToken", tokens.AccessToken, tokens.AccessExpiresAt))
+	c.SetCookie(authCookie(refreshTokenCookie, tokens.RefreshToken, tokens.RefreshExpiresAt))
+}
+
+func clearAuthCookies(c echo.Context) {
+	c.SetCookie(authCookie("jwtToken", "", time.Now()))
+	c.SetCookie(authCookie(refreshTokenCookie, "", time.Now()))
+}
+
+func authCookie(name string, value string, expires time.Time) *http.Cookie {
+	cookie := new(http.Cookie)
+	cookie.Name = name
+	cookie.Value = value
+	cookie.Expires = expires
+	cookie.Path = "/"
+	cookie.Domain = os.Getenv("API_DOMAIN")
+	// cookie.Secure = true
+	cookie.HttpOnly = true
+	cookie.SameSite = http.SameSiteNoneMode
+	return cookie
+}
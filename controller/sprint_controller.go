@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type ISprintController interface {
+	// スプリントを作成する
+	CreateSprint(c echo.Context) error
+	// チームのスプリント一覧を取得する
+	GetTeamSprints(c echo.Context) error
+	// スプリントを取得する
+	GetSprint(c echo.Context) error
+	// スプリントを更新する
+	UpdateSprint(c echo.Context) error
+	// スプリントを削除する
+	DeleteSprint(c echo.Context) error
+	// スプリントのタスクを取得する
+	GetSprintTasks(c echo.Context) error
+	// スプリントにタスクを追加する
+	AddSprintTasks(c echo.Context) error
+	// スプリントからタスクを外す
+	RemoveSprintTask(c echo.Context) error
+	// スプリントを終了する
+	CloseSprint(c echo.Context) error
+	// スプリントの集計を取得する
+	GetSprintReport(c echo.Context) error
+	// バーンダウンチャートのデータを取得する
+	GetBurndown(c echo.Context) error
+	// バーンアップチャートのデータを取得する
+	GetBurnup(c echo.Context) error
+}
+
+type sprintController struct {
+	su usecase.ISprintUseCase
+}
+
+func NewSprintController(su usecase.ISprintUseCase) ISprintController {
+	return &sprintController{su}
+}
+
+func (sc *sprintController) CreateSprint(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	sprint := model.Sprint{}
+	if err := c.Bind(&sprint); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	sprintRes, err := sc.su.CreateSprint(uint(userId.(float64)), sprint)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, sprintRes)
+}
+
+func (sc *sprintController) GetTeamSprints(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	sprintsRes, err := sc.su.GetTeamSprints(uint(userId.(float64)), uint(teamId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, sprintsRes)
+}
+
+func (sc *sprintController) GetSprint(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("sprintId")
+	sprintId, _ := strconv.Atoi(id)
+
+	sprintRes, err := sc.su.GetSprint(uint(userId.(float64)), uint(sprintId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, sprintRes)
+}
+
+func (sc *sprintController) UpdateSprint(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("sprintId")
+	sprintId, _ := strconv.Atoi(id)
+
+	sprint := model.Sprint{}
+	if err := c.Bind(&sprint); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	sprintRes, err := sc.su.UpdateSprint(uint(userId.(float64)), uint(sprintId), sprint)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, sprintRes)
+}
+
+func (sc *sprintController) DeleteSprint(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("sprintId")
+	sprintId, _ := strconv.Atoi(id)
+
+	if err := sc.su.DeleteSprint(uint(userId.(float64)), uint(sprintId)); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (sc *sprintController) GetSprintTasks(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("sprintId")
+	sprintId, _ := strconv.Atoi(id)
+
+	tasksRes, err := sc.su.GetSprintTasks(uint(userId.(float64)), uint(sprintId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, tasksRes)
+}
+
+func (sc *sprintController) AddSprintTasks(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("sprintId")
+	sprintId, _ := strconv.Atoi(id)
+
+	request := model.SprintTasksRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	tasksRes, err := sc.su.AddSprintTasks(uint(userId.(float64)), uint(sprintId), request)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, tasksRes)
+}
+
+func (sc *sprintController) RemoveSprintTask(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("sprintId")
+	sprintId, _ := strconv.Atoi(id)
+	tid := c.Param("taskId")
+	taskId, _ := strconv.Atoi(tid)
+
+	tasksRes, err := sc.su.RemoveSprintTask(uint(userId.(float64)), uint(sprintId), uint(taskId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, tasksRes)
+}
+
+func (sc *sprintController) CloseSprint(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("sprintId")
+	sprintId, _ := strconv.Atoi(id)
+
+	request := model.SprintCloseRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	reportRes, err := sc.su.CloseSprint(uint(userId.(float64)), uint(sprintId), request)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, reportRes)
+}
+
+func (sc *sprintController) GetSprintReport(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("sprintId")
+	sprintId, _ := strconv.Atoi(id)
+
+	reportRes, err := sc.su.GetSprintReport(uint(userId.(float64)), uint(sprintId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, reportRes)
+}
+
+func (sc *sprintController) GetBurndown(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("sprintId")
+	sprintId, _ := strconv.Atoi(id)
+
+	pointsRes, err := sc.su.GetBurndown(uint(userId.(float64)), uint(sprintId), c.QueryParam("unit"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, pointsRes)
+}
+
+func (sc *sprintController) GetBurnup(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("sprintId")
+	sprintId, _ := strconv.Atoi(id)
+
+	pointsRes, err := sc.su.GetBurnup(uint(userId.(float64)), uint(sprintId), c.QueryParam("unit"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, pointsRes)
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+// プロキシに切断されないよう定期的にコメント行を送る
+const streamHeartbeatInterval = 25 * time.Second
+
+type IStreamController interface {
+	// 参加中のチームのタスク・コメントの変更をServer-Sent Eventsで配信する
+	Stream(c echo.Context) error
+}
+
+type streamController struct {
+	su usecase.IStreamUseCase
+}
+
+func NewStreamController(su usecase.IStreamUseCase) IStreamController {
+	return &streamController{su}
+}
+
+func (sc *streamController) Stream(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	// EventSourceは再接続時に Last-Event-ID ヘッダーを付ける
+	lastEventId := c.Request().Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = c.QueryParam("last_event_id")
+	}
+	afterId, _ := strconv.ParseUint(lastEventId, 10, 64)
+	// トークンの期限が切れるか無効にされたら切断する
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+
+	subscription, err := sc.su.Subscribe(uint(userId.(float64)), uint(afterId), jti, time.Unix(int64(exp), 0))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	defer subscription.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	fmt.Fprint(res, "retry: 3000\n\n")
+
+	// 取りこぼしが多すぎる場合は、画面を読み直すよう reset を送る
+	if subscription.ResetId != 0 {
+		fmt.Fprintf(res, "id: %d\nevent: reset\ndata: {}\n\n", subscription.ResetId)
+	}
+	sent := map[uint]bool{}
+	for _, v := range subscription.Backlog {
+		writeStreamEvent(res, v)
+		sent[v.ID] = true
+	}
+	res.Flush()
+
+	ticker := time.NewTicker(streamHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// 送信が追いつかないかトークンの期限が切れて購読が切断された。クライアントは Last-Event-ID を付けて再接続する
+				return nil
+			}
+			if sent[event.ID] || event.ID <= subscription.ResetId {
+				continue
+			}
+			writeStreamEvent(res, event)
+			res.Flush()
+		case <-ticker.C:
+			fmt.Fprint(res, ": ping\n\n")
+			res.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(res *echo.Response, event model.StreamEvent) {
+	fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Payload)
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"fmt"
 	"go-rest-api/model"
 	"go-rest-api/usecase"
 	"net/http"
@@ -21,6 +22,18 @@ type ITaskController interface {
 	DeleteTask(c echo.Context) error
 	NarrowDownStatus(c echo.Context) error
 	FuzzySearch(c echo.Context) error
+	// 条件を指定してタスクを絞り込み・並び替える
+	QueryTasks(c echo.Context) error
+	// メモ内のタスクリストのチェックボックスを切り替える
+	UpdateMemoCheckbox(c echo.Context) error
+	// タスクの担当者一覧を取得する
+	GetAssignees(c echo.Context) error
+	// タスクに担当者を追加する
+	AssignTask(c echo.Context) error
+	// タスクから担当者を外す
+	UnassignTask(c echo.Context) error
+	// チームの尺度でタスクを見積もる
+	EstimateTask(c echo.Context) error
 }
 
 type taskController struct {
@@ -88,7 +101,7 @@ func (tc *taskController) GetTaskById(c echo.Context) error {
 // 	if err := c.Bind(&task); err != nil {
 // 		return c.JSON(http.StatusBadRequest, err.Error())
 // 	}
-// 	task.UserId = uint(userId.(float64))
+// 	task.CreatedBy = uint(userId.(float64))
 // 	taskRes, err := tc.tu.CreateTask(task)
 // 	if err != nil {
 // 		return c.JSON(http.StatusInternalServerError, err.Error())
@@ -178,4 +191,167 @@ func (tc *taskController) FuzzySearch(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusOK, taskRes)
-}
\ No newline at end of file
+}
+
+func (tc *taskController) QueryTasks(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	query, err := bindTaskQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	taskRes, err := tc.tu.QueryTasks(uint(userId.(float64)), query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, taskRes)
+}
+
+func (tc *taskController) UpdateMemoCheckbox(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "index must be a number")
+	}
+
+	checkbox := model.TaskMemoCheckbox{}
+	if err := c.Bind(&checkbox); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	taskRes, err := tc.tu.UpdateMemoCheckbox(uint(userId.(float64)), uint(taskId), index, checkbox.Checked)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, taskRes)
+}
+
+func (tc *taskController) GetAssignees(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+
+	assigneesRes, err := tc.tu.GetAssignees(uint(userId.(float64)), uint(taskId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, assigneesRes)
+}
+
+func (tc *taskController) AssignTask(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+
+	inCharge := model.InCharge{}
+	if err := c.Bind(&inCharge); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	assigneesRes, err := tc.tu.AssignTask(uint(userId.(float64)), uint(taskId), inCharge.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, assigneesRes)
+}
+
+func (tc *taskController) UnassignTask(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+	assigneeId, _ := strconv.Atoi(c.Param("userId"))
+
+	assigneesRes, err := tc.tu.UnassignTask(uint(userId.(float64)), uint(taskId), uint(assigneeId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, assigneesRes)
+}
+
+func (tc *taskController) EstimateTask(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+
+	estimate := model.TaskEstimate{}
+	if err := c.Bind(&estimate); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	taskRes, err := tc.tu.EstimateTask(uint(userId.(float64)), uint(taskId), estimate)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, taskRes)
+}
+
+// クエリパラメータから検索条件を組み立てる
+// 例: /tasks/query?status=Unstarted&status=Started&overdue=true&sort_by=dead_line&order=asc
+func bindTaskQuery(c echo.Context) (model.TaskQuery, error) {
+	query := model.TaskQuery{
+		Keyword: c.QueryParam("keyword"),
+		DeadlineFrom: c.QueryParam("deadline_from"),
+		DeadlineTo: c.QueryParam("deadline_to"),
+		SortBy: c.QueryParam("sort_by"),
+		Order: c.QueryParam("order"),
+	}
+	if v := c.QueryParam("team_id"); v != "" {
+		teamId, err := strconv.Atoi(v)
+		if err != nil {
+			return model.TaskQuery{}, fmt.Errorf("team_id must be a number")
+		}
+		query.TeamId = uint(teamId)
+	}
+	if v := c.QueryParam("project_id"); v != "" {
+		projectId, err := strconv.Atoi(v)
+		if err != nil {
+			return model.TaskQuery{}, fmt.Errorf("project_id must be a number")
+		}
+		query.ProjectId = uint(projectId)
+	}
+	for _, v := range c.QueryParams()["status"] {
+		switch v {
+		case "Unstarted":
+			query.Statuses = append(query.Statuses, model.TaskStatusUnstarted)
+		case "Started":
+			query.Statuses = append(query.Statuses, model.TaskStatusStarted)
+		case "Completed":
+			query.Statuses = append(query.Statuses, model.TaskStatusCompleted)
+		default:
+			return model.TaskQuery{}, fmt.Errorf("status must be one of Unstarted, Started or Completed")
+		}
+	}
+	if v := c.QueryParam("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			return model.TaskQuery{}, fmt.Errorf("overdue must be true or false")
+		}
+		query.Overdue = overdue
+	}
+	if v := c.QueryParam("assigned_to_me"); v != "" {
+		assignedToMe, err := strconv.ParseBool(v)
+		if err != nil {
+			return model.TaskQuery{}, fmt.Errorf("assigned_to_me must be true or false")
+		}
+		query.AssignedToMe = assignedToMe
+	}
+
+	return query, nil
+}
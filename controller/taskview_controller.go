@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type ITaskViewController interface {
+	// 保存済みビューを作成する
+	CreateTaskView(c echo.Context) error
+	// 自分のビューと所属チームで共有されたビューを取得する
+	GetTaskViews(c echo.Context) error
+	// チームで共有されたビューを取得する
+	GetTeamTaskViews(c echo.Context) error
+	// 保存済みビューを更新する
+	UpdateTaskView(c echo.Context) error
+	// 保存済みビューを削除する
+	DeleteTaskView(c echo.Context) error
+	// チームのビューをピン留めする
+	PinTaskView(c echo.Context) error
+	// チームのビューのピン留めを外す
+	UnpinTaskView(c echo.Context) error
+	// 保存済みビューの条件でタスクを取得する
+	ExecuteTaskView(c echo.Context) error
+}
+
+type taskViewController struct {
+	tvu usecase.ITaskViewUseCase
+}
+
+func NewTaskViewController(tvu usecase.ITaskViewUseCase) ITaskViewController {
+	return &taskViewController{tvu}
+}
+
+func (tvc *taskViewController) CreateTaskView(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	taskView := model.TaskView{}
+	if err := c.Bind(&taskView); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	taskViewRes, err := tvc.tvu.CreateTaskView(taskView, uint(userId.(float64)))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, taskViewRes)
+}
+
+func (tvc *taskViewController) GetTaskViews(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	taskViewsRes, err := tvc.tvu.GetTaskViews(uint(userId.(float64)))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, taskViewsRes)
+}
+
+func (tvc *taskViewController) GetTeamTaskViews(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	taskViewsRes, err := tvc.tvu.GetTeamTaskViews(uint(userId.(float64)), uint(teamId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, taskViewsRes)
+}
+
+func (tvc *taskViewController) UpdateTaskView(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("viewId")
+	taskViewId, _ := strconv.Atoi(id)
+
+	taskView := model.TaskView{}
+	if err := c.Bind(&taskView); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	taskViewRes, err := tvc.tvu.UpdateTaskView(taskView, uint(userId.(float64)), uint(taskViewId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, taskViewRes)
+}
+
+func (tvc *taskViewController) DeleteTaskView(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("viewId")
+	taskViewId, _ := strconv.Atoi(id)
+
+	if err := tvc.tvu.DeleteTaskView(uint(userId.(float64)), uint(taskViewId)); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (tvc *taskViewController) PinTaskView(c echo.Context) error {
+	return tvc.setPinned(c, true)
+}
+
+func (tvc *taskViewController) UnpinTaskView(c echo.Context) error {
+	return tvc.setPinned(c, false)
+}
+
+func (tvc *taskViewController) setPinned(c echo.Context, pinned bool) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("viewId")
+	taskViewId, _ := strconv.Atoi(id)
+
+	taskViewRes, err := tvc.tvu.PinTaskView(uint(userId.(float64)), uint(taskViewId), pinned)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, taskViewRes)
+}
+
+func (tvc *taskViewController) ExecuteTaskView(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("viewId")
+	taskViewId, _ := strconv.Atoi(id)
+
+	taskRes, err := tvc.tvu.ExecuteTaskView(uint(userId.(float64)), uint(taskViewId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, taskRes)
+}
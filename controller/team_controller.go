@@ -19,6 +19,10 @@ type ITeamController interface {
 	GetTeamsByOrganizationId(c echo.Context) error
 	// チームを削除する
 	DeleteTeam(c echo.Context) error
+	// 見積もりの尺度を取得する
+	GetEstimateScale(c echo.Context) error
+	// 見積もりの尺度を変更する
+	UpdateEstimateScale(c echo.Context) error
 }
 
 type teamController struct {
@@ -85,4 +89,38 @@ func (tc *teamController) DeleteTeam(c echo.Context) error {
 	}
 
 	return c.NoContent(http.StatusOK)
-}
\ No newline at end of file
+}
+
+func (tc *teamController) GetEstimateScale(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	scaleRes, err := tc.tu.GetEstimateScale(uint(userId.(float64)), uint(teamId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, scaleRes)
+}
+
+func (tc *teamController) UpdateEstimateScale(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	request := model.EstimateScaleRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	scaleRes, err := tc.tu.UpdateEstimateScale(uint(userId.(float64)), uint(teamId), request)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, scaleRes)
+}
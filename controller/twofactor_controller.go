@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"errors"
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type ITwoFactorController interface {
+	// ログインの2段階目としてコードを確認する
+	LogInWithCode(c echo.Context) error
+	// 2段階認証の状態を取得する
+	GetStatus(c echo.Context) error
+	// 2段階認証の設定を始める
+	Setup(c echo.Context) error
+	// 2段階認証を有効にする
+	Enable(c echo.Context) error
+	// 2段階認証を無効にする
+	Disable(c echo.Context) error
+	// リカバリーコードを作り直す
+	RegenerateRecoveryCodes(c echo.Context) error
+}
+
+type twoFactorController struct {
+	tfu usecase.ITwoFactorUseCase
+}
+
+func NewTwoFactorController(tfu usecase.ITwoFactorUseCase) ITwoFactorController {
+	return &twoFactorController{tfu}
+}
+
+func (tfc *twoFactorController) LogInWithCode(c echo.Context) error {
+	request := model.TwoFactorLoginRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	tokens, err := tfc.tfu.CompleteLogin(request, sessionClient(c))
+	if err != nil {
+		return c.JSON(twoFactorErrorStatus(err), err.Error())
+	}
+	setAuthCookies(c, tokens)
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (tfc *twoFactorController) GetStatus(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	statusRes, err := tfc.tfu.GetStatus(uint(userId.(float64)))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, statusRes)
+}
+
+func (tfc *twoFactorController) Setup(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	request := model.TwoFactorSetupRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	setupRes, err := tfc.tfu.Setup(uint(userId.(float64)), request)
+	if err != nil {
+		return c.JSON(twoFactorErrorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, setupRes)
+}
+
+func (tfc *twoFactorController) Enable(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	request := model.TwoFactorCodeRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	codesRes, err := tfc.tfu.Enable(uint(userId.(float64)), request)
+	if err != nil {
+		return c.JSON(twoFactorErrorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, codesRes)
+}
+
+func (tfc *twoFactorController) Disable(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	request := model.TwoFactorReauthRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if err := tfc.tfu.Disable(uint(userId.(float64)), request); err != nil {
+		return c.JSON(twoFactorErrorStatus(err), err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (tfc *twoFactorController) RegenerateRecoveryCodes(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	request := model.TwoFactorReauthRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	codesRes, err := tfc.tfu.RegenerateRecoveryCodes(uint(userId.(float64)), request)
+	if err != nil {
+		return c.JSON(twoFactorErrorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, codesRes)
+}
+
+func twoFactorErrorStatus(err error) int {
+	if errors.Is(err, usecase.ErrInvalidCode) {
+		return http.StatusForbidden
+	}
+	return accountErrorStatus(err)
+}
@@ -1,12 +1,12 @@
 package controller
 
 import (
+	"errors"
 	"go-rest-api/model"
 	"go-rest-api/usecase"
+	"log"
 	"net/http"
-	"os"
 	"strconv"
-	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
@@ -56,36 +56,31 @@ func (uc *userController) LogIn(c echo.Context) error {
 	if err := c.Bind(&user); err != nil {
 		return c.JSON(http.StatusBadRequest, err.Error())
 	}
-	tokenString, err := uc.uu.Login(user)
+	loginRes, err := uc.uu.Login(user, sessionClient(c))
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
-
-	cookie := new(http.Cookie)
-	cookie.Name = "jwtToken"
-	cookie.Value = tokenString
-	cookie.Expires = time.Now().Add(24 * time.Hour)
-	cookie.Path = "/"
-	cookie.Domain = os.Getenv("API_DOMAIN")
-	// cookie.Secure = true
-	cookie.HttpOnly = true
-	cookie.SameSite = http.SameSiteNoneMode
-	c.SetCookie(cookie)
+	// 2段階認証が有効な場合は /login/2fa でコードを確認してからCookieを発行する
+	if loginRes.ChallengeToken != "" {
+		return c.JSON(http.StatusOK, model.LoginChallengeResponse{TwoFactorRequired: true, ChallengeToken: loginRes.ChallengeToken})
+	}
+	setAuthCookies(c, loginRes.Tokens)
 
 	return c.NoContent(http.StatusOK)
 }
 
 func (uc *userController) LogOut(c echo.Context) error {
-	cookie := new(http.Cookie)
-	cookie.Name = "jwtToken"
-	cookie.Value = ""
-	cookie.Expires = time.Now()
-	cookie.Path = "/"
-	cookie.Domain = os.Getenv("API_DOMAIN")
-	// cookie.Secure = true
-	cookie.HttpOnly = true
-	cookie.SameSite = http.SameSiteNoneMode
-	c.SetCookie(cookie)
+	accessToken, refreshToken := "", ""
+	if cookie, err := c.Cookie("jwtToken"); err == nil {
+		accessToken = cookie.Value
+	}
+	if cookie, err := c.Cookie(refreshTokenCookie); err == nil {
+		refreshToken = cookie.Value
+	}
+	if err := uc.uu.LogOut(accessToken, refreshToken); err != nil {
+		log.Printf("failed to end session: %v", err)
+	}
+	clearAuthCookies(c)
 
 	return c.NoContent(http.StatusOK)
 }
@@ -139,6 +134,9 @@ func (uc *userController) AssignUserToOrganization(c echo.Context) error {
 
 	userRes, err := uc.uu.AssignUserToOrganization(userModel, userModel.ID)
 	if err != nil {
+		if errors.Is(err, usecase.ErrEmailNotVerified) {
+			return c.JSON(http.StatusForbidden, err.Error())
+		}
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
 
@@ -154,6 +152,9 @@ func (uc *userController) AssignUserToTeam(c echo.Context) error {
 
 	teamMemberRes, err := uc.uu.AssignUserToTeam(teamMember, teamMember.UserID);
 	if err != nil {
+		if errors.Is(err, usecase.ErrEmailNotVerified) {
+			return c.JSON(http.StatusForbidden, err.Error())
+		}
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
 
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"errors"
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type IVerificationController interface {
+	// メールアドレスを確認する
+	VerifyEmail(c echo.Context) error
+	// 確認メールを送り直す
+	ResendVerification(c echo.Context) error
+	// メールアドレスの変更を依頼する
+	ChangeEmail(c echo.Context) error
+}
+
+type verificationController struct {
+	vu usecase.IVerificationUseCase
+}
+
+func NewVerificationController(vu usecase.IVerificationUseCase) IVerificationController {
+	return &verificationController{vu}
+}
+
+func (vc *verificationController) VerifyEmail(c echo.Context) error {
+	request := model.VerifyEmailRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if err := vc.vu.VerifyEmail(request); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (vc *verificationController) ResendVerification(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	if err := vc.vu.ResendVerification(uint(userId.(float64))); err != nil {
+		if errors.Is(err, usecase.ErrTooManyRequests) {
+			return c.JSON(http.StatusTooManyRequests, err.Error())
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (vc *verificationController) ChangeEmail(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	request := model.ChangeEmailRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if err := vc.vu.ChangeEmail(uint(userId.(float64)), request); err != nil {
+		return c.JSON(accountErrorStatus(err), err.Error())
+	}
+
+	// 新しいメールアドレスを確認した時点で変更される
+	return c.NoContent(http.StatusAccepted)
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type IWatcherController interface {
+	// タスクをフォローしているユーザーの一覧を取得する
+	GetWatchers(c echo.Context) error
+	// タスクをフォローする
+	Watch(c echo.Context) error
+	// タスクのフォローをやめる
+	Unwatch(c echo.Context) error
+}
+
+type watcherController struct {
+	wu usecase.IWatcherUseCase
+}
+
+func NewWatcherController(wu usecase.IWatcherUseCase) IWatcherController {
+	return &watcherController{wu}
+}
+
+func (wc *watcherController) GetWatchers(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+
+	watchRes, err := wc.wu.GetWatchers(uint(userId.(float64)), uint(taskId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, watchRes)
+}
+
+func (wc *watcherController) Watch(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+
+	watchRes, err := wc.wu.Watch(uint(userId.(float64)), uint(taskId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, watchRes)
+}
+
+func (wc *watcherController) Unwatch(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("taskId")
+	taskId, _ := strconv.Atoi(id)
+
+	watchRes, err := wc.wu.Unwatch(uint(userId.(float64)), uint(taskId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, watchRes)
+}
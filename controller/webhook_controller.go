@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"go-rest-api/model"
+	"go-rest-api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type IWebhookController interface {
+	// Webhookを登録する
+	CreateWebhook(c echo.Context) error
+	// 組織のWebhook一覧を取得する
+	GetOrganizationWebhooks(c echo.Context) error
+	// チームのWebhook一覧を取得する
+	GetTeamWebhooks(c echo.Context) error
+	// Webhookを更新する
+	UpdateWebhook(c echo.Context) error
+	// Webhookを削除する
+	DeleteWebhook(c echo.Context) error
+	// 配信履歴を取得する
+	GetDeliveries(c echo.Context) error
+	// 配信をやり直す
+	Redeliver(c echo.Context) error
+}
+
+type webhookController struct {
+	wu usecase.IWebhookUseCase
+}
+
+func NewWebhookController(wu usecase.IWebhookUseCase) IWebhookController {
+	return &webhookController{wu}
+}
+
+func (wc *webhookController) CreateWebhook(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+
+	webhook := model.Webhook{}
+	if err := c.Bind(&webhook); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	webhookRes, err := wc.wu.CreateWebhook(uint(userId.(float64)), webhook)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, webhookRes)
+}
+
+func (wc *webhookController) GetOrganizationWebhooks(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("organizationId")
+	organizationId, _ := strconv.Atoi(id)
+
+	webhooksRes, err := wc.wu.GetOrganizationWebhooks(uint(userId.(float64)), uint(organizationId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, webhooksRes)
+}
+
+func (wc *webhookController) GetTeamWebhooks(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("teamId")
+	teamId, _ := strconv.Atoi(id)
+
+	webhooksRes, err := wc.wu.GetTeamWebhooks(uint(userId.(float64)), uint(teamId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, webhooksRes)
+}
+
+func (wc *webhookController) UpdateWebhook(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("webhookId")
+	webhookId, _ := strconv.Atoi(id)
+
+	webhook := model.Webhook{}
+	if err := c.Bind(&webhook); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	webhookRes, err := wc.wu.UpdateWebhook(uint(userId.(float64)), uint(webhookId), webhook)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, webhookRes)
+}
+
+func (wc *webhookController) DeleteWebhook(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("webhookId")
+	webhookId, _ := strconv.Atoi(id)
+
+	if err := wc.wu.DeleteWebhook(uint(userId.(float64)), uint(webhookId)); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (wc *webhookController) GetDeliveries(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("webhookId")
+	webhookId, _ := strconv.Atoi(id)
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	perPage, _ := strconv.Atoi(c.QueryParam("per_page"))
+
+	deliveriesRes, err := wc.wu.GetDeliveries(uint(userId.(float64)), uint(webhookId), page, perPage)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, deliveriesRes)
+}
+
+func (wc *webhookController) Redeliver(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userId := claims["user_id"]
+	id := c.Param("webhookId")
+	webhookId, _ := strconv.Atoi(id)
+	deliveryId, _ := strconv.Atoi(c.Param("deliveryId"))
+
+	deliveryRes, err := wc.wu.Redeliver(uint(userId.(float64)), uint(webhookId), uint(deliveryId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusAccepted, deliveryRes)
+}
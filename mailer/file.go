@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// 開発用。送る代わりに1通ずつ .eml ファイルとして保存する
+func NewFileMailer(dir string, from string) IMailer {
+	return &fileMailer{dir, from}
+}
+
+func (fm *fileMailer) Send(message Message) error {
+	body, err := buildMIME(fm.from, message)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(fm.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000000"), filepath.Base(message.To))
+	return os.WriteFile(filepath.Join(fm.dir, name), body, 0o644)
+}
+
+type logMailer struct {
+	from string
+}
+
+// 開発用。送る代わりに宛先・件名・本文(テキスト)をログに書き出す
+func NewLogMailer(from string) IMailer {
+	return &logMailer{from}
+}
+
+func (lm *logMailer) Send(message Message) error {
+	log.Printf("mail from=%s to=%s subject=%q\n%s", lm.from, message.To, message.Subject, message.Text)
+	return nil
+}
@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type IMailer interface {
+	// メールを1通送る
+	Send(message Message) error
+}
+
+// MAIL_DRIVER に応じて送信方法を選ぶ
+//
+//	smtp: MAIL_HOST / MAIL_PORT / MAIL_USERNAME / MAIL_PASSWORD で送る(MailHogなどのローカルのSMTPでもよい)
+//	file: MAIL_DIR に .eml として保存する
+//	log : 標準出力に書き出す(既定)
+func NewMailer() (IMailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+	switch os.Getenv("MAIL_DRIVER") {
+	case "smtp":
+		port, err := strconv.Atoi(os.Getenv("MAIL_PORT"))
+		if err != nil {
+			return nil, fmt.Errorf("MAIL_PORT must be a number")
+		}
+		return NewSMTPMailer(os.Getenv("MAIL_HOST"), port, os.Getenv("MAIL_USERNAME"), os.Getenv("MAIL_PASSWORD"), from), nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mails"
+		}
+		return NewFileMailer(dir, from), nil
+	case "", "log":
+		return NewLogMailer(from), nil
+	}
+	return nil, fmt.Errorf("unknown MAIL_DRIVER %q", os.Getenv("MAIL_DRIVER"))
+}
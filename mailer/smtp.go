@@ -0,0 +1,29 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+)
+
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// ユーザー名が空なら認証せずに送る(ローカルのSMTPサーバー向け)
+func NewSMTPMailer(host string, port int, username string, password string, from string) IMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpMailer{fmt.Sprintf("%s:%d", host, port), auth, from}
+}
+
+func (sm *smtpMailer) Send(message Message) error {
+	body, err := buildMIME(sm.from, message)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(sm.addr, sm.auth, sm.from, []string{message.To}, body)
+}
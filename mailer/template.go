@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+const DefaultLanguage = "ja"
+
+var Languages = []string{"ja", "en"}
+
+// templates/{言語}/{名前}.txt に "subject" と "text"、{名前}.html に "html" を定義する
+//
+//go:embed templates
+var templateFS embed.FS
+
+// テンプレートからメールの件名と本文を作る。対応していない言語は日本語にする
+func Render(language string, name string, to string, data interface{}) (Message, error) {
+	if !supported(language) {
+		language = DefaultLanguage
+	}
+	textTemplate, err := texttemplate.ParseFS(templateFS, fmt.Sprintf("templates/%s/%s.txt", language, name))
+	if err != nil {
+		return Message{}, err
+	}
+	htmlTemplate, err := htmltemplate.ParseFS(templateFS, fmt.Sprintf("templates/%s/%s.html", language, name))
+	if err != nil {
+		return Message{}, err
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := textTemplate.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := textTemplate.ExecuteTemplate(&text, "text", data); err != nil {
+		return Message{}, err
+	}
+	if err := htmlTemplate.ExecuteTemplate(&html, "html", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To: to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text: strings.TrimSpace(text.String()) + "\n",
+		HTML: html.String(),
+	}, nil
+}
+
+func supported(language string) bool {
+	for _, v := range Languages {
+		if v == language {
+			return true
+		}
+	}
+	return false
+}
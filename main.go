@@ -1,31 +1,147 @@
 package main
 
 import (
+	"context"
 	"go-rest-api/controller"
 	"go-rest-api/db"
+	"go-rest-api/mailer"
 	"go-rest-api/repository"
 	"go-rest-api/router"
 	"go-rest-api/usecase"
 	"go-rest-api/validator"
+	"log"
+	"time"
 )
 
 func main() {
 	db := db.CreateDB()
+	m, err := mailer.NewMailer()
+	if err != nil {
+		log.Fatalln(err)
+	}
 	userValidator := validator.NewUserValidator()
 	taskValidator := validator.NewTaskValidator()
+	taskViewValidator := validator.NewTaskViewValidator()
+	commentValidator := validator.NewCommentValidator()
+	notificationValidator := validator.NewNotificationValidator()
+	webhookValidator := validator.NewWebhookValidator()
+	sprintValidator := validator.NewSprintValidator()
+	projectValidator := validator.NewProjectValidator()
+	estimateValidator := validator.NewEstimateValidator()
+	reportValidator := validator.NewReportValidator()
 	userRepository := repository.NewUserRepostory(db)
 	taskRepository := repository.NewTaskRepository(db)
 	organizationRepository := repository.NewOrganizationRepository(db)
 	teamRepository := repository.NewTeamRepository(db)
 	teamMemberRepository := repository.NewTeamMemberRepository(db)
-	userUsecase := usecase.NewUserUseCase(userRepository, userValidator, teamMemberRepository)
-	taskUsecase := usecase.NewTaskUsecase(taskRepository, taskValidator)
+	taskViewRepository := repository.NewTaskViewRepository(db)
+	calendarTokenRepository := repository.NewCalendarTokenRepository(db)
+	archiveRepository := repository.NewArchiveRepository(db)
+	commentRepository := repository.NewCommentRepository(db)
+	mentionRepository := repository.NewMentionRepository(db)
+	notificationRepository := repository.NewNotificationRepository(db)
+	webhookRepository := repository.NewWebhookRepository(db)
+	streamEventRepository := repository.NewStreamEventRepository(db)
+	emailRepository := repository.NewEmailRepository(db)
+	watcherRepository := repository.NewWatcherRepository(db)
+	sprintRepository := repository.NewSprintRepository(db)
+	taskHistoryRepository := repository.NewTaskHistoryRepository(db)
+	projectRepository := repository.NewProjectRepository(db)
+	reportRepository := repository.NewReportRepository(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	passwordRepository := repository.NewPasswordRepository(db)
+	rateLimitRepository := repository.NewRateLimitRepository(db)
+	verificationRepository := repository.NewVerificationRepository(db)
+	twoFactorRepository := repository.NewTwoFactorRepository(db)
+	emailUsecase := usecase.NewEmailUseCase(emailRepository, m)
+	notificationUsecase := usecase.NewNotificationUseCase(notificationRepository, taskRepository, watcherRepository, userRepository, emailUsecase, notificationValidator)
+	webhookUsecase := usecase.NewWebhookUseCase(webhookRepository, organizationRepository, teamRepository, teamMemberRepository, webhookValidator)
+	streamUsecase := usecase.NewStreamUseCase(streamEventRepository, teamMemberRepository, sessionRepository)
+	eventPublisher := usecase.NewEventPublisher(webhookUsecase, streamUsecase)
+	watcherUsecase := usecase.NewWatcherUseCase(watcherRepository, taskRepository)
+	sprintUsecase := usecase.NewSprintUseCase(sprintRepository, taskHistoryRepository, teamMemberRepository, sprintValidator)
+	projectUsecase := usecase.NewProjectUseCase(projectRepository, taskRepository, teamRepository, teamMemberRepository, taskValidator, projectValidator)
+	reportUsecase := usecase.NewReportUseCase(reportRepository, sprintRepository, taskHistoryRepository, teamMemberRepository, organizationRepository, userRepository, reportValidator)
+	sessionUsecase := usecase.NewSessionUseCase(sessionRepository, organizationRepository, userRepository)
+	passwordUsecase := usecase.NewPasswordUseCase(userRepository, passwordRepository, rateLimitRepository, sessionUsecase, notificationUsecase, emailUsecase, userValidator)
+	verificationUsecase := usecase.NewVerificationUseCase(userRepository, verificationRepository, rateLimitRepository, notificationUsecase, emailUsecase, userValidator)
+	twoFactorUsecase := usecase.NewTwoFactorUseCase(twoFactorRepository, userRepository, rateLimitRepository, sessionUsecase)
+	userUsecase := usecase.NewUserUseCase(userRepository, userValidator, teamMemberRepository, notificationUsecase, sessionUsecase, verificationUsecase, twoFactorUsecase)
+	mentionUsecase := usecase.NewMentionUseCase(mentionRepository, taskRepository)
+	taskUsecase := usecase.NewTaskUsecase(taskRepository, teamMemberRepository, teamRepository, taskValidator, estimateValidator, mentionUsecase, notificationUsecase, watcherUsecase, eventPublisher)
 	organizationUsecase := usecase.NewOrganizationUseCase(organizationRepository)
-	teamUsecase := usecase.NewTeamUseCase(teamRepository, teamMemberRepository)
+	teamUsecase := usecase.NewTeamUseCase(teamRepository, teamMemberRepository, estimateValidator)
+	boardUsecase := usecase.NewBoardUseCase(taskRepository, teamMemberRepository, taskValidator, notificationUsecase, eventPublisher)
+	calendarUsecase := usecase.NewCalendarUseCase(calendarTokenRepository, taskRepository, teamMemberRepository)
+	csvUsecase := usecase.NewCsvUseCase(taskRepository, teamMemberRepository, taskValidator, watcherUsecase)
+	archiveUsecase := usecase.NewArchiveUseCase(archiveRepository, organizationRepository, taskValidator, estimateValidator)
+	importerUsecase := usecase.NewImporterUseCase(taskRepository, userRepository, teamMemberRepository, taskValidator, watcherUsecase)
+	commentUsecase := usecase.NewCommentUseCase(commentRepository, taskRepository, mentionRepository, mentionUsecase, notificationUsecase, watcherUsecase, eventPublisher, commentValidator)
+	taskViewUsecase := usecase.NewTaskViewUseCase(taskViewRepository, teamMemberRepository, taskUsecase, taskValidator, taskViewValidator)
 	userController := controller.NewUserContoller(userUsecase)
 	taskController := controller.NewTaskController(taskUsecase)
 	organizationController := controller.NewOrganizationController(organizationUsecase)
 	teamController := controller.NewTeamController(teamUsecase)
-	e := router.NewRouter(userController, taskController, organizationController, teamController)
+	taskViewController := controller.NewTaskViewController(taskViewUsecase)
+	boardController := controller.NewBoardController(boardUsecase)
+	calendarController := controller.NewCalendarController(calendarUsecase)
+	csvController := controller.NewCsvController(csvUsecase)
+	archiveController := controller.NewArchiveController(archiveUsecase)
+	importerController := controller.NewImporterController(importerUsecase)
+	commentController := controller.NewCommentController(commentUsecase)
+	mentionController := controller.NewMentionController(mentionUsecase)
+	notificationController := controller.NewNotificationController(notificationUsecase)
+	webhookController := controller.NewWebhookController(webhookUsecase)
+	streamController := controller.NewStreamController(streamUsecase)
+	watcherController := controller.NewWatcherController(watcherUsecase)
+	sprintController := controller.NewSprintController(sprintUsecase)
+	projectController := controller.NewProjectController(projectUsecase)
+	reportController := controller.NewReportController(reportUsecase)
+	sessionController := controller.NewSessionController(sessionUsecase)
+	passwordController := controller.NewPasswordController(passwordUsecase)
+	verificationController := controller.NewVerificationController(verificationUsecase)
+	twoFactorController := controller.NewTwoFactorController(twoFactorUsecase)
+	e := router.NewRouter(userController, taskController, organizationController, teamController, taskViewController, boardController, calendarController, csvController, archiveController, importerController, commentController, mentionController, notificationController, webhookController, streamController, watcherController, sprintController, projectController, reportController, sessionController, passwordController, verificationController, twoFactorController)
+	go streamUsecase.Run(context.Background())
+	// 期限が迫ったタスクの通知と、まとめて受け取る設定のユーザーへのメールを1時間ごとに作成する
+	// 期限の過ぎたアクセストークンの拒否リストや再設定・確認用のトークン、ログインのチャレンジもあわせて削除する
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			if err := notificationUsecase.NotifyDeadlines(time.Now()); err != nil {
+				log.Printf("failed to notify deadlines: %v", err)
+			}
+			if err := notificationUsecase.SendDigests(time.Now()); err != nil {
+				log.Printf("failed to send digests: %v", err)
+			}
+			if err := sessionUsecase.PurgeRevokedTokens(time.Now()); err != nil {
+				log.Printf("failed to purge revoked tokens: %v", err)
+			}
+			if err := passwordUsecase.PurgeExpired(time.Now()); err != nil {
+				log.Printf("failed to purge password reset tokens: %v", err)
+			}
+			if err := verificationUsecase.PurgeExpired(time.Now()); err != nil {
+				log.Printf("failed to purge email verification tokens: %v", err)
+			}
+			if err := twoFactorUsecase.PurgeExpired(time.Now()); err != nil {
+				log.Printf("failed to purge login challenges: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+	// 再送待ちのWebhookとメールを30秒ごとに送る
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := webhookUsecase.ProcessDeliveries(time.Now()); err != nil {
+				log.Printf("failed to deliver webhooks: %v", err)
+			}
+			if err := emailUsecase.ProcessQueue(time.Now()); err != nil {
+				log.Printf("failed to send emails: %v", err)
+			}
+		}
+	}()
 	e.Logger.Fatal(e.Start(":8080"))
 }
\ No newline at end of file
@@ -13,6 +13,8 @@ func main() {
 	dbConn := db.CreateDB()
 	defer fmt.Println("Successfully Migrated!")
 	defer db.CloseDB(dbConn)
+	// メールアドレスの確認を導入する前からいるユーザーは確認済みとする
+	verifyExistingUsers := dbConn.Migrator().HasTable(&model.User{}) && !dbConn.Migrator().HasColumn(&model.User{}, "EmailVerifiedAt")
 	dbConn.AutoMigrate(
 		&model.User{},
 		&model.Task{},
@@ -20,7 +22,42 @@ func main() {
 		&model.Team{},
 		&model.InCharge{},
 		&model.TeamMember{},
+		&model.TaskView{},
+		&model.CalendarToken{},
+		&model.TaskComment{},
+		&model.Mention{},
+		&model.Notification{},
+		&model.NotificationPreference{},
+		&model.Webhook{},
+		&model.WebhookDelivery{},
+		&model.StreamEvent{},
+		&model.EmailJob{},
+		&model.EmailSetting{},
+		&model.TaskWatcher{},
+		&model.TaskStatusChange{},
+		&model.Sprint{},
+		&model.SprintTaskChange{},
+		&model.SprintCarryOver{},
+		&model.Project{},
+		&model.ProjectTeam{},
+		&model.Session{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.PasswordResetToken{},
+		&model.RateLimitEvent{},
+		&model.EmailVerificationToken{},
+		&model.TwoFactor{},
+		&model.RecoveryCode{},
+		&model.LoginChallenge{},
 	)
+	if verifyExistingUsers {
+		dbConn.Exec("UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL")
+	}
+	// 既存のタスクは担当者がフォローしている状態にする
+	dbConn.Exec("INSERT INTO task_watchers (task_id, user_id, created_at) SELECT task_id, user_id, NOW() FROM in_charges ON CONFLICT DO NOTHING")
+	// 履歴のない既存のタスクは作成日に未着手で作られ、更新日に今のステータスになったとみなす
+	dbConn.Exec("INSERT INTO task_status_changes (task_id, from_status, to_status, changed_at) SELECT id, 0, status, updated_at FROM tasks WHERE status <> 0 AND id NOT IN (SELECT task_id FROM task_status_changes)")
+	dbConn.Exec("INSERT INTO task_status_changes (task_id, to_status, changed_by, changed_at) SELECT id, 0, created_by, created_at FROM tasks WHERE id NOT IN (SELECT task_id FROM task_status_changes WHERE from_status IS NULL)")
 	seed(dbConn)
 }
 
@@ -35,11 +72,13 @@ func seed(db *gorm.DB) {
 		Description: "初期のデータとして作成したもの",
 		Founder: 1,
 	}
+	now := time.Now()
 	user := model.User{
 		Name: "管理会社User",
 		Email: "admin@sample.com",
 		Password: "$2a$10$E/gZvqfuDl0LedbZymuj1uqyNIspFTLysSwov6EBi3XpZKn0CGuHa",
 		OrganizationId: 2,
+		EmailVerifiedAt: &now,
 		CreatedAt: time.Now().Local(),
 		UpdatedAt: time.Now().Local(),
 	}
@@ -0,0 +1,89 @@
+package model
+
+import "time"
+
+// 組織バックアップの形式のバージョン。形式を変えたら上げる
+//  1: 組織・ユーザー・チーム・メンバー・タスク・担当者
+//  2: タスクのラベルを追加
+//  3: コメントを追加
+//  4: タスクの見積もり・ストーリーポイント、チームの見積もりスケールを追加
+const OrganizationArchiveVersion = 4
+
+// 組織単位のバックアップ。IDはバックアップ元のもので、復元時に振り直す
+type OrganizationArchive struct {
+	Version      int                 `json:"version"`
+	ExportedAt   time.Time           `json:"exported_at"`
+	Organization ArchiveOrganization `json:"organization"`
+	Users        []ArchiveUser       `json:"users"`
+	Teams        []ArchiveTeam       `json:"teams"`
+	TeamMembers  []ArchiveTeamMember `json:"team_members"`
+	Tasks        []ArchiveTask       `json:"tasks"`
+	InCharges    []ArchiveInCharge   `json:"in_charges"`
+	Comments     []ArchiveComment    `json:"comments"`
+}
+
+type ArchiveOrganization struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// パスワードはバックアップに含めない
+type ArchiveUser struct {
+	ID    uint   `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+type ArchiveTeam struct {
+	ID            uint          `json:"id"`
+	Name          string        `json:"name"`
+	Description   string        `json:"description"`
+	EstimateScale EstimateScale `json:"estimate_scale,omitempty"`
+}
+
+type ArchiveTeamMember struct {
+	TeamID    uint `json:"team_id"`
+	UserID    uint `json:"user_id"`
+	DeleteFlg bool `json:"delete_flg"`
+}
+
+type ArchiveTask struct {
+	ID        uint       `json:"id"`
+	TeamId    uint       `json:"team_id"`
+	Title     string     `json:"title"`
+	Status    TaskStatus `json:"status"`
+	Memo      string     `json:"memo"`
+	DeadLine  time.Time  `json:"dead_line"`
+	Position  string     `json:"position"`
+	Labels    []string   `json:"labels" gorm:"serializer:json"`
+	Estimate    string   `json:"estimate,omitempty"`
+	StoryPoints *float64 `json:"story_points,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type ArchiveInCharge struct {
+	TaskID uint `json:"task_id"`
+	UserID uint `json:"user_id"`
+}
+
+type ArchiveComment struct {
+	ID        uint      `json:"id"`
+	TaskId    uint      `json:"task_id"`
+	UserId    uint      `json:"user_id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OrganizationRestoreResponse struct {
+	OrganizationId uint `json:"organization_id"`
+	CreatedUsers   int  `json:"created_users"`
+	MatchedUsers   int  `json:"matched_users"`
+	Teams          int  `json:"teams"`
+	TeamMembers    int  `json:"team_members"`
+	Tasks          int  `json:"tasks"`
+	InCharges      int  `json:"in_charges"`
+	Comments       int  `json:"comments"`
+}
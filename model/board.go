@@ -0,0 +1,19 @@
+package model
+
+type BoardColumn struct {
+	Status TaskStatus     `json:"status"`
+	Name   string         `json:"name"`
+	Cards  []TaskResponse `json:"cards"`
+}
+
+type BoardResponse struct {
+	TeamId  uint          `json:"team_id"`
+	Columns []BoardColumn `json:"columns"`
+}
+
+// カードの移動先。BeforeIdは移動後に直上に来るカード、AfterIdは直下に来るカード
+type TaskMove struct {
+	Status   TaskStatus `json:"status"`
+	BeforeId uint       `json:"before_id"`
+	AfterId  uint       `json:"after_id"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// カレンダー購読用のトークン。トークン自体は保存せずハッシュのみを持つ
+type CalendarToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	TokenHash  string     `json:"-" gorm:"not null; uniqueIndex"`
+	User       User       `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId     uint       `json:"user_id" gorm:"not null"`
+	TeamId     uint       `json:"team_id" gorm:"default:0"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CalendarTokenResponse struct {
+	ID         uint       `json:"id"`
+	TeamId     uint       `json:"team_id"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// 作成時のみ返す
+	URL string `json:"url,omitempty"`
+}
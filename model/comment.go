@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+type TaskComment struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Body      string    `json:"body" gorm:"not null; size: 65535"`
+	Task      Task      `json:"task" gorm:"foreignKey:TaskId; constraint:OnDelete:CASCADE"`
+	TaskId    uint      `json:"task_id" gorm:"not null; index"`
+	User      User      `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId    uint      `json:"user_id" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TaskCommentResponse struct {
+	ID        uint              `json:"id"`
+	Body      string            `json:"body"`
+	BodyHTML  string            `json:"body_html"`
+	TaskId    uint              `json:"task_id"`
+	UserId    uint              `json:"user_id"`
+	Mentions  []MentionResponse `json:"mentions"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
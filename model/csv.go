@@ -0,0 +1,22 @@
+package model
+
+// CSVの列名とタスクの項目の対応。空の項目は既定の列名(title, status, memo, dead_line)を使う
+type CsvColumnMapping struct {
+	Title    string `json:"title"`
+	Status   string `json:"status"`
+	Memo     string `json:"memo"`
+	DeadLine string `json:"dead_line"`
+}
+
+type CsvRowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+type CsvImportResponse struct {
+	DryRun  bool           `json:"dry_run"`
+	Total   int            `json:"total"`
+	Created int            `json:"created"`
+	Errors  []CsvRowError  `json:"errors"`
+	Preview []TaskResponse `json:"preview"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+type EmailJobStatus string
+
+const (
+	EmailJobPending EmailJobStatus = "pending"
+	EmailJobSent    EmailJobStatus = "sent"
+	EmailJobFailed  EmailJobStatus = "failed"
+)
+
+// 送信待ちのメール。テンプレートは登録時に展開しておく
+type EmailJob struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	To            string         `json:"to" gorm:"not null"`
+	Subject       string         `json:"subject" gorm:"not null"`
+	Text          string         `json:"text" gorm:"type:text"`
+	HTML          string         `json:"html" gorm:"type:text"`
+	Status        EmailJobStatus `json:"status" gorm:"not null; index:idx_email_job_due"`
+	Attempts      int            `json:"attempts" gorm:"not null; default:0"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at" gorm:"index:idx_email_job_due"`
+	LastError     string         `json:"last_error" gorm:"type:text"`
+	SentAt        *time.Time     `json:"sent_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// メールの言語と、通知を1日1回まとめて受け取るかどうか
+type EmailSetting struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	User         User       `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId       uint       `json:"user_id" gorm:"not null; uniqueIndex"`
+	Language     string     `json:"language" gorm:"not null; default:'ja'"`
+	Digest       bool       `json:"digest" gorm:"not null; default:false"`
+	LastDigestAt *time.Time `json:"last_digest_at"`
+}
+
+type EmailSettingResponse struct {
+	Language string `json:"language"`
+	Digest   bool   `json:"digest"`
+}
@@ -0,0 +1,61 @@
+package model
+
+type EstimateScale string
+
+const (
+	EstimateScaleFibonacci EstimateScale = "fibonacci"
+	EstimateScaleTShirt    EstimateScale = "tshirt"
+)
+
+// 見積もりの選択肢。ベロシティはPointsで集計する
+type EstimateOption struct {
+	Label  string  `json:"label"`
+	Points float64 `json:"points"`
+}
+
+var EstimateScaleOptions = map[EstimateScale][]EstimateOption{
+	EstimateScaleFibonacci: {
+		{Label: "0", Points: 0},
+		{Label: "1", Points: 1},
+		{Label: "2", Points: 2},
+		{Label: "3", Points: 3},
+		{Label: "5", Points: 5},
+		{Label: "8", Points: 8},
+		{Label: "13", Points: 13},
+		{Label: "21", Points: 21},
+	},
+	EstimateScaleTShirt: {
+		{Label: "XS", Points: 1},
+		{Label: "S", Points: 2},
+		{Label: "M", Points: 3},
+		{Label: "L", Points: 5},
+		{Label: "XL", Points: 8},
+		{Label: "XXL", Points: 13},
+	},
+}
+
+type EstimateScaleResponse struct {
+	TeamId  uint             `json:"team_id"`
+	Scale   EstimateScale    `json:"scale"`
+	Options []EstimateOption `json:"options"`
+}
+
+// チームの見積もりの尺度を変更する: {"scale": "tshirt"}
+type EstimateScaleRequest struct {
+	Scale EstimateScale `json:"scale"`
+}
+
+// タスクを見積もる: {"estimate": "5"} (空文字で見積もりを外す)
+type TaskEstimate struct {
+	Estimate string `json:"estimate"`
+}
+
+// 尺度の選択肢から見積もりのポイントを求める
+func (s EstimateScale) Points(label string) (float64, bool) {
+	for _, v := range EstimateScaleOptions[s] {
+		if v.Label == label {
+			return v.Points, true
+		}
+	}
+	return 0, false
+}
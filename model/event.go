@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+type EventType string
+
+const (
+	EventTaskCreated    EventType = "task.created"
+	EventTaskUpdated    EventType = "task.updated"
+	EventTaskDeleted    EventType = "task.deleted"
+	EventTaskAssigned   EventType = "task.assigned"
+	EventTaskUnassigned EventType = "task.unassigned"
+	EventCommentCreated EventType = "comment.created"
+	EventCommentUpdated EventType = "comment.updated"
+	EventCommentDeleted EventType = "comment.deleted"
+)
+
+var EventTypes = []EventType{
+	EventTaskCreated,
+	EventTaskUpdated,
+	EventTaskDeleted,
+	EventTaskAssigned,
+	EventTaskUnassigned,
+	EventCommentCreated,
+	EventCommentUpdated,
+	EventCommentDeleted,
+}
+
+// タスクやコメントの変更を外部(Webhookなど)へ伝えるためのイベント
+type Event struct {
+	Type       EventType   `json:"event"`
+	TeamId     uint        `json:"team_id"`
+	TaskId     uint        `json:"task_id"`
+	ActorId    uint        `json:"actor_id"`
+	Data       interface{} `json:"data"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
@@ -0,0 +1,37 @@
+package model
+
+// 外部ツールからの取り込み設定
+type ImportOptions struct {
+	// Trelloはリスト名、GitHubはラベル名からステータスへの対応
+	StatusMapping map[string]TaskStatus `json:"status_mapping"`
+	// Trelloのusername・GitHubのloginからメールアドレスへの対応
+	MemberEmails map[string]string `json:"member_emails"`
+	// アーカイブ済みのカード・クローズ済みのIssueも取り込む
+	IncludeArchived bool `json:"include_archived"`
+	DryRun          bool `json:"dry_run"`
+}
+
+type ImportedTask struct {
+	SourceId  string     `json:"source_id"`
+	TaskId    uint       `json:"task_id"`
+	Title     string     `json:"title"`
+	Status    TaskStatus `json:"status"`
+	Labels    []string   `json:"labels"`
+	Assignees []uint     `json:"assignees"`
+	// メモが上限を超えたため本文を切り詰めた
+	MemoTruncated bool `json:"memo_truncated,omitempty"`
+}
+
+type ImportSkipped struct {
+	SourceId string `json:"source_id"`
+	Title    string `json:"title"`
+	Reason   string `json:"reason"`
+}
+
+type ImportReport struct {
+	Source           string          `json:"source"`
+	DryRun           bool            `json:"dry_run"`
+	Created          []ImportedTask  `json:"created"`
+	Skipped          []ImportSkipped `json:"skipped"`
+	UnmatchedMembers []string        `json:"unmatched_members"`
+}
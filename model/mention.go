@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// メモ・コメント内の @name / @email を解決したもの。CommentIdが0ならタスクのメモ
+type Mention struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Task        Task      `json:"task" gorm:"foreignKey:TaskId; constraint:OnDelete:CASCADE"`
+	TaskId      uint      `json:"task_id" gorm:"not null; uniqueIndex:idx_mention_source_user"`
+	CommentId   uint      `json:"comment_id" gorm:"not null; default:0; uniqueIndex:idx_mention_source_user"`
+	User        User      `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId      uint      `json:"user_id" gorm:"not null; uniqueIndex:idx_mention_source_user"`
+	MentionedBy uint      `json:"mentioned_by" gorm:"not null"`
+	Text        string    `json:"text" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type MentionResponse struct {
+	ID          uint      `json:"id"`
+	TaskId      uint      `json:"task_id"`
+	CommentId   uint      `json:"comment_id"`
+	UserId      uint      `json:"user_id"`
+	Name        string    `json:"name"`
+	Text        string    `json:"text"`
+	MentionedBy uint      `json:"mentioned_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// メンション入力時の候補
+type MentionCandidate struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	TeamMember bool   `json:"team_member"`
+}
@@ -0,0 +1,86 @@
+package model
+
+import "time"
+
+type NotificationType string
+
+const (
+	NotificationTypeAssigned      NotificationType = "assigned"
+	NotificationTypeMentioned     NotificationType = "mentioned"
+	NotificationTypeStatusChanged NotificationType = "status_changed"
+	NotificationTypeTeamJoined    NotificationType = "team_joined"
+	NotificationTypeDeadlineSoon  NotificationType = "deadline_soon"
+	NotificationTypeCommented     NotificationType = "commented"
+	NotificationTypeTaskUpdated   NotificationType = "task_updated"
+	NotificationTypeTaskDeleted   NotificationType = "task_deleted"
+)
+
+var NotificationTypes = []NotificationType{
+	NotificationTypeAssigned,
+	NotificationTypeMentioned,
+	NotificationTypeStatusChanged,
+	NotificationTypeTeamJoined,
+	NotificationTypeDeadlineSoon,
+	NotificationTypeCommented,
+	NotificationTypeTaskUpdated,
+	NotificationTypeTaskDeleted,
+}
+
+// Emailはメール(即時またはまとめて)でも知らせるもの
+// EmailOnlyはアプリ内の通知をオフにしてメールだけ受け取るもので、受信箱には表示しない
+type Notification struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	User      User             `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId    uint             `json:"user_id" gorm:"not null; index:idx_notification_user_read"`
+	Type      NotificationType `json:"type" gorm:"not null"`
+	TaskId    uint             `json:"task_id" gorm:"default:0"`
+	TeamId    uint             `json:"team_id" gorm:"default:0"`
+	ActorId   uint             `json:"actor_id" gorm:"default:0"`
+	Message   string           `json:"message" gorm:"not null"`
+	ReadAt    *time.Time       `json:"read_at" gorm:"index:idx_notification_user_read"`
+	EmailOnly bool             `json:"email_only" gorm:"not null; default:false"`
+	Email     bool             `json:"email" gorm:"not null; default:false"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type NotificationResponse struct {
+	ID        uint             `json:"id"`
+	Type      NotificationType `json:"type"`
+	TaskId    uint             `json:"task_id"`
+	TeamId    uint             `json:"team_id"`
+	ActorId   uint             `json:"actor_id"`
+	Message   string           `json:"message"`
+	ReadAt    *time.Time       `json:"read_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	UnreadCount   int64                  `json:"unread_count"`
+	Total         int64                  `json:"total"`
+	Page          int                    `json:"page"`
+	PerPage       int                    `json:"per_page"`
+}
+
+// 種類ごとの受け取り設定。行がなければ受け取る
+type NotificationPreference struct {
+	ID     uint             `json:"id" gorm:"primaryKey"`
+	User   User             `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId uint             `json:"user_id" gorm:"not null; uniqueIndex:idx_notification_preference"`
+	Type   NotificationType `json:"type" gorm:"not null; uniqueIndex:idx_notification_preference"`
+	InApp  bool             `json:"in_app" gorm:"not null; default:true"`
+	Email  bool             `json:"email" gorm:"not null; default:true"`
+}
+
+type NotificationPreferenceResponse struct {
+	Type  NotificationType `json:"type"`
+	InApp bool             `json:"in_app"`
+	Email bool             `json:"email"`
+}
+
+// 省略した項目は変更しない
+type NotificationPreferenceUpdate struct {
+	Type  NotificationType `json:"type"`
+	InApp *bool            `json:"in_app"`
+	Email *bool            `json:"email"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// パスワード再設定用のトークン。トークン自体は保存せずハッシュのみを持つ
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	User      User       `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId    uint       `json:"user_id" gorm:"not null; index"`
+	TokenHash string     `json:"-" gorm:"not null; uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
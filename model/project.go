@@ -0,0 +1,105 @@
+package model
+
+import "time"
+
+type ProjectStatus string
+
+const (
+	ProjectStatusPlanned   ProjectStatus = "planned"
+	ProjectStatusActive    ProjectStatus = "active"
+	ProjectStatusOnHold    ProjectStatus = "on_hold"
+	ProjectStatusCompleted ProjectStatus = "completed"
+	ProjectStatusCancelled ProjectStatus = "cancelled"
+)
+
+var ProjectStatuses = []ProjectStatus{
+	ProjectStatusPlanned,
+	ProjectStatusActive,
+	ProjectStatusOnHold,
+	ProjectStatusCompleted,
+	ProjectStatusCancelled,
+}
+
+// チームが持つプロジェクト。同じ組織の他のチームと共有できる
+type Project struct {
+	ID             uint          `json:"id" gorm:"primaryKey"`
+	Organization   Organization  `json:"organization" gorm:"foreignKey:OrganizationId; constraint:OnDelete:CASCADE"`
+	OrganizationId uint          `json:"organization_id" gorm:"not null; index"`
+	Team           Team          `json:"team" gorm:"foreignKey:TeamId; constraint:OnDelete:CASCADE"`
+	TeamId         uint          `json:"team_id" gorm:"not null; index"`
+	Name           string        `json:"name" gorm:"not null"`
+	Description    string        `json:"description" gorm:"size: 65535"`
+	Status         ProjectStatus `json:"status" gorm:"not null; default:'planned'"`
+	StartDate      *time.Time    `json:"start_date" gorm:"type:date"`
+	EndDate        *time.Time    `json:"end_date" gorm:"type:date"`
+	CreatedBy      uint          `json:"created_by" gorm:"not null"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	// 作成時に共有するチーム
+	SharedTeamIds []uint `json:"shared_team_ids" gorm:"-"`
+}
+
+// プロジェクトを共有しているチーム(持ち主のチームは含まない)
+type ProjectTeam struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Project   Project   `json:"project" gorm:"foreignKey:ProjectId; constraint:OnDelete:CASCADE"`
+	ProjectId uint      `json:"project_id" gorm:"not null; uniqueIndex:idx_project_team"`
+	Team      Team      `json:"team" gorm:"foreignKey:TeamId; constraint:OnDelete:CASCADE"`
+	TeamId    uint      `json:"team_id" gorm:"not null; uniqueIndex:idx_project_team; index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ProjectResponse struct {
+	ID             uint            `json:"id"`
+	OrganizationId uint            `json:"organization_id"`
+	TeamId         uint            `json:"team_id"`
+	SharedTeamIds  []uint          `json:"shared_team_ids"`
+	Name           string          `json:"name"`
+	Description    string          `json:"description"`
+	Status         ProjectStatus   `json:"status"`
+	StartDate      *time.Time      `json:"start_date"`
+	EndDate        *time.Time      `json:"end_date"`
+	Progress       ProjectProgress `json:"progress"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// プロジェクトのタスクの進捗
+type ProjectProgress struct {
+	Total          int     `json:"total"`
+	Unstarted      int     `json:"unstarted"`
+	Started        int     `json:"started"`
+	Completed      int     `json:"completed"`
+	Overdue        int     `json:"overdue"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+type ProjectTeamProgress struct {
+	TeamId   uint            `json:"team_id"`
+	Progress ProjectProgress `json:"progress"`
+}
+
+type ProjectProgressResponse struct {
+	ProjectId uint                  `json:"project_id"`
+	Progress  ProjectProgress       `json:"progress"`
+	Teams     []ProjectTeamProgress `json:"teams"`
+}
+
+// プロジェクト・チーム・ステータスごとのタスク数(SQLで集計する)
+type ProjectTaskCount struct {
+	ProjectId uint
+	TeamId    uint
+	Status    TaskStatus
+	Count     int
+	Overdue   int
+}
+
+// 共有するチームを置き換える: {"team_ids": [2, 3]}
+type ProjectTeamsRequest struct {
+	TeamIds []uint `json:"team_ids"`
+}
+
+// プロジェクトにタスクを追加する: {"task_ids": [1, 2]}
+type ProjectTasksRequest struct {
+	TaskIds []uint `json:"task_ids"`
+}
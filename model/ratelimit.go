@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// 回数を制限する操作の記録。Key は "password_reset:email:{メールアドレス}" のように操作と対象を表す
+type RateLimitEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Key       string    `json:"key" gorm:"not null; index:idx_rate_limit_event"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_rate_limit_event"`
+}
+
+// Window の間に Limit 回まで Key の操作を許可する
+type RateLimit struct {
+	Key    string
+	Limit  int64
+	Window time.Duration
+}
@@ -0,0 +1,215 @@
+package model
+
+import "time"
+
+const (
+	VelocityPeriodWeek   = "week"
+	VelocityPeriodSprint = "sprint"
+)
+
+// ベロシティの集計条件。Countは直近の期間(週またはスプリント)の数
+type VelocityQuery struct {
+	Period string `json:"period"`
+	Count  int    `json:"count"`
+}
+
+// 週ごとに完了したタスク数とポイント(SQLで集計する)
+type WeeklyCompletion struct {
+	PeriodStart time.Time
+	Points      float64
+	Tasks       int
+}
+
+type VelocityPeriod struct {
+	Label    string    `json:"label"`
+	SprintId uint      `json:"sprint_id,omitempty"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	// スプリントの場合は開始時のスコープのポイント
+	CommittedPoints *float64 `json:"committed_points,omitempty"`
+	CompletedPoints float64  `json:"completed_points"`
+	CompletedTasks  int      `json:"completed_tasks"`
+	// この期間までの直近3期間の完了ポイントの平均
+	RollingAverage float64 `json:"rolling_average"`
+}
+
+type VelocityResponse struct {
+	TeamId        uint             `json:"team_id"`
+	Period        string           `json:"period"`
+	Periods       []VelocityPeriod `json:"periods"`
+	AveragePoints float64          `json:"average_points"`
+	AverageTasks  float64          `json:"average_tasks"`
+	// 1期間あたりの完了ポイントの増減(最小二乗法の傾き)
+	Trend float64 `json:"trend"`
+}
+
+const (
+	StatsIntervalDay  = "day"
+	StatsIntervalWeek = "week"
+)
+
+// 統計の集計条件。期間は YYYY-MM-DD で指定し、To の日を含む
+type StatsQuery struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Interval string `json:"interval"`
+}
+
+// 集計の対象。TeamId を指定した場合はそのチーム、それ以外は組織内のすべてのチーム
+type StatsScope struct {
+	TeamId         uint
+	OrganizationId uint
+}
+
+// 現在のステータスごとのタスク数と期限切れのタスク数
+type TaskCounts struct {
+	TeamId    uint `json:"team_id,omitempty"`
+	Total     int  `json:"total"`
+	Unstarted int  `json:"unstarted"`
+	Started   int  `json:"started"`
+	Completed int  `json:"completed"`
+	Overdue   int  `json:"overdue"`
+}
+
+// 期間内に作成・完了したタスク数
+// CreatedCompletedは期間内に作成したタスクのうち、今完了しているもの
+type RangeCounts struct {
+	Created          int
+	Completed        int
+	CreatedCompleted int
+}
+
+type ThroughputPoint struct {
+	Date      time.Time `json:"date"`
+	Created   int       `json:"created"`
+	Completed int       `json:"completed"`
+}
+
+type TaskStatsResponse struct {
+	TeamId         uint              `json:"team_id,omitempty"`
+	OrganizationId uint              `json:"organization_id,omitempty"`
+	From           string            `json:"from"`
+	To             string            `json:"to"`
+	Interval       string            `json:"interval"`
+	Current        TaskCounts        `json:"current"`
+	Created        int               `json:"created"`
+	Completed      int               `json:"completed"`
+	// 期間内に作成したタスクのうち完了したものの割合
+	CompletionRate float64           `json:"completion_rate"`
+	Series         []ThroughputPoint `json:"series"`
+	// 組織の場合はチームごとの現在のタスク数
+	Teams []TaskCounts `json:"teams,omitempty"`
+}
+
+// 期限までの残りで分けた区分
+const (
+	DeadlineBucketOverdue  = "overdue"
+	DeadlineBucketThisWeek = "this_week"
+	DeadlineBucketLater    = "later"
+)
+
+// 担当者ごとの未完了タスク。担当者のいないタスクは UserId が0
+type AssignedTask struct {
+	UserId      uint
+	TaskId      uint
+	Title       string
+	Status      TaskStatus
+	DeadLine    time.Time
+	Estimate    string
+	StoryPoints *float64
+}
+
+type WorkloadTask struct {
+	ID             uint       `json:"id"`
+	Title          string     `json:"title"`
+	Status         TaskStatus `json:"status"`
+	DeadLine       time.Time  `json:"dead_line"`
+	DeadlineBucket string     `json:"deadline_bucket"`
+	Estimate       string     `json:"estimate"`
+	StoryPoints    *float64   `json:"story_points"`
+}
+
+// 未完了タスクのステータス、期限、見積もりごとの内訳
+type WorkloadSummary struct {
+	OpenTasks        int     `json:"open_tasks"`
+	Unstarted        int     `json:"unstarted"`
+	Started          int     `json:"started"`
+	Overdue          int     `json:"overdue"`
+	DueThisWeek      int     `json:"due_this_week"`
+	DueLater         int     `json:"due_later"`
+	Points           float64 `json:"points"`
+	UnestimatedTasks int     `json:"unestimated_tasks"`
+}
+
+type MemberWorkload struct {
+	User    UserResponse    `json:"user"`
+	Summary WorkloadSummary `json:"summary"`
+	// メンバーの平均ポイントに対する割合。1より大きいほど負荷が高い
+	LoadRatio float64        `json:"load_ratio"`
+	Tasks     []WorkloadTask `json:"tasks"`
+}
+
+type WorkloadResponse struct {
+	TeamId uint `json:"team_id"`
+	// 今週の期限の区切り(次の月曜日)
+	WeekEnd       time.Time        `json:"week_end"`
+	AveragePoints float64          `json:"average_points"`
+	Members       []MemberWorkload `json:"members"`
+	// 担当者がいないか、担当者が全員チームを抜けたタスク
+	Unassigned    WorkloadSummary  `json:"unassigned"`
+}
+
+// 期間内に完了したタスクの作成・着手・完了の日時
+// 着手せずに完了したタスクは StartedAt が nil
+type TaskFlowTimes struct {
+	TaskId      uint
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	CompletedAt time.Time
+}
+
+// 所要日数のヒストグラムの階級。MaxDays が nil の場合は上限なし
+type DurationBucket struct {
+	MinDays float64  `json:"min_days"`
+	MaxDays *float64 `json:"max_days"`
+	Count   int      `json:"count"`
+}
+
+// 所要日数の統計。日数は小数で表す
+type DurationStats struct {
+	Count       int              `json:"count"`
+	AverageDays float64          `json:"average_days"`
+	MinDays     float64          `json:"min_days"`
+	MaxDays     float64          `json:"max_days"`
+	P50Days     float64          `json:"p50_days"`
+	P75Days     float64          `json:"p75_days"`
+	P85Days     float64          `json:"p85_days"`
+	P95Days     float64          `json:"p95_days"`
+	Histogram   []DurationBucket `json:"histogram"`
+}
+
+type CycleTimeResponse struct {
+	TeamId uint   `json:"team_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	// 作成から完了まで
+	LeadTime DurationStats `json:"lead_time"`
+	// 着手から完了まで
+	CycleTime DurationStats `json:"cycle_time"`
+}
+
+// 各期間の終わりの時点でのステータスごとのタスク数。Date は期間の開始日
+type CumulativeFlowPoint struct {
+	Date      time.Time `json:"date"`
+	Unstarted int       `json:"unstarted"`
+	Started   int       `json:"started"`
+	Completed int       `json:"completed"`
+}
+
+type CumulativeFlowResponse struct {
+	TeamId   uint                  `json:"team_id"`
+	From     string                `json:"from"`
+	To       string                `json:"to"`
+	Interval string                `json:"interval"`
+	Points   []CumulativeFlowPoint `json:"points"`
+}
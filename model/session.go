@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+// セッションを失効させた理由
+const (
+	SessionRevokedLogout = "logout"
+	SessionRevokedByUser = "revoked"
+	// 使用済みのリフレッシュトークンが再び使われた
+	SessionRevokedReused = "reused"
+	// 組織の作成者による強制ログアウト
+	SessionRevokedByAdmin         = "force_logout"
+	SessionRevokedPasswordChanged = "password_changed"
+)
+
+// ログインごとのセッション。リフレッシュトークンを使うたびに有効期限を延ばす
+type Session struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	User          User       `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId        uint       `json:"user_id" gorm:"not null; index"`
+	UserAgent     string     `json:"user_agent" gorm:"not null; default:''"`
+	IPAddress     string     `json:"ip_address" gorm:"not null; default:''"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	RevokedReason string     `json:"revoked_reason" gorm:"not null; default:''"`
+	// 最後に発行したアクセストークン。セッションを失効させるときに拒否リストに入れる
+	AccessJti       string    `json:"-" gorm:"not null; default:''"`
+	AccessExpiresAt time.Time `json:"-"`
+}
+
+// 期限前に無効にしたアクセストークン(jti)の拒否リスト。期限が過ぎたものは削除する
+type RevokedToken struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Jti       string    `json:"jti" gorm:"not null; uniqueIndex"`
+	UserId    uint      `json:"user_id" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null; index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// セッションで発行したリフレッシュトークン。トークン自体は保存せずハッシュのみを持つ
+// 使うたびに使用済みにして新しいトークンを発行する
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Session   Session    `json:"session" gorm:"foreignKey:SessionId; constraint:OnDelete:CASCADE"`
+	SessionId uint       `json:"session_id" gorm:"not null; index"`
+	TokenHash string     `json:"-" gorm:"not null; uniqueIndex"`
+	UsedAt    *time.Time `json:"used_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ログインしている端末の情報
+type SessionClient struct {
+	UserAgent string
+	IPAddress string
+}
+
+// ログイン・リフレッシュで発行するトークン。どちらもCookieで返す
+type AuthTokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// リクエストしたセッション自身かどうか
+	Current bool `json:"current"`
+}
@@ -0,0 +1,115 @@
+package model
+
+import "time"
+
+type SprintState string
+
+const (
+	SprintStatePlanned SprintState = "planned"
+	SprintStateActive  SprintState = "active"
+	SprintStateClosed  SprintState = "closed"
+)
+
+// スプリントの期間(終了日を含む日数)の上限。バーンダウンは1日ごとの値を作るため長さを制限する
+const MaxSprintDays = 366
+
+// チームのスプリント(マイルストーン)。期間は開始日から終了日まで(終了日を含む)
+type Sprint struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Team      Team       `json:"team" gorm:"foreignKey:TeamId; constraint:OnDelete:CASCADE"`
+	TeamId    uint       `json:"team_id" gorm:"not null; index"`
+	Name      string     `json:"name" gorm:"not null"`
+	Goal      string     `json:"goal" gorm:"size: 65535"`
+	StartDate time.Time  `json:"start_date" gorm:"not null; type:date"`
+	EndDate   time.Time  `json:"end_date" gorm:"not null; type:date"`
+	ClosedAt  *time.Time `json:"closed_at"`
+	CreatedBy uint       `json:"created_by" gorm:"not null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type SprintResponse struct {
+	ID        uint        `json:"id"`
+	TeamId    uint        `json:"team_id"`
+	Name      string      `json:"name"`
+	Goal      string      `json:"goal"`
+	StartDate time.Time   `json:"start_date"`
+	EndDate   time.Time   `json:"end_date"`
+	State     SprintState `json:"state"`
+	ClosedAt  *time.Time  `json:"closed_at"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// スプリントへのタスクの追加・除外の履歴。期間中のスコープの推移を求めるのに使う
+type SprintTaskChange struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Sprint    Sprint    `json:"sprint" gorm:"foreignKey:SprintId; constraint:OnDelete:CASCADE"`
+	SprintId  uint      `json:"sprint_id" gorm:"not null; index:idx_sprint_task_change"`
+	Task      Task      `json:"task" gorm:"foreignKey:TaskId; constraint:OnDelete:CASCADE"`
+	TaskId    uint      `json:"task_id" gorm:"not null"`
+	Added     bool      `json:"added" gorm:"not null"`
+	ChangedAt time.Time `json:"changed_at" gorm:"not null; index:idx_sprint_task_change"`
+}
+
+// スプリントの終了時に完了していなかったタスク。ToSprintIdが0の場合はバックログに戻した
+type SprintCarryOver struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Sprint     Sprint    `json:"sprint" gorm:"foreignKey:SprintId; constraint:OnDelete:CASCADE"`
+	SprintId   uint      `json:"sprint_id" gorm:"not null; index"`
+	Task       Task      `json:"task" gorm:"foreignKey:TaskId; constraint:OnDelete:CASCADE"`
+	TaskId     uint      `json:"task_id" gorm:"not null"`
+	ToSprintId uint      `json:"to_sprint_id" gorm:"default:0"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// スプリントにタスクを追加する: {"task_ids": [1, 2]}
+type SprintTasksRequest struct {
+	TaskIds []uint `json:"task_ids"`
+}
+
+// スプリントを終了する: {"carry_over_to": 3} (0または省略時は未完了のタスクをバックログに戻す)
+type SprintCloseRequest struct {
+	CarryOverTo uint `json:"carry_over_to"`
+}
+
+type SprintCarryOverResponse struct {
+	TaskId     uint   `json:"task_id"`
+	Title      string `json:"title"`
+	ToSprintId uint   `json:"to_sprint_id"`
+}
+
+// スプリントのスコープと完了状況
+// Committedは開始日の終わりの時点のスコープ、Added/Removedはそれ以降に追加・除外したタスク
+type SprintReportResponse struct {
+	Sprint         SprintResponse            `json:"sprint"`
+	Committed      []uint                    `json:"committed"`
+	Added          []uint                    `json:"added"`
+	Removed        []uint                    `json:"removed"`
+	Completed      []uint                    `json:"completed"`
+	Incomplete     []uint                    `json:"incomplete"`
+	CarriedOver    []SprintCarryOverResponse `json:"carried_over"`
+	CompletionRate float64                   `json:"completion_rate"`
+	// 見積もりのあるタスクのストーリーポイントの合計
+	CommittedPoints float64 `json:"committed_points"`
+	CompletedPoints float64 `json:"completed_points"`
+}
+
+// チャートの単位。tasks(既定)はタスク数、pointsはストーリーポイントの合計
+const (
+	ChartUnitTasks  = "tasks"
+	ChartUnitPoints = "points"
+)
+
+// 日ごとの値は各日の終わり(進行中の日は現在)の時点のもの。まだ来ていない日はnull
+type BurndownPoint struct {
+	Date      time.Time `json:"date"`
+	Remaining *float64  `json:"remaining"`
+	Ideal     float64   `json:"ideal"`
+}
+
+type BurnupPoint struct {
+	Date      time.Time `json:"date"`
+	Scope     *float64  `json:"scope"`
+	Completed *float64  `json:"completed"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// リアルタイム配信用に保存するイベント。IDの順に配信し、再接続時はLast-Event-ID以降を送り直す
+type StreamEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TeamId    uint      `json:"team_id" gorm:"not null; index"`
+	Type      EventType `json:"event" gorm:"not null"`
+	TaskId    uint      `json:"task_id"`
+	ActorId   uint      `json:"actor_id"`
+	Payload   string    `json:"payload" gorm:"not null; type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
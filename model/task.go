@@ -2,6 +2,9 @@ package model
 
 import "time"
 
+// タスクのタイトルの文字数上限
+const MaxTaskTitleLength = 10
+
 type Task struct {
 	ID        uint       `json:"id" gorm:"primaryKey"`
 	Title     string     `json:"title" gorm:"not null"`
@@ -10,8 +13,19 @@ type Task struct {
 	DeadLine  time.Time  `json:"dead_line" gorm:"not null; default:CURRENT_TIMESTAMP; type:date"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
+	// 更新のたびに増える版数。カレンダーのSEQUENCEに使う
+	Sequence  int        `json:"-" gorm:"not null; default:0"`
+	Position  string     `json:"position" gorm:"not null; default:''; index"`
+	Labels    []string   `json:"labels" gorm:"serializer:json; type:text"`
 	Team			Team       `json:"team" gorm:"foreignKey:TeamId; constraint:OnDelete:CASCADE"`
 	TeamId    uint       `json:"team_id" gorm:"not null"`
+	CreatedBy uint       `json:"created_by" gorm:"not null; default:0"`
+	// 未設定の場合はバックログ
+	SprintId  *uint      `json:"sprint_id" gorm:"index"`
+	ProjectId *uint      `json:"project_id" gorm:"index"`
+	// チームの尺度で選んだ見積もりと、そのポイント。未見積もりの場合は空文字とnil
+	Estimate    string   `json:"estimate" gorm:"not null; default:''"`
+	StoryPoints *float64 `json:"story_points"`
 }
 
 type TaskResponse struct {
@@ -19,9 +33,21 @@ type TaskResponse struct {
 	Title     string     `json:"title" gorm:"not null"`
 	Status    TaskStatus `json:"status" gorm:"not null; default:0"`
 	Memo      string     `json:"memo" gorm:"size: 65535"`
+	MemoHTML  string     `json:"memo_html"`
 	DeadLine  time.Time  `json:"dead_line" gorm:"not null; default:CURRENT_TIMESTAMP; type:date"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
+	Position  string     `json:"position"`
+	Labels    []string   `json:"labels"`
+	SprintId  *uint      `json:"sprint_id"`
+	ProjectId *uint      `json:"project_id"`
+	Estimate    string   `json:"estimate"`
+	StoryPoints *float64 `json:"story_points"`
+}
+
+// メモ内のタスクリストのチェックボックスの状態
+type TaskMemoCheckbox struct {
+	Checked bool `json:"checked"`
 }
 
 type TaskStatus int
@@ -32,3 +58,31 @@ const (
 	TaskStatusCompleted
 )
 
+// ボードの列として並べるステータスの順番
+var TaskStatuses = []TaskStatus{TaskStatusUnstarted, TaskStatusStarted, TaskStatusCompleted}
+
+func (s TaskStatus) String() string {
+	switch s {
+	case TaskStatusUnstarted:
+		return "Unstarted"
+	case TaskStatusStarted:
+		return "Started"
+	case TaskStatusCompleted:
+		return "Completed"
+	}
+	return "Unknown"
+}
+
+// タスク一覧の絞り込み・並び替え条件
+type TaskQuery struct {
+	TeamId       uint         `json:"team_id,omitempty"`
+	ProjectId    uint         `json:"project_id,omitempty"`
+	Statuses     []TaskStatus `json:"statuses,omitempty"`
+	Keyword      string       `json:"keyword,omitempty"`
+	DeadlineFrom string       `json:"deadline_from,omitempty"`
+	DeadlineTo   string       `json:"deadline_to,omitempty"`
+	Overdue      bool         `json:"overdue,omitempty"`
+	AssignedToMe bool         `json:"assigned_to_me,omitempty"`
+	SortBy       string       `json:"sort_by,omitempty"`
+	Order        string       `json:"order,omitempty"`
+}
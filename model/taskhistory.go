@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// タスクのステータスの変更履歴。作成時はFromStatusがnil
+type TaskStatusChange struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	Task       Task        `json:"task" gorm:"foreignKey:TaskId; constraint:OnDelete:CASCADE"`
+	TaskId     uint        `json:"task_id" gorm:"not null; index:idx_task_status_change"`
+	FromStatus *TaskStatus `json:"from_status"`
+	ToStatus   TaskStatus  `json:"to_status" gorm:"not null"`
+	ChangedBy  uint        `json:"changed_by" gorm:"default:0"`
+	ChangedAt  time.Time   `json:"changed_at" gorm:"not null; index:idx_task_status_change"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type TaskView struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	Query     TaskQuery `json:"query" gorm:"serializer:json"`
+	User      User      `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId    uint      `json:"user_id" gorm:"not null"`
+	TeamId    uint      `json:"team_id" gorm:"default:0"`
+	Pinned    bool      `json:"pinned" gorm:"default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TaskViewResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Query     TaskQuery `json:"query"`
+	UserId    uint      `json:"user_id"`
+	TeamId    uint      `json:"team_id"`
+	Pinned    bool      `json:"pinned"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
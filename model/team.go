@@ -6,6 +6,8 @@ type Team struct {
 	Description    string       `json:"description" gorm:"size: 65535"`
 	Organization   Organization `json:"organization" gorm:"foreignKey:OrganizationId; constraint:OnDelete:CASCADE"`
 	OrganizationId uint         `json:"organization_id" gorm:"not null"`
+	// タスクの見積もりに使う尺度
+	EstimateScale  EstimateScale `json:"estimate_scale" gorm:"not null; default:'fibonacci'"`
 }
 
 type TeamResponse struct {
@@ -0,0 +1,86 @@
+package model
+
+import "time"
+
+// ユーザーのTOTPの秘密鍵。有効にするまでは EnabledAt が nil
+type TwoFactor struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	User   User   `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId uint   `json:"user_id" gorm:"not null; uniqueIndex"`
+	Secret string `json:"-" gorm:"not null"`
+	// 最後に使ったコードの時間ステップ。同じコードを2回使えないようにする
+	LastUsedStep int64      `json:"-" gorm:"not null; default:0"`
+	EnabledAt    *time.Time `json:"enabled_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// 認証アプリを使えないときのための一度だけ使えるコード。ハッシュのみを持つ
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	User      User       `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId    uint       `json:"user_id" gorm:"not null; index"`
+	CodeHash  string     `json:"-" gorm:"not null; uniqueIndex"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// パスワードを確認した後、2段階目のコードを待っているログイン
+type LoginChallenge struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	User      User       `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId    uint       `json:"user_id" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"not null; uniqueIndex"`
+	Attempts  int        `json:"attempts" gorm:"not null; default:0"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ログインの結果。2段階認証が有効な場合はセッションを作らずにチャレンジを返す
+type LoginResult struct {
+	Tokens         AuthTokens
+	ChallengeToken string
+}
+
+type LoginChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+}
+
+// Code には認証アプリの6桁のコードか、リカバリーコードを指定する
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+type TwoFactorSetupRequest struct {
+	CurrentPassword string `json:"current_password"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// 無効にする・リカバリーコードを作り直す場合はパスワードとコードの両方で本人確認する
+type TwoFactorReauthRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret"`
+	// QRコードにして認証アプリで読み取る otpauth:// のURI
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// リカバリーコードは作成時にしか返さない
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
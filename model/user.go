@@ -9,6 +9,10 @@ type User struct {
 	Name           string       `json:"name"`
 	Organization   Organization `json:"organization" gorm:"foreignKey:OrganizationId; constraint:OnDelete:CASCADE"`
 	OrganizationId uint         `json:"organization_id" gorm:"default:1"`
+	// メールアドレスを確認するまでは nil
+	EmailVerifiedAt *time.Time  `json:"email_verified_at"`
+	// 復元で仮のメールアドレスで作成したユーザーの元のメールアドレス。持ち主が確認すると引き継ぐ
+	ClaimEmail     string       `json:"-" gorm:"not null; default:''; index"`
 	CreatedAt      time.Time    `json:"created_at"`
 	UpdatedAt      time.Time    `json:"updated_at"`
 }
@@ -17,6 +21,8 @@ type UserResponse struct {
 	ID    uint   `json:"id" gorm:"primaryKey"`
 	Email string `json:"email" gorm:"unique"`
 	Name  string `json:"name"`
+	// 本人の情報を返す場合のみ
+	EmailVerified *bool `json:"email_verified,omitempty"`
 }
 
 type UserAssignResponse struct {
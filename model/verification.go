@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// メールアドレス確認用のトークン。トークン自体は保存せずハッシュのみを持つ
+// 確認すると Email がユーザーのメールアドレスになる
+type EmailVerificationToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	User      User       `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId    uint       `json:"user_id" gorm:"not null; index"`
+	Email     string     `json:"email" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"not null; uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// 新しいメールアドレスは確認するまで反映しない
+type ChangeEmailRequest struct {
+	Email           string `json:"email"`
+	CurrentPassword string `json:"current_password"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// タスクをフォローしているユーザー。タスクの変更の通知を受け取る
+type TaskWatcher struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Task      Task      `json:"task" gorm:"foreignKey:TaskId; constraint:OnDelete:CASCADE"`
+	TaskId    uint      `json:"task_id" gorm:"not null; uniqueIndex:idx_task_watcher"`
+	User      User      `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId    uint      `json:"user_id" gorm:"not null; uniqueIndex:idx_task_watcher; index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TaskWatchResponse struct {
+	TaskId   uint           `json:"task_id"`
+	Watching bool           `json:"watching"`
+	Watchers []UserResponse `json:"watchers"`
+}
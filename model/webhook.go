@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// TeamIdが0の場合は組織内のすべてのチームのイベントを受け取る
+type Webhook struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	Organization   Organization `json:"organization" gorm:"foreignKey:OrganizationId; constraint:OnDelete:CASCADE"`
+	OrganizationId uint         `json:"organization_id" gorm:"not null; index"`
+	TeamId         uint         `json:"team_id" gorm:"default:0; index"`
+	URL            string       `json:"url" gorm:"not null"`
+	Secret         string       `json:"-" gorm:"not null"`
+	Events         []EventType  `json:"events" gorm:"serializer:json; type:text"`
+	Active         bool         `json:"active" gorm:"not null; default:true"`
+	// 連続して配信に失敗した回数。上限に達すると無効にする
+	FailureCount int        `json:"failure_count" gorm:"not null; default:0"`
+	DisabledAt   *time.Time `json:"disabled_at"`
+	CreatedBy    uint       `json:"created_by" gorm:"not null"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type WebhookResponse struct {
+	ID             uint        `json:"id"`
+	OrganizationId uint        `json:"organization_id"`
+	TeamId         uint        `json:"team_id"`
+	URL            string      `json:"url"`
+	Events         []EventType `json:"events"`
+	Active         bool        `json:"active"`
+	FailureCount   int         `json:"failure_count"`
+	DisabledAt     *time.Time  `json:"disabled_at"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	// 署名用のシークレット。作成時のみ返す
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookDelivery struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	Webhook        Webhook               `json:"webhook" gorm:"foreignKey:WebhookId; constraint:OnDelete:CASCADE"`
+	WebhookId      uint                  `json:"webhook_id" gorm:"not null; index"`
+	Event          EventType             `json:"event" gorm:"not null"`
+	Payload        string                `json:"payload" gorm:"not null; type:text"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"not null; index:idx_webhook_delivery_due"`
+	Attempts       int                   `json:"attempts" gorm:"not null; default:0"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_due"`
+	ResponseStatus int                   `json:"response_status"`
+	ResponseBody   string                `json:"response_body" gorm:"type:text"`
+	Error          string                `json:"error" gorm:"type:text"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint                  `json:"id"`
+	WebhookId      uint                  `json:"webhook_id"`
+	Event          EventType             `json:"event"`
+	Payload        string                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at"`
+	ResponseStatus int                   `json:"response_status"`
+	ResponseBody   string                `json:"response_body"`
+	Error          string                `json:"error"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"go-rest-api/model"
+
+	"gorm.io/gorm"
+)
+
+// 対応付けできなかったユーザーはログインできないパスワードで作成する
+const unusablePassword = "!"
+
+// 対応付けできなかったユーザーの仮のメールアドレス。
+// 実在のアドレスを使うと持ち主が登録していないアドレスを占有してしまうため、配送されない .invalid ドメインにする
+// 元のメールアドレスは ClaimEmail に残し、持ち主が確認すると引き継げるようにする
+const placeholderEmailFormat = "restored-%d-%d@placeholder.invalid"
+
+type IArchiveRepository interface {
+	// 組織のチーム・メンバー・タスク・担当者・コメントをまとめて取得する
+	GetOrganizationArchive(archive *model.OrganizationArchive, organizationId uint) error
+	// バックアップを新しい組織として1つのトランザクションで復元する
+	RestoreOrganizationArchive(archive *model.OrganizationArchive, founderId uint, result *model.OrganizationRestoreResponse) error
+}
+
+type archiveRepository struct {
+	db *gorm.DB
+}
+
+func NewArchiveRepository(db *gorm.DB) IArchiveRepository {
+	return &archiveRepository{db}
+}
+
+func (ar *archiveRepository) GetOrganizationArchive(archive *model.OrganizationArchive, organizationId uint) error {
+	organization := model.Organization{}
+	if err := ar.db.First(&organization, organizationId).Error; err != nil {
+		return err
+	}
+	archive.Organization = model.ArchiveOrganization{ID: organization.ID, Name: organization.Name, Description: organization.Description}
+
+	teamIds := ar.db.Model(&model.Team{}).Select("id").Where("organization_id=?", organizationId)
+	taskIds := ar.db.Model(&model.Task{}).Select("id").Where("team_id IN (?)", teamIds)
+
+	if err := ar.db.Model(&model.Team{}).Where("organization_id=?", organizationId).Order("id").Find(&archive.Teams).Error; err != nil {
+		return err
+	}
+	if err := ar.db.Model(&model.TeamMember{}).Where("team_id IN (?)", teamIds).Order("id").Find(&archive.TeamMembers).Error; err != nil {
+		return err
+	}
+	if err := ar.db.Model(&model.Task{}).Where("team_id IN (?)", teamIds).Order("id").Find(&archive.Tasks).Error; err != nil {
+		return err
+	}
+	if err := ar.db.Model(&model.InCharge{}).Where("task_id IN (?)", taskIds).Order("id").Find(&archive.InCharges).Error; err != nil {
+		return err
+	}
+	if err := ar.db.Model(&model.TaskComment{}).Where("task_id IN (?)", taskIds).Order("id").Find(&archive.Comments).Error; err != nil {
+		return err
+	}
+	// 組織に所属するユーザーと、他の組織からチームに参加しているユーザー
+	if err := ar.db.Model(&model.User{}).
+		Where("organization_id=?", organizationId).
+		Or("id IN (?)", ar.db.Model(&model.TeamMember{}).Select("user_id").Where("team_id IN (?)", teamIds)).
+		Or("id IN (?)", ar.db.Model(&model.InCharge{}).Select("user_id").Where("task_id IN (?)", taskIds)).
+		Or("id IN (?)", ar.db.Model(&model.TaskComment{}).Select("user_id").Where("task_id IN (?)", taskIds)).
+		Order("id").Find(&archive.Users).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ar *archiveRepository) RestoreOrganizationArchive(archive *model.OrganizationArchive, founderId uint, result *model.OrganizationRestoreResponse) error {
+	return ar.db.Transaction(func(tx *gorm.DB) error {
+		organization := model.Organization{Name: archive.Organization.Name, Description: archive.Organization.Description, Founder: founderId}
+		if err := tx.Create(&organization).Error; err != nil {
+			return err
+		}
+		result.OrganizationId = organization.ID
+
+		// 既存のユーザーは復元を実行したユーザー本人か、そのユーザーが作成した組織の所属者に限りメールアドレスで対応付ける。
+		// それ以外は新しい組織に仮のユーザーとして作成する
+		foundedIds := tx.Model(&model.Organization{}).Select("id").Where("founder=?", founderId)
+		userIds := map[uint]uint{}
+		for _, v := range archive.Users {
+			user := model.User{}
+			err := tx.Where("email=?", v.Email).Where(tx.Where("id=?", founderId).Or("organization_id IN (?)", foundedIds)).First(&user).Error
+			if err == nil {
+				userIds[v.ID] = user.ID
+				result.MatchedUsers++
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			user = model.User{Email: fmt.Sprintf(placeholderEmailFormat, organization.ID, v.ID), Name: v.Name, Password: unusablePassword, OrganizationId: organization.ID, ClaimEmail: v.Email}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			userIds[v.ID] = user.ID
+			result.CreatedUsers++
+		}
+
+		teamIds := map[uint]uint{}
+		for _, v := range archive.Teams {
+			team := model.Team{Name: v.Name, Description: v.Description, OrganizationId: organization.ID, EstimateScale: v.EstimateScale}
+			if team.EstimateScale == "" {
+				team.EstimateScale = model.EstimateScaleFibonacci
+			}
+			if err := tx.Create(&team).Error; err != nil {
+				return err
+			}
+			teamIds[v.ID] = team.ID
+			result.Teams++
+		}
+
+		for _, v := range archive.TeamMembers {
+			teamId, ok := teamIds[v.TeamID]
+			if !ok {
+				return fmt.Errorf("team member references unknown team %d", v.TeamID)
+			}
+			userId, ok := userIds[v.UserID]
+			if !ok {
+				return fmt.Errorf("team member references unknown user %d", v.UserID)
+			}
+			teamMember := model.TeamMember{TeamID: teamId, UserID: userId, DeleteFlg: v.DeleteFlg}
+			if err := tx.Create(&teamMember).Error; err != nil {
+				return err
+			}
+			result.TeamMembers++
+		}
+
+		taskIds := map[uint]uint{}
+		for _, v := range archive.Tasks {
+			teamId, ok := teamIds[v.TeamId]
+			if !ok {
+				return fmt.Errorf("task %d references unknown team %d", v.ID, v.TeamId)
+			}
+			task := model.Task{
+				Title: v.Title,
+				Status: v.Status,
+				Memo: v.Memo,
+				DeadLine: v.DeadLine,
+				Position: v.Position,
+				Labels: v.Labels,
+				Estimate: v.Estimate,
+				StoryPoints: v.StoryPoints,
+				CreatedAt: v.CreatedAt,
+				UpdatedAt: v.UpdatedAt,
+				TeamId: teamId,
+			}
+			if err := tx.Create(&task).Error; err != nil {
+				return err
+			}
+			// バックアップには履歴を含めないため、作成日と更新日から推定する
+			if err := tx.Create(estimateStatusChanges(task)).Error; err != nil {
+				return err
+			}
+			taskIds[v.ID] = task.ID
+			result.Tasks++
+		}
+
+		for _, v := range archive.InCharges {
+			taskId, ok := taskIds[v.TaskID]
+			if !ok {
+				return fmt.Errorf("assignment references unknown task %d", v.TaskID)
+			}
+			userId, ok := userIds[v.UserID]
+			if !ok {
+				return fmt.Errorf("assignment references unknown user %d", v.UserID)
+			}
+			inCharge := model.InCharge{TaskID: taskId, UserID: userId}
+			if err := tx.Create(&inCharge).Error; err != nil {
+				return err
+			}
+			result.InCharges++
+		}
+
+		// メンションはコメント本文から再作成できるため含めない
+		for _, v := range archive.Comments {
+			taskId, ok := taskIds[v.TaskId]
+			if !ok {
+				return fmt.Errorf("comment references unknown task %d", v.TaskId)
+			}
+			userId, ok := userIds[v.UserId]
+			if !ok {
+				return fmt.Errorf("comment references unknown user %d", v.UserId)
+			}
+			comment := model.TaskComment{Body: v.Body, TaskId: taskId, UserId: userId, CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt}
+			if err := tx.Create(&comment).Error; err != nil {
+				return err
+			}
+			result.Comments++
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ICalendarTokenRepository interface {
+	// 購読用トークンを作成する
+	CreateCalendarToken(calendarToken *model.CalendarToken) error
+	// ユーザーの購読用トークン一覧を取得する
+	GetCalendarTokensByUserId(calendarTokens *[]model.CalendarToken, userId uint) error
+	// 有効な購読用トークンをハッシュから取得する
+	GetActiveCalendarTokenByHash(calendarToken *model.CalendarToken, tokenHash string) error
+	// 最終利用日時を更新する
+	UpdateCalendarTokenLastUsedAt(calendarTokenId uint, usedAt time.Time) error
+	// 購読用トークンを無効にする
+	RevokeCalendarToken(calendarToken *model.CalendarToken, userId uint, calendarTokenId uint) error
+}
+
+type calendarTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarTokenRepository(db *gorm.DB) ICalendarTokenRepository {
+	return &calendarTokenRepository{db}
+}
+
+func (ctr *calendarTokenRepository) CreateCalendarToken(calendarToken *model.CalendarToken) error {
+	if err := ctr.db.Create(calendarToken).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ctr *calendarTokenRepository) GetCalendarTokensByUserId(calendarTokens *[]model.CalendarToken, userId uint) error {
+	if err := ctr.db.Where("user_id=?", userId).Order("created_at").Find(calendarTokens).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ctr *calendarTokenRepository) GetActiveCalendarTokenByHash(calendarToken *model.CalendarToken, tokenHash string) error {
+	if err := ctr.db.Where("token_hash=? AND revoked_at IS NULL", tokenHash).First(calendarToken).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ctr *calendarTokenRepository) UpdateCalendarTokenLastUsedAt(calendarTokenId uint, usedAt time.Time) error {
+	if err := ctr.db.Model(&model.CalendarToken{}).Where("id=?", calendarTokenId).Update("last_used_at", usedAt).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ctr *calendarTokenRepository) RevokeCalendarToken(calendarToken *model.CalendarToken, userId uint, calendarTokenId uint) error {
+	result := ctr.db.Model(calendarToken).Clauses(clause.Returning{}).Where("id=? AND user_id=? AND revoked_at IS NULL", calendarTokenId, userId).Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ICommentRepository interface {
+	// コメントを作成する
+	CreateComment(comment *model.TaskComment) error
+	// タスクのコメント一覧を取得する
+	GetCommentsByTaskId(comments *[]model.TaskComment, taskId uint) error
+	// コメントを取得する
+	GetCommentById(comment *model.TaskComment, taskId uint, commentId uint) error
+	// コメントを更新する
+	UpdateComment(comment *model.TaskComment, commentId uint) error
+	// コメントを削除する
+	DeleteComment(commentId uint) error
+}
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) ICommentRepository {
+	return &commentRepository{db}
+}
+
+func (cr *commentRepository) CreateComment(comment *model.TaskComment) error {
+	if err := cr.db.Create(comment).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (cr *commentRepository) GetCommentsByTaskId(comments *[]model.TaskComment, taskId uint) error {
+	if err := cr.db.Where("task_id=?", taskId).Order("created_at").Order("id").Find(comments).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (cr *commentRepository) GetCommentById(comment *model.TaskComment, taskId uint, commentId uint) error {
+	if err := cr.db.Where("task_id=?", taskId).First(comment, commentId).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (cr *commentRepository) UpdateComment(comment *model.TaskComment, commentId uint) error {
+	result := cr.db.Model(comment).Clauses(clause.Returning{}).Where("id=?", commentId).Update("body", comment.Body)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
+
+func (cr *commentRepository) DeleteComment(commentId uint) error {
+	result := cr.db.Where("id=?", commentId).Delete(&model.TaskComment{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"go-rest-api/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IEmailRepository interface {
+	// 送信待ちのメールを登録する
+	CreateEmailJob(job *model.EmailJob) error
+	// 送信時刻になったメールを取得し、他のインスタンスが同時に送らないよう次の送信時刻を先送りする
+	ClaimDueEmailJobs(jobs *[]model.EmailJob, now time.Time, lease time.Duration, limit int) error
+	// 送信結果を保存する
+	UpdateEmailJob(job *model.EmailJob) error
+}
+
+type emailRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailRepository(db *gorm.DB) IEmailRepository {
+	return &emailRepository{db}
+}
+
+func (er *emailRepository) CreateEmailJob(job *model.EmailJob) error {
+	if err := er.db.Create(job).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (er *emailRepository) ClaimDueEmailJobs(jobs *[]model.EmailJob, now time.Time, lease time.Duration, limit int) error {
+	return er.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status=? AND next_attempt_at <= ?", model.EmailJobPending, now).
+			Order("next_attempt_at").Limit(limit).Find(jobs).Error; err != nil {
+			return err
+		}
+		if len(*jobs) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(*jobs))
+		for i, v := range *jobs {
+			ids[i] = v.ID
+		}
+		return tx.Model(&model.EmailJob{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+}
+
+func (er *emailRepository) UpdateEmailJob(job *model.EmailJob) error {
+	if err := er.db.Select("status", "attempts", "next_attempt_at", "last_error", "sent_at", "updated_at").Updates(job).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"go-rest-api/model"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type IMentionRepository interface {
+	// タスクの組織のユーザーとチームメンバーを取得する
+	GetMentionableUsers(users *[]model.User, taskId uint) error
+	// 名前かメールアドレスが前方一致するメンション候補を取得する(チームメンバーを先に並べる)
+	GetMentionCandidates(candidates *[]model.MentionCandidate, taskId uint, prefix string, limit int) error
+	// メモ(commentId=0)またはコメントのメンションを取得する
+	GetMentions(mentions *[]model.Mention, taskId uint, commentId uint) error
+	// タスクのメンションをすべて取得する
+	GetMentionsByTaskId(mentions *[]model.Mention, taskId uint) error
+	// ユーザーへのメンションを新しい順に取得する
+	GetMentionsByUserId(mentions *[]model.Mention, userId uint) error
+	// メンションを作成する
+	CreateMentions(mentions *[]model.Mention) error
+	// メンションを削除する
+	DeleteMentions(mentionIds []uint) error
+}
+
+type mentionRepository struct {
+	db *gorm.DB
+}
+
+func NewMentionRepository(db *gorm.DB) IMentionRepository {
+	return &mentionRepository{db}
+}
+
+func (mr *mentionRepository) mentionableUsers(taskId uint) *gorm.DB {
+	organizationId := mr.db.Table("tasks").Select("teams.organization_id").Joins("INNER JOIN teams ON teams.id = tasks.team_id").Where("tasks.id = ?", taskId)
+	teamMemberIds := mr.db.Table("team_members").Select("team_members.user_id").Joins("INNER JOIN tasks ON tasks.team_id = team_members.team_id").Where("tasks.id = ? AND team_members.delete_flg = ?", taskId, false)
+	return mr.db.Table("users").Where("users.organization_id = (?) OR users.id IN (?)", organizationId, teamMemberIds)
+}
+
+func (mr *mentionRepository) GetMentionableUsers(users *[]model.User, taskId uint) error {
+	if err := mr.mentionableUsers(taskId).Find(users).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mr *mentionRepository) GetMentionCandidates(candidates *[]model.MentionCandidate, taskId uint, prefix string, limit int) error {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+	teamMember := mr.db.Table("team_members").Select("1").Joins("INNER JOIN tasks ON tasks.team_id = team_members.team_id").Where("tasks.id = ? AND team_members.user_id = users.id AND team_members.delete_flg = ?", taskId, false)
+	if err := mr.mentionableUsers(taskId).
+		Select("users.id, users.name, users.email, EXISTS (?) AS team_member", teamMember).
+		Where("users.name ILIKE ? OR users.email ILIKE ?", escaped, escaped).
+		Order("team_member DESC").Order("users.name").
+		Limit(limit).
+		Find(candidates).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mr *mentionRepository) GetMentions(mentions *[]model.Mention, taskId uint, commentId uint) error {
+	if err := mr.db.Joins("User").Where("task_id=? AND comment_id=?", taskId, commentId).Find(mentions).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mr *mentionRepository) GetMentionsByTaskId(mentions *[]model.Mention, taskId uint) error {
+	if err := mr.db.Joins("User").Where("task_id=?", taskId).Order("mentions.id").Find(mentions).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mr *mentionRepository) GetMentionsByUserId(mentions *[]model.Mention, userId uint) error {
+	if err := mr.db.Joins("User").Where("mentions.user_id=?", userId).Order("mentions.created_at desc").Find(mentions).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mr *mentionRepository) CreateMentions(mentions *[]model.Mention) error {
+	if err := mr.db.Create(mentions).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mr *mentionRepository) DeleteMentions(mentionIds []uint) error {
+	if err := mr.db.Where("id IN ?", mentionIds).Delete(&model.Mention{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type INotificationRepository interface {
+	// 通知を作成する
+	CreateNotifications(notifications *[]model.Notification) error
+	// 通知を新しい順にページ単位で取得する
+	GetNotificationsByUserId(notifications *[]model.Notification, userId uint, unreadOnly bool, offset int, limit int) error
+	// 通知の件数を取得する
+	CountNotifications(count *int64, userId uint, unreadOnly bool) error
+	// 通知を既読にする
+	MarkNotificationRead(notification *model.Notification, userId uint, notificationId uint) error
+	// すべての通知を既読にする
+	MarkAllNotificationsRead(userId uint) error
+	// 同じタスク・種類の通知が指定日時以降にあるか確認する
+	ExistsNotification(exists *bool, userId uint, notificationType model.NotificationType, taskId uint, since time.Time) error
+	// 受け取り設定を取得する
+	GetNotificationPreferences(preferences *[]model.NotificationPreference, userId uint) error
+	// 複数ユーザーの種類ごとの受け取り設定を取得する
+	GetNotificationPreferencesByUserIds(preferences *[]model.NotificationPreference, userIds []uint, notificationType model.NotificationType) error
+	// 受け取り設定を保存する
+	UpsertNotificationPreference(preference *model.NotificationPreference) error
+	// メールの設定を取得する
+	GetEmailSettingsByUserIds(settings *[]model.EmailSetting, userIds []uint) error
+	// メールの設定を保存する
+	UpsertEmailSetting(setting *model.EmailSetting) error
+	// まとめて受け取る設定で、前回の送信から指定日時以上経ったユーザーの設定を取得する
+	GetDueDigestSettings(settings *[]model.EmailSetting, before time.Time) error
+	// まとめて送った日時を記録する
+	UpdateLastDigestAt(userId uint, sentAt time.Time) error
+	// まとめて送る未読の通知を取得する
+	GetDigestNotifications(notifications *[]model.Notification, userId uint, since time.Time) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) INotificationRepository {
+	return &notificationRepository{db}
+}
+
+func (nr *notificationRepository) CreateNotifications(notifications *[]model.Notification) error {
+	if err := nr.db.Create(notifications).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nr *notificationRepository) GetNotificationsByUserId(notifications *[]model.Notification, userId uint, unreadOnly bool, offset int, limit int) error {
+	db := nr.db.Where("user_id=? AND email_only=?", userId, false)
+	if unreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+	if err := db.Order("created_at desc").Order("id desc").Offset(offset).Limit(limit).Find(notifications).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nr *notificationRepository) CountNotifications(count *int64, userId uint, unreadOnly bool) error {
+	db := nr.db.Model(&model.Notification{}).Where("user_id=? AND email_only=?", userId, false)
+	if unreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+	if err := db.Count(count).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nr *notificationRepository) MarkNotificationRead(notification *model.Notification, userId uint, notificationId uint) error {
+	result := nr.db.Model(notification).Clauses(clause.Returning{}).Where("id=? AND user_id=?", notificationId, userId).Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
+
+func (nr *notificationRepository) MarkAllNotificationsRead(userId uint) error {
+	if err := nr.db.Model(&model.Notification{}).Where("user_id=? AND read_at IS NULL", userId).Update("read_at", time.Now()).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nr *notificationRepository) ExistsNotification(exists *bool, userId uint, notificationType model.NotificationType, taskId uint, since time.Time) error {
+	var count int64
+	if err := nr.db.Model(&model.Notification{}).Where("user_id=? AND type=? AND task_id=? AND created_at >= ?", userId, notificationType, taskId, since).Count(&count).Error; err != nil {
+		return err
+	}
+	*exists = count > 0
+
+	return nil
+}
+
+func (nr *notificationRepository) GetNotificationPreferences(preferences *[]model.NotificationPreference, userId uint) error {
+	if err := nr.db.Where("user_id=?", userId).Find(preferences).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nr *notificationRepository) GetNotificationPreferencesByUserIds(preferences *[]model.NotificationPreference, userIds []uint, notificationType model.NotificationType) error {
+	if err := nr.db.Where("user_id IN ? AND type=?", userIds, notificationType).Find(preferences).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nr *notificationRepository) UpsertNotificationPreference(preference *model.NotificationPreference) error {
+	// falseも保存するよう列を明示する(既定値のある列はゼロ値だと省略されるため)
+	if err := nr.db.Select("user_id", "type", "in_app", "email").Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email"}),
+	}).Create(preference).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nr *notificationRepository) GetEmailSettingsByUserIds(settings *[]model.EmailSetting, userIds []uint) error {
+	if err := nr.db.Where("user_id IN ?", userIds).Find(settings).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nr *notificationRepository) UpsertEmailSetting(setting *model.EmailSetting) error {
+	if err := nr.db.Select("user_id", "language", "digest").Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"language", "digest"}),
+	}).Create(setting).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nr *notificationRepository) GetDueDigestSettings(settings *[]model.EmailSetting, before time.Time) error {
+	if err := nr.db.Where("digest=? AND (last_digest_at IS NULL OR last_digest_at <= ?)", true, before).Find(settings).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nr *notificationRepository) UpdateLastDigestAt(userId uint, sentAt time.Time) error {
+	if err := nr.db.Model(&model.EmailSetting{}).Where("user_id=?", userId).Update("last_digest_at", sentAt).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nr *notificationRepository) GetDigestNotifications(notifications *[]model.Notification, userId uint, since time.Time) error {
+	if err := nr.db.Where("user_id=? AND email=? AND read_at IS NULL AND created_at > ?", userId, true, since).Order("created_at").Find(notifications).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
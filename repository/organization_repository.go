@@ -13,6 +13,8 @@ type IOrganizationRepository interface {
 	CreateOrganization(organization *model.Organization) error
 	// 組織の一覧を取得する
 	ListOrganizations(organizations *[]model.Organization) error
+	// 組織を取得する
+	GetOrganizationById(organization *model.Organization, organizationId uint) error
 }
 
 type organizationRepository struct {
@@ -50,3 +52,11 @@ func (or *organizationRepository) ListOrganizations(organizations *[]model.Organ
 	return nil
 }
 
+// 組織を取得する
+func (or *organizationRepository) GetOrganizationById(organization *model.Organization, organizationId uint) error {
+	if err := or.db.First(organization, organizationId).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IPasswordRepository interface {
+	// 再設定用のトークンを作成する。ユーザーの未使用のトークンは無効にする
+	CreateResetToken(resetToken *model.PasswordResetToken) error
+	// 有効なトークンを使用済みにしてパスワードを更新する
+	ResetPassword(resetToken *model.PasswordResetToken, tokenHash string, passwordHash string, now time.Time) error
+	// 期限の過ぎたトークンを削除する
+	DeleteExpiredResetTokens(now time.Time) error
+}
+
+type passwordRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordRepository(db *gorm.DB) IPasswordRepository {
+	return &passwordRepository{db}
+}
+
+func (pr *passwordRepository) CreateResetToken(resetToken *model.PasswordResetToken) error {
+	return pr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.PasswordResetToken{}).Where("user_id=? AND used_at IS NULL", resetToken.UserId).Update("used_at", resetToken.CreatedAt).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(resetToken).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (pr *passwordRepository) ResetPassword(resetToken *model.PasswordResetToken, tokenHash string, passwordHash string, now time.Time) error {
+	return pr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(resetToken).Clauses(clause.Returning{}).Where("token_hash=? AND used_at IS NULL AND expires_at > ?", tokenHash, now).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return fmt.Errorf("the reset token is invalid or has expired")
+		}
+		if err := tx.Model(&model.User{}).Where("id=?", resetToken.UserId).Update("password", passwordHash).Error; err != nil {
+			return err
+		}
+		// 復元したユーザーは、元のメールアドレスに届いたトークンで確認できたためメールアドレスを引き継ぐ
+		if err := tx.Model(&model.User{}).Where("id=? AND claim_email<>''", resetToken.UserId).
+			Updates(map[string]interface{}{"email": gorm.Expr("claim_email"), "claim_email": "", "email_verified_at": now}).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (pr *passwordRepository) DeleteExpiredResetTokens(now time.Time) error {
+	if err := pr.db.Where("expires_at < ?", now).Delete(&model.PasswordResetToken{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
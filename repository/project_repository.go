@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IProjectRepository interface {
+	// プロジェクトを作成し、共有するチームを登録する
+	CreateProject(project *model.Project, sharedTeamIds []uint) error
+	GetProjectById(project *model.Project, projectId uint) error
+	// チームが持っている、または共有されているプロジェクトを取得する
+	GetProjectsByTeamId(projects *[]model.Project, teamId uint) error
+	// プロジェクトを共有しているチームのIDを取得する
+	GetSharedTeamIds(teamIds *[]uint, projectId uint) error
+	// 複数のプロジェクトを共有しているチームをまとめて取得する
+	GetProjectTeams(projectTeams *[]model.ProjectTeam, projectIds []uint) error
+	UpdateProject(project *model.Project, projectId uint) error
+	// 共有するチームを置き換え、外れたチームのタスクをプロジェクトから外す
+	SetSharedTeams(projectId uint, ownerTeamId uint, teamIds []uint) error
+	// プロジェクトを削除し、タスクはプロジェクトなしに戻す
+	DeleteProject(projectId uint) error
+	// 指定したチームのタスクをプロジェクトに追加する
+	AddProjectTasks(projectId uint, teamIds []uint, taskIds []uint) error
+	// タスクをプロジェクトから外す
+	RemoveProjectTask(projectId uint, taskId uint) error
+	// プロジェクト・チーム・ステータスごとのタスク数を集計する
+	CountProjectTasks(counts *[]model.ProjectTaskCount, projectIds []uint) error
+}
+
+type projectRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectRepository(db *gorm.DB) IProjectRepository {
+	return &projectRepository{db}
+}
+
+func (pr *projectRepository) CreateProject(project *model.Project, sharedTeamIds []uint) error {
+	return pr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		if len(sharedTeamIds) == 0 {
+			return nil
+		}
+		projectTeams := make([]model.ProjectTeam, len(sharedTeamIds))
+		for i, v := range sharedTeamIds {
+			projectTeams[i] = model.ProjectTeam{ProjectId: project.ID, TeamId: v}
+		}
+		return tx.Create(&projectTeams).Error
+	})
+}
+
+func (pr *projectRepository) GetProjectById(project *model.Project, projectId uint) error {
+	if err := pr.db.First(project, projectId).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (pr *projectRepository) GetProjectsByTeamId(projects *[]model.Project, teamId uint) error {
+	if err := pr.db.Where("team_id=? OR id IN (?)", teamId, pr.db.Model(&model.ProjectTeam{}).Select("project_id").Where("team_id=?", teamId)).Order("created_at desc").Order("id desc").Find(projects).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (pr *projectRepository) GetSharedTeamIds(teamIds *[]uint, projectId uint) error {
+	if err := pr.db.Model(&model.ProjectTeam{}).Where("project_id=?", projectId).Order("team_id").Pluck("team_id", teamIds).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (pr *projectRepository) GetProjectTeams(projectTeams *[]model.ProjectTeam, projectIds []uint) error {
+	if len(projectIds) == 0 {
+		return nil
+	}
+	if err := pr.db.Where("project_id IN ?", projectIds).Order("team_id").Find(projectTeams).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (pr *projectRepository) UpdateProject(project *model.Project, projectId uint) error {
+	result := pr.db.Model(project).Clauses(clause.Returning{}).Where("id=?", projectId).Updates(map[string]interface{}{"name": project.Name, "description": project.Description, "status": project.Status, "start_date": project.StartDate, "end_date": project.EndDate})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
+
+func (pr *projectRepository) SetSharedTeams(projectId uint, ownerTeamId uint, teamIds []uint) error {
+	return pr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id=?", projectId).Delete(&model.ProjectTeam{}).Error; err != nil {
+			return err
+		}
+		if len(teamIds) > 0 {
+			projectTeams := make([]model.ProjectTeam, len(teamIds))
+			for i, v := range teamIds {
+				projectTeams[i] = model.ProjectTeam{ProjectId: projectId, TeamId: v}
+			}
+			if err := tx.Create(&projectTeams).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.Task{}).Where("project_id=? AND team_id NOT IN ?", projectId, append([]uint{ownerTeamId}, teamIds...)).Update("project_id", nil).Error
+	})
+}
+
+func (pr *projectRepository) DeleteProject(projectId uint) error {
+	return pr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Task{}).Where("project_id=?", projectId).Update("project_id", nil).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&model.Project{}, projectId)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return fmt.Errorf("object does not exist")
+		}
+		return nil
+	})
+}
+
+func (pr *projectRepository) AddProjectTasks(projectId uint, teamIds []uint, taskIds []uint) error {
+	return pr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Task{}).Where("id IN ? AND team_id IN ?", taskIds, teamIds).Update("project_id", projectId)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < int64(len(taskIds)) {
+			return fmt.Errorf("some tasks do not exist in the teams of the project")
+		}
+		return nil
+	})
+}
+
+func (pr *projectRepository) RemoveProjectTask(projectId uint, taskId uint) error {
+	result := pr.db.Model(&model.Task{}).Where("id=? AND project_id=?", taskId, projectId).Update("project_id", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
+
+func (pr *projectRepository) CountProjectTasks(counts *[]model.ProjectTaskCount, projectIds []uint) error {
+	if len(projectIds) == 0 {
+		return nil
+	}
+	if err := pr.db.Model(&model.Task{}).
+		Select("project_id, team_id, status, COUNT(*) AS count, COUNT(*) FILTER (WHERE dead_line < CURRENT_DATE AND status <> ?) AS overdue", model.TaskStatusCompleted).
+		Where("project_id IN ?", projectIds).
+		Group("project_id, team_id, status").
+		Order("project_id, team_id, status").
+		Scan(counts).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"go-rest-api/model"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IRateLimitRepository interface {
+	// すべての制限に収まる場合だけ操作を記録する。記録した場合は true
+	CreateEventsWithinLimits(limits []model.RateLimit, now time.Time) (bool, error)
+	// before より前の記録を削除する
+	DeleteEventsBefore(before time.Time) error
+}
+
+type rateLimitRepository struct {
+	db *gorm.DB
+}
+
+func NewRateLimitRepository(db *gorm.DB) IRateLimitRepository {
+	return &rateLimitRepository{db}
+}
+
+func (rlr *rateLimitRepository) CreateEventsWithinLimits(limits []model.RateLimit, now time.Time) (bool, error) {
+	allowed := false
+	err := rlr.db.Transaction(func(tx *gorm.DB) error {
+		// 同じキーの数えてから記録するまでを直列にし、同時の操作で上限を超えないようにする
+		// デッドロックしないようキーの順にロックを取る
+		keys := make([]string, len(limits))
+		for i, v := range limits {
+			keys[i] = v.Key
+		}
+		sort.Strings(keys)
+		for _, v := range keys {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", v).Error; err != nil {
+				return err
+			}
+		}
+
+		events := make([]model.RateLimitEvent, len(limits))
+		for i, v := range limits {
+			var count int64
+			if err := tx.Model(&model.RateLimitEvent{}).Where("key=? AND created_at >= ?", v.Key, now.Add(-v.Window)).Count(&count).Error; err != nil {
+				return err
+			}
+			if count >= v.Limit {
+				return nil
+			}
+			events[i] = model.RateLimitEvent{Key: v.Key, CreatedAt: now}
+		}
+		if len(events) > 0 {
+			if err := tx.Create(&events).Error; err != nil {
+				return err
+			}
+		}
+		allowed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return allowed, nil
+}
+
+func (rlr *rateLimitRepository) DeleteEventsBefore(before time.Time) error {
+	if err := rlr.db.Where("created_at < ?", before).Delete(&model.RateLimitEvent{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
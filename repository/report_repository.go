@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IReportRepository interface {
+	// チームのタスクが完了した週ごとに、完了したタスク数とポイントを集計する
+	GetWeeklyCompletions(completions *[]model.WeeklyCompletion, teamId uint, from time.Time, to time.Time) error
+	// 現在のステータスごとのタスク数と期限切れのタスク数を集計する
+	CountTasks(counts *model.TaskCounts, scope model.StatsScope) error
+	// チームごとに現在のタスク数を集計する
+	CountTasksByTeam(counts *[]model.TaskCounts, scope model.StatsScope) error
+	// 期間内に作成・完了したタスク数を集計する
+	CountRange(counts *model.RangeCounts, scope model.StatsScope, from time.Time, to time.Time) error
+	// 日または週ごとに作成・完了したタスク数を集計する(タスクのない期間も0で返す)
+	GetThroughput(points *[]model.ThroughputPoint, scope model.StatsScope, from time.Time, to time.Time, interval string) error
+	// チームの未完了タスクを担当者ごとに取得する(担当者のいないタスクも含む)
+	GetOpenAssignedTasks(tasks *[]model.AssignedTask, teamId uint) error
+	// 期間内に完了したチームのタスクの作成・着手・完了の日時を取得する
+	GetTaskFlowTimes(times *[]model.TaskFlowTimes, teamId uint, from time.Time, to time.Time) error
+	// 日または週の終わりの時点ごとに、ステータスごとのタスク数を集計する
+	GetCumulativeFlow(points *[]model.CumulativeFlowPoint, teamId uint, from time.Time, to time.Time, interval string) error
+}
+
+type reportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) IReportRepository {
+	return &reportRepository{db}
+}
+
+// 完了後に戻されたタスクは数えず、最後に完了した日時で集計する
+func (rr *reportRepository) completedAt() *gorm.DB {
+	return rr.db.Model(&model.TaskStatusChange{}).Select("task_id, MAX(changed_at) AS completed_at").Where("to_status=?", model.TaskStatusCompleted).Group("task_id")
+}
+
+func (rr *reportRepository) GetWeeklyCompletions(completions *[]model.WeeklyCompletion, teamId uint, from time.Time, to time.Time) error {
+	if err := rr.db.Table("tasks").
+		Select("date_trunc('week', c.completed_at AT TIME ZONE 'UTC') AS period_start, COALESCE(SUM(tasks.story_points), 0) AS points, COUNT(*) AS tasks").
+		Joins("JOIN (?) AS c ON c.task_id = tasks.id", rr.completedAt()).
+		Where("tasks.team_id=? AND tasks.status=? AND c.completed_at >= ? AND c.completed_at < ?", teamId, model.TaskStatusCompleted, from, to).
+		Group("period_start").
+		Order("period_start").
+		Scan(completions).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// 集計の対象のタスクに絞り込む
+func (rr *reportRepository) scopedTasks(scope model.StatsScope) *gorm.DB {
+	if scope.TeamId != 0 {
+		return rr.db.Table("tasks").Where("tasks.team_id=?", scope.TeamId)
+	}
+	return rr.db.Table("tasks").Where("tasks.team_id IN (?)", rr.db.Model(&model.Team{}).Select("id").Where("organization_id=?", scope.OrganizationId))
+}
+
+// ステータスごとのタスク数と期限切れのタスク数を数える列
+func taskCountColumns() string {
+	return fmt.Sprintf("COUNT(*) AS total, "+
+		"COUNT(*) FILTER (WHERE tasks.status=%d) AS unstarted, "+
+		"COUNT(*) FILTER (WHERE tasks.status=%d) AS started, "+
+		"COUNT(*) FILTER (WHERE tasks.status=%d) AS completed, "+
+		"COUNT(*) FILTER (WHERE tasks.status<>%d AND tasks.dead_line < CURRENT_DATE) AS overdue",
+		model.TaskStatusUnstarted, model.TaskStatusStarted, model.TaskStatusCompleted, model.TaskStatusCompleted)
+}
+
+func (rr *reportRepository) CountTasks(counts *model.TaskCounts, scope model.StatsScope) error {
+	if err := rr.scopedTasks(scope).Select(taskCountColumns()).Scan(counts).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (rr *reportRepository) CountTasksByTeam(counts *[]model.TaskCounts, scope model.StatsScope) error {
+	if err := rr.scopedTasks(scope).Select("tasks.team_id, " + taskCountColumns()).Group("tasks.team_id").Order("tasks.team_id").Scan(counts).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (rr *reportRepository) CountRange(counts *model.RangeCounts, scope model.StatsScope, from time.Time, to time.Time) error {
+	if err := rr.scopedTasks(scope).
+		Select("COUNT(*) FILTER (WHERE tasks.created_at >= ? AND tasks.created_at < ?) AS created, "+
+			"COUNT(*) FILTER (WHERE tasks.status=? AND c.completed_at >= ? AND c.completed_at < ?) AS completed, "+
+			"COUNT(*) FILTER (WHERE tasks.created_at >= ? AND tasks.created_at < ? AND tasks.status=?) AS created_completed",
+			from, to, model.TaskStatusCompleted, from, to, from, to, model.TaskStatusCompleted).
+		Joins("LEFT JOIN (?) AS c ON c.task_id = tasks.id", rr.completedAt()).
+		Scan(counts).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (rr *reportRepository) GetThroughput(points *[]model.ThroughputPoint, scope model.StatsScope, from time.Time, to time.Time, interval string) error {
+	created := rr.scopedTasks(scope).
+		Select("date_trunc(?, tasks.created_at AT TIME ZONE 'UTC') AS period_start, COUNT(*) AS count", interval).
+		Where("tasks.created_at >= ? AND tasks.created_at < ?", from, to).
+		Group("period_start")
+	completed := rr.scopedTasks(scope).
+		Select("date_trunc(?, c.completed_at AT TIME ZONE 'UTC') AS period_start, COUNT(*) AS count", interval).
+		Joins("JOIN (?) AS c ON c.task_id = tasks.id", rr.completedAt()).
+		Where("tasks.status=? AND c.completed_at >= ? AND c.completed_at < ?", model.TaskStatusCompleted, from, to).
+		Group("period_start")
+	if err := rr.db.Raw("SELECT s.period_start AS date, COALESCE(cr.count, 0) AS created, COALESCE(cp.count, 0) AS completed "+
+		"FROM generate_series(?::timestamp, ?::timestamp - interval '1 second', ('1 ' || ?)::interval) AS s(period_start) "+
+		"LEFT JOIN (?) AS cr ON cr.period_start = s.period_start "+
+		"LEFT JOIN (?) AS cp ON cp.period_start = s.period_start "+
+		"ORDER BY s.period_start",
+		from.UTC().Format("2006-01-02 15:04:05"), to.UTC().Format("2006-01-02 15:04:05"), interval, created, completed).
+		Scan(points).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (rr *reportRepository) GetOpenAssignedTasks(tasks *[]model.AssignedTask, teamId uint) error {
+	if err := rr.db.Table("tasks").
+		Select("COALESCE(in_charges.user_id, 0) AS user_id, tasks.id AS task_id, tasks.title, tasks.status, tasks.dead_line, tasks.estimate, tasks.story_points").
+		Joins("LEFT JOIN in_charges ON in_charges.task_id = tasks.id").
+		Where("tasks.team_id=? AND tasks.status<>?", teamId, model.TaskStatusCompleted).
+		Order("tasks.dead_line, tasks.id").
+		Scan(tasks).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// 着手は最初に着手中になった日時とする
+func (rr *reportRepository) GetTaskFlowTimes(times *[]model.TaskFlowTimes, teamId uint, from time.Time, to time.Time) error {
+	startedAt := rr.db.Model(&model.TaskStatusChange{}).Select("task_id, MIN(changed_at) AS started_at").Where("to_status=?", model.TaskStatusStarted).Group("task_id")
+	if err := rr.db.Table("tasks").
+		Select("tasks.id AS task_id, tasks.created_at, s.started_at, c.completed_at").
+		Joins("JOIN (?) AS c ON c.task_id = tasks.id", rr.completedAt()).
+		Joins("LEFT JOIN (?) AS s ON s.task_id = tasks.id AND s.started_at <= c.completed_at", startedAt).
+		Where("tasks.team_id=? AND tasks.status=? AND c.completed_at >= ? AND c.completed_at < ?", teamId, model.TaskStatusCompleted, from, to).
+		Order("c.completed_at").
+		Scan(times).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// 各時点で最後に記録されたステータスで数える。その時点で作成されていないタスクは数えない
+func (rr *reportRepository) GetCumulativeFlow(points *[]model.CumulativeFlowPoint, teamId uint, from time.Time, to time.Time, interval string) error {
+	if err := rr.db.Raw("SELECT s.period_start AS date, "+
+		"COUNT(h.to_status) FILTER (WHERE h.to_status=?) AS unstarted, "+
+		"COUNT(h.to_status) FILTER (WHERE h.to_status=?) AS started, "+
+		"COUNT(h.to_status) FILTER (WHERE h.to_status=?) AS completed "+
+		"FROM generate_series(?::timestamp, ?::timestamp - interval '1 second', ('1 ' || ?)::interval) AS s(period_start) "+
+		"LEFT JOIN tasks ON tasks.team_id = ? "+
+		"LEFT JOIN LATERAL (SELECT to_status FROM task_status_changes "+
+		"WHERE task_status_changes.task_id = tasks.id AND task_status_changes.changed_at < LEAST(s.period_start + ('1 ' || ?)::interval, ?::timestamp) AT TIME ZONE 'UTC' "+
+		"ORDER BY task_status_changes.changed_at DESC, task_status_changes.id DESC LIMIT 1) AS h ON true "+
+		"GROUP BY s.period_start "+
+		"ORDER BY s.period_start",
+		model.TaskStatusUnstarted, model.TaskStatusStarted, model.TaskStatusCompleted,
+		from.UTC().Format("2006-01-02 15:04:05"), to.UTC().Format("2006-01-02 15:04:05"), interval,
+		teamId, interval, to.UTC().Format("2006-01-02 15:04:05")).
+		Scan(points).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
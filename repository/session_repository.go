@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"go-rest-api/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTokenReused = errors.New("the refresh token has already been used")
+
+type ISessionRepository interface {
+	// セッションと最初のリフレッシュトークンを作成する
+	CreateSession(session *model.Session, refreshToken *model.RefreshToken) error
+	// リフレッシュトークンをハッシュから取得する(セッションも読み込む)
+	GetRefreshTokenByHash(refreshToken *model.RefreshToken, tokenHash string) error
+	// リフレッシュトークンを使用済みにして新しいトークンを発行し、セッションを延長する
+	// 既に使用済みだった場合は ErrTokenReused を返す
+	RotateRefreshToken(session *model.Session, refreshTokenId uint, newRefreshToken *model.RefreshToken) error
+	// ユーザーの有効なセッション一覧を取得する
+	GetActiveSessions(sessions *[]model.Session, userId uint, now time.Time) error
+	// セッションを失効させる
+	RevokeSession(session *model.Session, userId uint, sessionId uint, reason string) error
+	// ユーザーの有効なセッションをまとめて失効させる。exceptSessionId のセッションは残す
+	RevokeSessions(sessions *[]model.Session, userId uint, exceptSessionId uint, reason string) error
+	// アクセストークンを拒否リストに追加する
+	RevokeTokens(revokedTokens []model.RevokedToken) error
+	// アクセストークンが拒否リストにあるか確認する
+	IsTokenRevoked(jti string) (bool, error)
+	// 指定したアクセストークンのうち拒否リストにあるものの jti を取得する
+	GetRevokedJtis(revoked *[]string, jtis []string) error
+	// 期限が過ぎて不要になった拒否リストを削除する
+	DeleteExpiredRevokedTokens(now time.Time) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) ISessionRepository {
+	return &sessionRepository{db}
+}
+
+func (sr *sessionRepository) CreateSession(session *model.Session, refreshToken *model.RefreshToken) error {
+	return sr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		refreshToken.SessionId = session.ID
+		if err := tx.Create(refreshToken).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (sr *sessionRepository) GetRefreshTokenByHash(refreshToken *model.RefreshToken, tokenHash string) error {
+	if err := sr.db.Preload("Session").Where("token_hash=?", tokenHash).First(refreshToken).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *sessionRepository) RotateRefreshToken(session *model.Session, refreshTokenId uint, newRefreshToken *model.RefreshToken) error {
+	return sr.db.Transaction(func(tx *gorm.DB) error {
+		// 同じトークンが同時に使われた場合も、使用済みにできるのは1回だけ
+		result := tx.Model(&model.RefreshToken{}).Where("id=? AND used_at IS NULL", refreshTokenId).Update("used_at", newRefreshToken.CreatedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return ErrTokenReused
+		}
+		result = tx.Model(session).Clauses(clause.Returning{}).Where("id=? AND revoked_at IS NULL", session.ID).Updates(map[string]interface{}{
+			"user_agent": session.UserAgent,
+			"ip_address": session.IPAddress,
+			"last_used_at": session.LastUsedAt,
+			"expires_at": session.ExpiresAt,
+			"access_jti": session.AccessJti,
+			"access_expires_at": session.AccessExpiresAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return fmt.Errorf("object does not exist")
+		}
+		newRefreshToken.SessionId = session.ID
+		if err := tx.Create(newRefreshToken).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (sr *sessionRepository) GetActiveSessions(sessions *[]model.Session, userId uint, now time.Time) error {
+	if err := sr.db.Where("user_id=? AND revoked_at IS NULL AND expires_at > ?", userId, now).Order("last_used_at DESC").Find(sessions).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *sessionRepository) RevokeSession(session *model.Session, userId uint, sessionId uint, reason string) error {
+	result := sr.db.Model(session).Clauses(clause.Returning{}).Where("id=? AND user_id=? AND revoked_at IS NULL", sessionId, userId).Updates(map[string]interface{}{
+		"revoked_at": time.Now(),
+		"revoked_reason": reason,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
+
+func (sr *sessionRepository) RevokeSessions(sessions *[]model.Session, userId uint, exceptSessionId uint, reason string) error {
+	if err := sr.db.Model(sessions).Clauses(clause.Returning{}).Where("user_id=? AND id<>? AND revoked_at IS NULL", userId, exceptSessionId).Updates(map[string]interface{}{
+		"revoked_at": time.Now(),
+		"revoked_reason": reason,
+	}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *sessionRepository) RevokeTokens(revokedTokens []model.RevokedToken) error {
+	if len(revokedTokens) == 0 {
+		return nil
+	}
+	if err := sr.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&revokedTokens).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *sessionRepository) GetRevokedJtis(revoked *[]string, jtis []string) error {
+	if len(jtis) == 0 {
+		return nil
+	}
+	if err := sr.db.Model(&model.RevokedToken{}).Where("jti IN ?", jtis).Pluck("jti", revoked).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *sessionRepository) IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+	if err := sr.db.Model(&model.RevokedToken{}).Where("jti=?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (sr *sessionRepository) DeleteExpiredRevokedTokens(now time.Time) error {
+	if err := sr.db.Where("expires_at < ?", now).Delete(&model.RevokedToken{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
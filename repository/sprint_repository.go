@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ISprintRepository interface {
+	CreateSprint(sprint *model.Sprint) error
+	GetSprintById(sprint *model.Sprint, sprintId uint) error
+	// チームのスプリントを開始日の新しい順に取得する
+	GetSprintsByTeamId(sprints *[]model.Sprint, teamId uint) error
+	UpdateSprint(sprint *model.Sprint, sprintId uint) error
+	// スプリントを削除し、含まれていたタスクはバックログに戻す
+	DeleteSprint(sprintId uint) error
+	// スプリントに含まれているタスクを取得する
+	GetSprintTasks(tasks *[]model.Task, sprintId uint) error
+	// チームのタスクをスプリントに移す(他のスプリントに含まれていれば、そちらからは除外する)
+	AddSprintTasks(sprintId uint, teamId uint, taskIds []uint) error
+	// IDを指定してタスクを取得する
+	GetTasksByIds(tasks *[]model.Task, taskIds []uint) error
+	// タスクをスプリントから外してバックログに戻す
+	RemoveSprintTask(sprintId uint, taskId uint) error
+	// スプリントを終了し、未完了のタスクを次のスプリント(0の場合はバックログ)に移す
+	CloseSprint(sprint *model.Sprint, sprintId uint, carryOverTo uint) error
+	// スプリントへのタスクの追加・除外の履歴を古い順に取得する
+	GetSprintTaskChanges(changes *[]model.SprintTaskChange, sprintId uint) error
+	GetCarryOvers(carryOvers *[]model.SprintCarryOver, sprintId uint) error
+}
+
+type sprintRepository struct {
+	db *gorm.DB
+}
+
+func NewSprintRepository(db *gorm.DB) ISprintRepository {
+	return &sprintRepository{db}
+}
+
+func (sr *sprintRepository) CreateSprint(sprint *model.Sprint) error {
+	if err := sr.db.Create(sprint).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *sprintRepository) GetSprintById(sprint *model.Sprint, sprintId uint) error {
+	if err := sr.db.First(sprint, sprintId).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *sprintRepository) GetSprintsByTeamId(sprints *[]model.Sprint, teamId uint) error {
+	if err := sr.db.Where("team_id=?", teamId).Order("start_date desc").Order("id desc").Find(sprints).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *sprintRepository) UpdateSprint(sprint *model.Sprint, sprintId uint) error {
+	result := sr.db.Model(sprint).Clauses(clause.Returning{}).Where("id=?", sprintId).Updates(map[string]interface{}{"name": sprint.Name, "goal": sprint.Goal, "start_date": sprint.StartDate, "end_date": sprint.EndDate})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
+
+func (sr *sprintRepository) DeleteSprint(sprintId uint) error {
+	return sr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Task{}).Where("sprint_id=?", sprintId).Update("sprint_id", nil).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&model.Sprint{}, sprintId)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return fmt.Errorf("object does not exist")
+		}
+		return nil
+	})
+}
+
+func (sr *sprintRepository) GetSprintTasks(tasks *[]model.Task, sprintId uint) error {
+	if err := sr.db.Where("sprint_id=?", sprintId).Order("status").Scopes(boardOrder).Find(tasks).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *sprintRepository) AddSprintTasks(sprintId uint, teamId uint, taskIds []uint) error {
+	return sr.db.Transaction(func(tx *gorm.DB) error {
+		tasks := make([]model.Task, 0)
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ? AND team_id=?", taskIds, teamId).Find(&tasks).Error; err != nil {
+			return err
+		}
+		if len(tasks) != len(taskIds) {
+			return fmt.Errorf("some tasks do not exist in the team")
+		}
+		now := time.Now()
+		changes := make([]model.SprintTaskChange, 0)
+		for _, v := range tasks {
+			if v.SprintId != nil && *v.SprintId == sprintId {
+				continue
+			}
+			if v.SprintId != nil {
+				changes = append(changes, model.SprintTaskChange{SprintId: *v.SprintId, TaskId: v.ID, Added: false, ChangedAt: now})
+			}
+			changes = append(changes, model.SprintTaskChange{SprintId: sprintId, TaskId: v.ID, Added: true, ChangedAt: now})
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		if err := tx.Model(&model.Task{}).Where("id IN ?", taskIds).Update("sprint_id", sprintId).Error; err != nil {
+			return err
+		}
+		return tx.Create(&changes).Error
+	})
+}
+
+func (sr *sprintRepository) GetTasksByIds(tasks *[]model.Task, taskIds []uint) error {
+	if len(taskIds) == 0 {
+		return nil
+	}
+	if err := sr.db.Where("id IN ?", taskIds).Order("id").Find(tasks).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *sprintRepository) RemoveSprintTask(sprintId uint, taskId uint) error {
+	return sr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Task{}).Where("id=? AND sprint_id=?", taskId, sprintId).Update("sprint_id", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return fmt.Errorf("object does not exist")
+		}
+		change := model.SprintTaskChange{SprintId: sprintId, TaskId: taskId, Added: false, ChangedAt: time.Now()}
+		return tx.Create(&change).Error
+	})
+}
+
+func (sr *sprintRepository) CloseSprint(sprint *model.Sprint, sprintId uint, carryOverTo uint) error {
+	return sr.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(sprint).Clauses(clause.Returning{}).Where("id=? AND closed_at IS NULL", sprintId).Update("closed_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return fmt.Errorf("the sprint is already closed")
+		}
+
+		tasks := make([]model.Task, 0)
+		if err := tx.Where("sprint_id=? AND status<>?", sprintId, model.TaskStatusCompleted).Find(&tasks).Error; err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+		taskIds := make([]uint, len(tasks))
+		carryOvers := make([]model.SprintCarryOver, len(tasks))
+		for i, v := range tasks {
+			taskIds[i] = v.ID
+			carryOvers[i] = model.SprintCarryOver{SprintId: sprintId, TaskId: v.ID, ToSprintId: carryOverTo}
+		}
+		if err := tx.Create(&carryOvers).Error; err != nil {
+			return err
+		}
+		// 終了したスプリントのスコープには残したまま、次のスプリントに追加する
+		if carryOverTo == 0 {
+			return tx.Model(&model.Task{}).Where("id IN ?", taskIds).Update("sprint_id", nil).Error
+		}
+		if err := tx.Model(&model.Task{}).Where("id IN ?", taskIds).Update("sprint_id", carryOverTo).Error; err != nil {
+			return err
+		}
+		changes := make([]model.SprintTaskChange, len(tasks))
+		for i, v := range tasks {
+			changes[i] = model.SprintTaskChange{SprintId: carryOverTo, TaskId: v.ID, Added: true, ChangedAt: now}
+		}
+		return tx.Create(&changes).Error
+	})
+}
+
+func (sr *sprintRepository) GetSprintTaskChanges(changes *[]model.SprintTaskChange, sprintId uint) error {
+	if err := sr.db.Where("sprint_id=?", sprintId).Order("changed_at").Order("id").Find(changes).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *sprintRepository) GetCarryOvers(carryOvers *[]model.SprintCarryOver, sprintId uint) error {
+	if err := sr.db.Where("sprint_id=?", sprintId).Order("id").Find(carryOvers).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"go-rest-api/model"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// 複数のAPIインスタンスに新しいイベントのIDを伝えるチャンネル
+const streamEventChannel = "stream_events"
+
+type IStreamEventRepository interface {
+	// イベントを保存し、コミット時に他のインスタンスへ通知する
+	CreateStreamEvent(event *model.StreamEvent) error
+	// イベントを取得する
+	GetStreamEventById(event *model.StreamEvent, eventId uint) error
+	// 指定したIDより後のチームのイベントを古い順に取得する
+	GetStreamEventsAfter(events *[]model.StreamEvent, afterId uint, teamIds []uint, limit int) error
+	// 最新のイベントのIDを取得する(イベントがなければ0)
+	GetLatestStreamEventId(eventId *uint) error
+	// 古いイベントを削除する
+	DeleteStreamEventsBefore(before time.Time) error
+	// 通知を待ち受け、届いたイベントIDを渡す。ctxが終了するか接続が切れるまで戻らない
+	ListenStreamEvents(ctx context.Context, handler func(eventId uint)) error
+}
+
+type streamEventRepository struct {
+	db *gorm.DB
+}
+
+func NewStreamEventRepository(db *gorm.DB) IStreamEventRepository {
+	return &streamEventRepository{db}
+}
+
+func (sr *streamEventRepository) CreateStreamEvent(event *model.StreamEvent) error {
+	return sr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		return tx.Exec("SELECT pg_notify(?, ?)", streamEventChannel, strconv.FormatUint(uint64(event.ID), 10)).Error
+	})
+}
+
+func (sr *streamEventRepository) GetStreamEventById(event *model.StreamEvent, eventId uint) error {
+	if err := sr.db.First(event, eventId).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *streamEventRepository) GetStreamEventsAfter(events *[]model.StreamEvent, afterId uint, teamIds []uint, limit int) error {
+	if err := sr.db.Where("id > ? AND team_id IN ?", afterId, teamIds).Order("id").Limit(limit).Find(events).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *streamEventRepository) GetLatestStreamEventId(eventId *uint) error {
+	if err := sr.db.Model(&model.StreamEvent{}).Select("COALESCE(MAX(id), 0)").Scan(eventId).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *streamEventRepository) DeleteStreamEventsBefore(before time.Time) error {
+	if err := sr.db.Where("created_at < ?", before).Delete(&model.StreamEvent{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *streamEventRepository) ListenStreamEvents(ctx context.Context, handler func(eventId uint)) error {
+	sqlDB, err := sr.db.DB()
+	if err != nil {
+		return err
+	}
+	// 待ち受けの間はプールのコネクションを1つ専有する
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		pgConn := driverConn.(*stdlib.Conn).Conn()
+		if _, err := pgConn.Exec(ctx, "LISTEN "+streamEventChannel); err != nil {
+			return err
+		}
+		// プールに戻す前に待ち受けを解除する
+		defer pgConn.Exec(context.Background(), "UNLISTEN "+streamEventChannel)
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			eventId, err := strconv.ParseUint(notification.Payload, 10, 64)
+			if err != nil {
+				continue
+			}
+			handler(uint(eventId))
+		}
+	})
+}
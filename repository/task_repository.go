@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"go-rest-api/model"
 	"time"
@@ -20,6 +21,48 @@ type ITaskRepository interface {
 	NarrowDownStatus(tasks *[]model.Task, userId uint, taskStatus int) error
 	FuzzySearch(tasks *[]model.Task, userId uint, keyword string) error
 	FuzzySearchStatus(tasks *[]model.Task, userId uint, keyword string, taskStatus int) error
+	// 所属チームのタスクを条件で絞り込み・並び替えて取得する
+	SearchTasks(tasks *[]model.Task, userId uint, query model.TaskQuery) error
+	// プロジェクトのタスクを検索する(共有しているチームのタスクも含む)
+	SearchProjectTasks(tasks *[]model.Task, userId uint, projectId uint, query model.TaskQuery) error
+	// 所属チームのタスクを取得する
+	GetMemberTaskById(task *model.Task, userId uint, taskId uint) error
+	// チームのタスクをボードの並び順で取得する
+	GetBoardTasks(tasks *[]model.Task, teamId uint) error
+	// ボードの列のタスクを並び順で取得する
+	GetColumnTasks(tasks *[]model.Task, teamId uint, taskStatus model.TaskStatus) error
+	// ステータスとボード上の位置を更新する
+	MoveTask(task *model.Task, userId uint, taskId uint, taskStatus model.TaskStatus, position string) error
+	// 位置が未設定のタスクにまとめて位置を設定する
+	UpdateTaskPositions(tasks []model.Task) error
+	// 複数のタスクを1つのトランザクションで作成する
+	CreateTasks(tasks *[]model.Task) error
+	// 複数のタスクと担当者を1つのトランザクションで作成する
+	CreateTasksWithAssignees(tasks *[]model.Task, assignees [][]uint) error
+	// メモが読み込んだ時から変わっていなければ更新する
+	UpdateTaskMemo(task *model.Task, taskId uint, oldMemo string, newMemo string) error
+	// タスクの担当者を取得する
+	GetAssignees(users *[]model.User, taskId uint) error
+	// タスクに担当者を追加する
+	AssignUser(inCharge *model.InCharge) error
+	// タスクから担当者を外す
+	UnassignUser(taskId uint, userId uint) error
+	// 見積もりとそのポイントを更新する
+	UpdateTaskEstimate(task *model.Task, taskId uint, estimate string, storyPoints *float64) error
+	// 期限が指定した日付の範囲(両端を含む)の未完了タスクを取得する
+	GetDueTasks(tasks *[]model.Task, fromDate time.Time, toDate time.Time) error
+}
+
+// 更新対象が読み込んだ後に変更されていた
+var ErrConflict = errors.New("object was modified concurrently")
+
+// 並び替えに指定できるカラム
+var taskSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"dead_line":  "dead_line",
+	"title":      "title",
+	"status":     "status",
 }
 
 type taskRepository struct {
@@ -58,15 +101,43 @@ func (tr *taskRepository) GetTasksByDeadline(tasks *[]model.Task, userId uint, f
 }
 
 func (tr *taskRepository) CreateTask(task *model.Task) error {
-	if err := tr.db.Create(task).Error; err != nil {
-		return err
-	}
-
-	return nil
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(task).Error; err != nil {
+			return err
+		}
+		return recordTasksCreated(tx, []model.Task{*task})
+	})
 }
 
 func (tr *taskRepository) UpdateTask(task *model.Task, userId uint, taskId uint) error {
-	result := tr.db.Model(task).Clauses(clause.Returning{}).Where("id=? AND user_id=?", taskId, userId).Updates(map[string]interface{}{"title": task.Title, "memo": task.Memo, "status": task.Status, "dead_line": task.DeadLine})
+	// タスクはチームに属するため、所属チームのタスクのみ更新できる
+	return tr.updateWithHistory(task, userId, taskId, map[string]interface{}{"title": task.Title, "memo": task.Memo, "status": task.Status, "dead_line": task.DeadLine})
+}
+
+func (tr *taskRepository) UpdateTaskStatus(task *model.Task, userId uint, taskId uint) error {
+	return tr.updateWithHistory(task, userId, taskId, map[string]interface{}{"status": task.Status})
+}
+
+// 所属チームのタスクを更新し、ステータスが変わった場合は履歴を残す
+func (tr *taskRepository) updateWithHistory(task *model.Task, userId uint, taskId uint, values map[string]interface{}) error {
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		oldTask := model.Task{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("team_id IN (?)", tx.Table("team_members").Select("team_id").Where("user_id=? AND delete_flg=?", userId, false)).First(&oldTask, taskId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("object does not exist")
+			}
+			return err
+		}
+		values["sequence"] = gorm.Expr("sequence + 1")
+		if err := tx.Model(task).Clauses(clause.Returning{}).Where("id=?", taskId).Updates(values).Error; err != nil {
+			return err
+		}
+		return recordStatusChange(tx, oldTask.ID, oldTask.Status, task.Status, userId)
+	})
+}
+
+func (tr *taskRepository) DeleteTask(userId uint, taskId uint) error {
+	result := tr.db.Where("id=? AND team_id IN (?)", taskId, tr.db.Table("team_members").Select("team_id").Where("user_id=? AND delete_flg=?", userId, false)).Delete(&model.Task{})
 	if result.Error != nil {
 		return result.Error
 	}
@@ -76,45 +147,208 @@ func (tr *taskRepository) UpdateTask(task *model.Task, userId uint, taskId uint)
 	return nil
 }
 
-func (tr *taskRepository) UpdateTaskStatus(task *model.Task, userId uint, taskId uint) error {
-	result := tr.db.Model(task).Clauses(clause.Returning{}).Where("id=? AND user_id=?", taskId, userId).Update("status", task.Status)
-	if result.Error != nil {
-		return result.Error
+func (tr *taskRepository) NarrowDownStatus(tasks *[]model.Task, userId uint, taskStatus int) error {
+	if err := tr.db.Joins("User").Where("user_id=? AND status=?", userId, taskStatus).Order("created_at").Find(tasks).Error; err != nil {
+		return err
 	}
-	if result.RowsAffected < 1 {
-		return fmt.Errorf("object does not exist")
+	return nil
+}
+
+func (tr *taskRepository) FuzzySearch(tasks *[]model.Task, userId uint, keyword string) error {
+	if err := tr.db.Joins("User").Where("user_id=? AND (title LIKE ? OR memo LIKE ?)", userId, "%"+keyword+"%", "%"+keyword+"%").Order("created_at").Find(tasks).Error; err != nil {
+		return err
 	}
 	return nil
 }
 
-func (tr *taskRepository) DeleteTask(userId uint, taskId uint) error {
-	result := tr.db.Where("id=? AND user_id=?", taskId, userId).Delete(&model.Task{})
+func (tr *taskRepository) FuzzySearchStatus(tasks *[]model.Task, userId uint, keyword string, taskStatus int) error {
+	if err := tr.db.Joins("User").Where("user_id=? AND (title LIKE ? OR memo LIKE ?) AND status=? ", userId, "%"+keyword+"%", "%"+keyword+"%", taskStatus).Order("created_at").Find(tasks).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tr *taskRepository) SearchTasks(tasks *[]model.Task, userId uint, query model.TaskQuery) error {
+	db := tr.db.Where("team_id IN (?)", tr.db.Table("team_members").Select("team_id").Where("user_id=? AND delete_flg=?", userId, false))
+	return tr.findTasks(db, tasks, userId, query)
+}
+
+func (tr *taskRepository) SearchProjectTasks(tasks *[]model.Task, userId uint, projectId uint, query model.TaskQuery) error {
+	query.ProjectId = projectId
+	return tr.findTasks(tr.db, tasks, userId, query)
+}
+
+// 検索条件で絞り込んで並び替える
+func (tr *taskRepository) findTasks(db *gorm.DB, tasks *[]model.Task, userId uint, query model.TaskQuery) error {
+	if query.TeamId != 0 {
+		db = db.Where("team_id=?", query.TeamId)
+	}
+	if query.ProjectId != 0 {
+		db = db.Where("project_id=?", query.ProjectId)
+	}
+	if len(query.Statuses) > 0 {
+		db = db.Where("status IN ?", query.Statuses)
+	}
+	if query.Keyword != "" {
+		db = db.Where("(title LIKE ? OR memo LIKE ?)", "%"+query.Keyword+"%", "%"+query.Keyword+"%")
+	}
+	if query.DeadlineFrom != "" {
+		db = db.Where("dead_line >= ?", query.DeadlineFrom)
+	}
+	if query.DeadlineTo != "" {
+		db = db.Where("dead_line <= ?", query.DeadlineTo)
+	}
+	if query.Overdue {
+		db = db.Where("dead_line < CURRENT_DATE AND status <> ?", model.TaskStatusCompleted)
+	}
+	if query.AssignedToMe {
+		db = db.Where("id IN (?)", tr.db.Table("in_charges").Select("task_id").Where("user_id=?", userId))
+	}
+
+	column, ok := taskSortColumns[query.SortBy]
+	if !ok {
+		column = "created_at"
+	}
+	order := clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: query.Order == "desc"}
+	if err := db.Order(order).Order("id").Find(tasks).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tr *taskRepository) GetMemberTaskById(task *model.Task, userId uint, taskId uint) error {
+	if err := tr.db.Where("team_id IN (?)", tr.db.Table("team_members").Select("team_id").Where("user_id=? AND delete_flg=?", userId, false)).First(task, taskId).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// 位置が未設定のタスクは列の末尾に作成日順で並べる
+func boardOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("position = ''").Order(`position COLLATE "C"`).Order("created_at").Order("id")
+}
+
+func (tr *taskRepository) GetBoardTasks(tasks *[]model.Task, teamId uint) error {
+	if err := tr.db.Where("team_id=?", teamId).Order("status").Scopes(boardOrder).Find(tasks).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tr *taskRepository) GetColumnTasks(tasks *[]model.Task, teamId uint, taskStatus model.TaskStatus) error {
+	if err := tr.db.Where("team_id=? AND status=?", teamId, taskStatus).Scopes(boardOrder).Find(tasks).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tr *taskRepository) MoveTask(task *model.Task, userId uint, taskId uint, taskStatus model.TaskStatus, position string) error {
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		oldTask := model.Task{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&oldTask, taskId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("object does not exist")
+			}
+			return err
+		}
+		if err := tx.Model(task).Clauses(clause.Returning{}).Where("id=?", taskId).Updates(map[string]interface{}{"status": taskStatus, "position": position, "sequence": gorm.Expr("sequence + 1")}).Error; err != nil {
+			return err
+		}
+		return recordStatusChange(tx, oldTask.ID, oldTask.Status, taskStatus, userId)
+	})
+}
+
+func (tr *taskRepository) UpdateTaskPositions(tasks []model.Task) error {
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		for _, v := range tasks {
+			if err := tx.Model(&model.Task{}).Where("id=?", v.ID).Update("position", v.Position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (tr *taskRepository) CreateTasks(tasks *[]model.Task) error {
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(tasks).Error; err != nil {
+			return err
+		}
+		return recordTasksCreated(tx, *tasks)
+	})
+}
+
+func (tr *taskRepository) CreateTasksWithAssignees(tasks *[]model.Task, assignees [][]uint) error {
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(tasks).Error; err != nil {
+			return err
+		}
+		for i, v := range *tasks {
+			for _, userId := range assignees[i] {
+				if err := tx.Create(&model.InCharge{TaskID: v.ID, UserID: userId}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return recordTasksCreated(tx, *tasks)
+	})
+}
+
+func (tr *taskRepository) UpdateTaskMemo(task *model.Task, taskId uint, oldMemo string, newMemo string) error {
+	result := tr.db.Model(task).Clauses(clause.Returning{}).Where("id=? AND memo=?", taskId, oldMemo).Updates(map[string]interface{}{"memo": newMemo, "sequence": gorm.Expr("sequence + 1")})
 	if result.Error != nil {
 		return result.Error
 	}
 	if result.RowsAffected < 1 {
-		return fmt.Errorf("object does not exist")
+		return ErrConflict
 	}
 	return nil
 }
 
-func (tr *taskRepository) NarrowDownStatus(tasks *[]model.Task, userId uint, taskStatus int) error {
-	if err := tr.db.Joins("User").Where("user_id=? AND status=?", userId, taskStatus).Order("created_at").Find(tasks).Error; err != nil {
+func (tr *taskRepository) GetAssignees(users *[]model.User, taskId uint) error {
+	if err := tr.db.Where("id IN (?)", tr.db.Model(&model.InCharge{}).Select("user_id").Where("task_id=?", taskId)).Order("id").Find(users).Error; err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func (tr *taskRepository) FuzzySearch(tasks *[]model.Task, userId uint, keyword string) error {
-	if err := tr.db.Joins("User").Where("user_id=? AND (title LIKE ? OR memo LIKE ?)", userId, "%"+keyword+"%", "%"+keyword+"%").Order("created_at").Find(tasks).Error; err != nil {
+func (tr *taskRepository) AssignUser(inCharge *model.InCharge) error {
+	if err := tr.db.Create(inCharge).Error; err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func (tr *taskRepository) FuzzySearchStatus(tasks *[]model.Task, userId uint, keyword string, taskStatus int) error {
-	if err := tr.db.Joins("User").Where("user_id=? AND (title LIKE ? OR memo LIKE ?) AND status=? ", userId, "%"+keyword+"%", "%"+keyword+"%", taskStatus).Order("created_at").Find(tasks).Error; err != nil {
+func (tr *taskRepository) UnassignUser(taskId uint, userId uint) error {
+	result := tr.db.Where("task_id=? AND user_id=?", taskId, userId).Delete(&model.InCharge{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("the user is not assigned to the task")
+	}
+	return nil
+}
+
+func (tr *taskRepository) GetDueTasks(tasks *[]model.Task, fromDate time.Time, toDate time.Time) error {
+	// dead_line は date 型のため、時刻やタイムゾーンに左右されないよう日付の文字列で比べる
+	if err := tr.db.Where("dead_line BETWEEN ? AND ? AND status <> ?", fromDate.Format("2006-01-02"), toDate.Format("2006-01-02"), model.TaskStatusCompleted).Order("dead_line").Find(tasks).Error; err != nil {
 		return err
 	}
+
 	return nil
-}
\ No newline at end of file
+}
+
+func (tr *taskRepository) UpdateTaskEstimate(task *model.Task, taskId uint, estimate string, storyPoints *float64) error {
+	result := tr.db.Model(task).Clauses(clause.Returning{}).Where("id=?", taskId).Updates(map[string]interface{}{"estimate": estimate, "story_points": storyPoints})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"go-rest-api/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ITaskHistoryRepository interface {
+	// タスクのステータスの変更履歴を古い順に取得する
+	GetStatusChanges(changes *[]model.TaskStatusChange, taskIds []uint) error
+}
+
+type taskHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewTaskHistoryRepository(db *gorm.DB) ITaskHistoryRepository {
+	return &taskHistoryRepository{db}
+}
+
+func (hr *taskHistoryRepository) GetStatusChanges(changes *[]model.TaskStatusChange, taskIds []uint) error {
+	if len(taskIds) == 0 {
+		return nil
+	}
+	if err := hr.db.Where("task_id IN ?", taskIds).Order("changed_at").Order("id").Find(changes).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// 作成したタスクの最初のステータスを履歴に残す
+func recordTasksCreated(tx *gorm.DB, tasks []model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	changes := make([]model.TaskStatusChange, len(tasks))
+	for i, v := range tasks {
+		changes[i] = model.TaskStatusChange{TaskId: v.ID, ToStatus: v.Status, ChangedBy: v.CreatedBy, ChangedAt: v.CreatedAt}
+	}
+	return tx.Create(&changes).Error
+}
+
+// ステータスが変わった場合だけ履歴に残す
+func recordStatusChange(tx *gorm.DB, taskId uint, oldStatus model.TaskStatus, newStatus model.TaskStatus, userId uint) error {
+	if oldStatus == newStatus {
+		return nil
+	}
+	change := model.TaskStatusChange{TaskId: taskId, FromStatus: &oldStatus, ToStatus: newStatus, ChangedBy: userId, ChangedAt: time.Now()}
+	return tx.Create(&change).Error
+}
+
+// 履歴のないタスクは作成日に未着手で作られ、更新日に今のステータスになったとみなす
+func estimateStatusChanges(task model.Task) *[]model.TaskStatusChange {
+	unstarted := model.TaskStatusUnstarted
+	changes := []model.TaskStatusChange{{TaskId: task.ID, ToStatus: unstarted, ChangedAt: task.CreatedAt}}
+	if task.Status != unstarted {
+		changes = append(changes, model.TaskStatusChange{TaskId: task.ID, FromStatus: &unstarted, ToStatus: task.Status, ChangedAt: task.UpdatedAt})
+	}
+	return &changes
+}
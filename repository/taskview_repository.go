@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ITaskViewRepository interface {
+	// 保存済みビューを作成する
+	CreateTaskView(taskView *model.TaskView) error
+	// 保存済みビューを取得する
+	GetTaskViewById(taskView *model.TaskView, taskViewId uint) error
+	// 自分のビューと所属チームで共有されたビューを取得する
+	GetTaskViewsByUserId(taskViews *[]model.TaskView, userId uint) error
+	// チームで共有されたビューを取得する(ピン留めを先頭に並べる)
+	GetTaskViewsByTeamId(taskViews *[]model.TaskView, teamId uint) error
+	// 保存済みビューを更新する
+	UpdateTaskView(taskView *model.TaskView, taskViewId uint) error
+	// ピン留めを切り替える
+	UpdateTaskViewPinned(taskView *model.TaskView, taskViewId uint, pinned bool) error
+	// 保存済みビューを削除する
+	DeleteTaskView(taskViewId uint) error
+}
+
+type taskViewRepository struct {
+	db *gorm.DB
+}
+
+func NewTaskViewRepository(db *gorm.DB) ITaskViewRepository {
+	return &taskViewRepository{db}
+}
+
+func (tvr *taskViewRepository) CreateTaskView(taskView *model.TaskView) error {
+	if err := tvr.db.Create(taskView).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tvr *taskViewRepository) GetTaskViewById(taskView *model.TaskView, taskViewId uint) error {
+	if err := tvr.db.First(taskView, taskViewId).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tvr *taskViewRepository) GetTaskViewsByUserId(taskViews *[]model.TaskView, userId uint) error {
+	if err := tvr.db.
+		Where("user_id=?", userId).
+		Or("team_id IN (?)", tvr.db.Table("team_members").Select("team_id").Where("user_id=? AND delete_flg=?", userId, false)).
+		Order("pinned desc").Order("name").
+		Find(taskViews).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tvr *taskViewRepository) GetTaskViewsByTeamId(taskViews *[]model.TaskView, teamId uint) error {
+	if err := tvr.db.Where("team_id=?", teamId).Order("pinned desc").Order("name").Find(taskViews).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tvr *taskViewRepository) UpdateTaskView(taskView *model.TaskView, taskViewId uint) error {
+	result := tvr.db.Model(taskView).Clauses(clause.Returning{}).Where("id=?", taskViewId).Select("name", "query", "team_id").Updates(taskView)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
+
+func (tvr *taskViewRepository) UpdateTaskViewPinned(taskView *model.TaskView, taskViewId uint, pinned bool) error {
+	result := tvr.db.Model(taskView).Clauses(clause.Returning{}).Where("id=?", taskViewId).Update("pinned", pinned)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
+
+func (tvr *taskViewRepository) DeleteTaskView(taskViewId uint) error {
+	result := tvr.db.Where("id=?", taskViewId).Delete(&model.TaskView{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
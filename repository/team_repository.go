@@ -5,6 +5,7 @@ import (
 	"go-rest-api/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ITeamRepository interface {
@@ -16,6 +17,10 @@ type ITeamRepository interface {
 	GetTeamsByOrganizationId(teams *[]model.Team, organizationId uint) error
 	// チームを削除する
 	DeleteTeam(teamId uint) error
+	// チームを取得する
+	GetTeamById(team *model.Team, teamId uint) error
+	// 見積もりの尺度を変更する
+	UpdateEstimateScale(team *model.Team, teamId uint, scale model.EstimateScale) error
 }
 
 type teamRepository struct {
@@ -66,3 +71,21 @@ func (tr *teamRepository) DeleteTeam(teamId uint) error {
 	return nil
 }
 
+func (tr *teamRepository) GetTeamById(team *model.Team, teamId uint) error {
+	if err := tr.db.First(team, teamId).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tr *teamRepository) UpdateEstimateScale(team *model.Team, teamId uint, scale model.EstimateScale) error {
+	result := tr.db.Model(team).Clauses(clause.Returning{}).Where("id=?", teamId).Update("estimate_scale", scale)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
@@ -15,6 +15,12 @@ type ITeamMemberRepository interface {
 	UnassignFromTeam(teamMember *model.TeamMember, userId uint, teamId uint) error
 	// ユーザーIDからチームを取得
 	GetTeamMembersByTeamId(teamMember *[]model.TeamMember, userId uint) error
+	// チームに参加中のメンバーを取得
+	GetActiveTeamMember(teamMember *model.TeamMember, userId uint, teamId uint) error
+	// チームに参加中のメンバーのユーザーIDを取得
+	GetActiveTeamMemberIds(userIds *[]uint, teamId uint) error
+	// ユーザーが参加中のチームIDを取得
+	GetActiveTeamIds(teamIds *[]uint, userId uint) error
 }
 
 type teamMemberRepository struct {
@@ -55,4 +61,28 @@ func (tmr *teamMemberRepository) GetTeamMembersByTeamId(teamMembers *[]model.Tea
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (tmr *teamMemberRepository) GetActiveTeamMember(teamMember *model.TeamMember, userId uint, teamId uint) error {
+	if err := tmr.db.Where("user_id=? AND team_id=? AND delete_flg=?", userId, teamId, false).First(teamMember).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tmr *teamMemberRepository) GetActiveTeamMemberIds(userIds *[]uint, teamId uint) error {
+	if err := tmr.db.Model(&model.TeamMember{}).Where("team_id=? AND delete_flg=?", teamId, false).Pluck("user_id", userIds).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tmr *teamMemberRepository) GetActiveTeamIds(teamIds *[]uint, userId uint) error {
+	if err := tmr.db.Model(&model.TeamMember{}).Where("user_id=? AND delete_flg=?", userId, false).Pluck("team_id", teamIds).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
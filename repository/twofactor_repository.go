@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ITwoFactorRepository interface {
+	// ユーザーの2段階認証の設定を取得する。設定していない場合は ID が0のまま返す
+	GetTwoFactor(twoFactor *model.TwoFactor, userId uint) error
+	// 有効にする前の秘密鍵を保存する。設定途中の秘密鍵は置き換える
+	SavePendingSecret(twoFactor *model.TwoFactor) error
+	// 2段階認証を有効にし、リカバリーコードを作成する
+	EnableTwoFactor(userId uint, step int64, recoveryCodes []model.RecoveryCode) error
+	// 使ったコードの時間ステップを記録する。既に同じか後のステップを使っていた場合はエラーを返す
+	UpdateLastUsedStep(userId uint, step int64) error
+	// 2段階認証を無効にし、リカバリーコードを削除する
+	DeleteTwoFactor(userId uint) error
+	// 未使用のリカバリーコードを使用済みにする
+	UseRecoveryCode(userId uint, codeHash string, now time.Time) error
+	// リカバリーコードを作り直す
+	ReplaceRecoveryCodes(userId uint, recoveryCodes []model.RecoveryCode) error
+	// 未使用のリカバリーコードの数を数える
+	CountRecoveryCodes(userId uint) (int64, error)
+	// ログインのチャレンジを作成する
+	CreateLoginChallenge(challenge *model.LoginChallenge) error
+	// ログインのチャレンジをハッシュから取得する
+	GetLoginChallengeByHash(challenge *model.LoginChallenge, tokenHash string) error
+	// コードを間違えた回数を増やす
+	IncrementChallengeAttempts(challengeId uint) error
+	// チャレンジを使用済みにする
+	UseLoginChallenge(challengeId uint, now time.Time) error
+	// 期限の過ぎたチャレンジを削除する
+	DeleteExpiredLoginChallenges(now time.Time) error
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) ITwoFactorRepository {
+	return &twoFactorRepository{db}
+}
+
+func (tfr *twoFactorRepository) GetTwoFactor(twoFactor *model.TwoFactor, userId uint) error {
+	if err := tfr.db.Where("user_id=?", userId).Limit(1).Find(twoFactor).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tfr *twoFactorRepository) SavePendingSecret(twoFactor *model.TwoFactor) error {
+	result := tfr.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "last_used_step", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "two_factors.enabled_at IS NULL"}}},
+	}).Select("user_id", "secret", "last_used_step", "created_at", "updated_at").Create(twoFactor)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("two-factor authentication is already enabled")
+	}
+	return nil
+}
+
+func (tfr *twoFactorRepository) EnableTwoFactor(userId uint, step int64, recoveryCodes []model.RecoveryCode) error {
+	return tfr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.TwoFactor{}).Where("user_id=? AND enabled_at IS NULL", userId).Updates(map[string]interface{}{
+			"enabled_at": time.Now(),
+			"last_used_step": step,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return fmt.Errorf("object does not exist")
+		}
+		return replaceRecoveryCodes(tx, userId, recoveryCodes)
+	})
+}
+
+func (tfr *twoFactorRepository) UpdateLastUsedStep(userId uint, step int64) error {
+	result := tfr.db.Model(&model.TwoFactor{}).Where("user_id=? AND last_used_step < ?", userId, step).Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("the code has already been used")
+	}
+	return nil
+}
+
+func (tfr *twoFactorRepository) DeleteTwoFactor(userId uint) error {
+	return tfr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id=?", userId).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("user_id=?", userId).Delete(&model.TwoFactor{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return fmt.Errorf("object does not exist")
+		}
+		return nil
+	})
+}
+
+func (tfr *twoFactorRepository) UseRecoveryCode(userId uint, codeHash string, now time.Time) error {
+	result := tfr.db.Model(&model.RecoveryCode{}).Where("user_id=? AND code_hash=? AND used_at IS NULL", userId, codeHash).Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
+
+func (tfr *twoFactorRepository) ReplaceRecoveryCodes(userId uint, recoveryCodes []model.RecoveryCode) error {
+	return tfr.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userId, recoveryCodes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userId uint, recoveryCodes []model.RecoveryCode) error {
+	if err := tx.Where("user_id=?", userId).Delete(&model.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(recoveryCodes) == 0 {
+		return nil
+	}
+	if err := tx.Create(&recoveryCodes).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tfr *twoFactorRepository) CountRecoveryCodes(userId uint) (int64, error) {
+	var count int64
+	if err := tfr.db.Model(&model.RecoveryCode{}).Where("user_id=? AND used_at IS NULL", userId).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (tfr *twoFactorRepository) CreateLoginChallenge(challenge *model.LoginChallenge) error {
+	if err := tfr.db.Create(challenge).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tfr *twoFactorRepository) GetLoginChallengeByHash(challenge *model.LoginChallenge, tokenHash string) error {
+	if err := tfr.db.Where("token_hash=?", tokenHash).First(challenge).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tfr *twoFactorRepository) IncrementChallengeAttempts(challengeId uint) error {
+	if err := tfr.db.Model(&model.LoginChallenge{}).Where("id=?", challengeId).Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tfr *twoFactorRepository) UseLoginChallenge(challengeId uint, now time.Time) error {
+	result := tfr.db.Model(&model.LoginChallenge{}).Where("id=? AND used_at IS NULL", challengeId).Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("the login challenge has already been used")
+	}
+	return nil
+}
+
+func (tfr *twoFactorRepository) DeleteExpiredLoginChallenges(now time.Time) error {
+	if err := tfr.db.Where("expires_at < ?", now).Delete(&model.LoginChallenge{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -10,6 +10,8 @@ import (
 
 type IUserRepository interface {
 	GetUserByEmail(user *model.User, email string) error
+	// 復元したユーザーのうち、元のメールアドレスがemailで引き継がれていないユーザーを取得する
+	GetUserByClaimEmail(user *model.User, email string) error
 	CreateUser(user *model.User) error
 	UpdateUserName(user * model.User, userId uint) error
 	AssignUserToOrganization(user *model.User, userId uint) error
@@ -17,6 +19,12 @@ type IUserRepository interface {
 	GetLoggedInUserDetails(user *model.User, userId uint) error
 	// 組織内のユーザー一覧情報を取得する
 	GetOrganizationUsers(users *[]model.User, organizationId uint) error
+	// メールアドレスからユーザーをまとめて取得する
+	GetUsersByEmails(users *[]model.User, emails []string) error
+	// IDからユーザーをまとめて取得する
+	GetUsersByIds(users *[]model.User, userIds []uint) error
+	// パスワード(ハッシュ)を更新する
+	UpdatePassword(userId uint, passwordHash string) error
 }
 
 type userRepository struct {
@@ -36,6 +44,14 @@ func (ur *userRepository) GetUserByEmail(user *model.User, email string) error {
 	return nil
 }
 
+func (ur *userRepository) GetUserByClaimEmail(user *model.User, email string) error {
+	if err := ur.db.Where("claim_email=? AND claim_email<>''", email).Order("id").First(user).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (ur *userRepository) CreateUser(user *model.User) error {
 	if err := ur.db.Create(user).Error; err != nil {
 		return err
@@ -82,4 +98,31 @@ func (ur *userRepository) GetOrganizationUsers(users *[]model.User, organization
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (ur *userRepository) GetUsersByEmails(users *[]model.User, emails []string) error {
+	if err := ur.db.Where("email IN ?", emails).Find(users).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ur *userRepository) GetUsersByIds(users *[]model.User, userIds []uint) error {
+	if err := ur.db.Where("id IN ?", userIds).Find(users).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ur *userRepository) UpdatePassword(userId uint, passwordHash string) error {
+	result := ur.db.Model(&model.User{}).Where("id=?", userId).Update("password", passwordHash)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
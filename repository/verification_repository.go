@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IVerificationRepository interface {
+	// 確認用のトークンを作成する。ユーザーの未使用のトークンは無効にする
+	CreateVerificationToken(verificationToken *model.EmailVerificationToken) error
+	// 有効なトークンを使用済みにして、ユーザーのメールアドレスを確認済みにする
+	VerifyEmail(verificationToken *model.EmailVerificationToken, tokenHash string, now time.Time) error
+	// 期限の過ぎたトークンを削除する
+	DeleteExpiredVerificationTokens(now time.Time) error
+}
+
+type verificationRepository struct {
+	db *gorm.DB
+}
+
+func NewVerificationRepository(db *gorm.DB) IVerificationRepository {
+	return &verificationRepository{db}
+}
+
+func (vr *verificationRepository) CreateVerificationToken(verificationToken *model.EmailVerificationToken) error {
+	return vr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.EmailVerificationToken{}).Where("user_id=? AND used_at IS NULL", verificationToken.UserId).Update("used_at", verificationToken.CreatedAt).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(verificationToken).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (vr *verificationRepository) VerifyEmail(verificationToken *model.EmailVerificationToken, tokenHash string, now time.Time) error {
+	return vr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(verificationToken).Clauses(clause.Returning{}).Where("token_hash=? AND used_at IS NULL AND expires_at > ?", tokenHash, now).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return fmt.Errorf("the verification token is invalid or has expired")
+		}
+		if err := tx.Model(&model.User{}).Where("id=?", verificationToken.UserId).Updates(map[string]interface{}{
+			"email": verificationToken.Email,
+			"email_verified_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (vr *verificationRepository) DeleteExpiredVerificationTokens(now time.Time) error {
+	if err := vr.db.Where("expires_at < ?", now).Delete(&model.EmailVerificationToken{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
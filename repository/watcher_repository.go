@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"go-rest-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IWatcherRepository interface {
+	// タスクをフォローしているユーザーを取得する
+	GetWatchers(users *[]model.User, taskId uint) error
+	// タスクをフォローしているユーザーのうち、タスクのチームに参加中のユーザーのIDを取得する
+	GetWatcherIds(userIds *[]uint, taskId uint) error
+	// フォローする(既にフォローしていれば何もしない)
+	AddWatchers(watchers *[]model.TaskWatcher) error
+	// フォローをやめる
+	RemoveWatcher(taskId uint, userId uint) error
+}
+
+type watcherRepository struct {
+	db *gorm.DB
+}
+
+func NewWatcherRepository(db *gorm.DB) IWatcherRepository {
+	return &watcherRepository{db}
+}
+
+func (wr *watcherRepository) GetWatchers(users *[]model.User, taskId uint) error {
+	if err := wr.db.Where("id IN (?)", wr.db.Model(&model.TaskWatcher{}).Select("user_id").Where("task_id=?", taskId)).Order("id").Find(users).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wr *watcherRepository) GetWatcherIds(userIds *[]uint, taskId uint) error {
+	// チームを抜けたユーザーには通知しない
+	teamIds := wr.db.Model(&model.Task{}).Select("team_id").Where("id=?", taskId)
+	members := wr.db.Model(&model.TeamMember{}).Select("user_id").Where("team_id IN (?) AND delete_flg=?", teamIds, false)
+	if err := wr.db.Model(&model.TaskWatcher{}).Where("task_id=? AND user_id IN (?)", taskId, members).Pluck("user_id", userIds).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wr *watcherRepository) AddWatchers(watchers *[]model.TaskWatcher) error {
+	if err := wr.db.Clauses(clause.OnConflict{DoNothing: true}).Create(watchers).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wr *watcherRepository) RemoveWatcher(taskId uint, userId uint) error {
+	if err := wr.db.Where("task_id=? AND user_id=?", taskId, userId).Delete(&model.TaskWatcher{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IWebhookRepository interface {
+	// Webhookを登録する
+	CreateWebhook(webhook *model.Webhook) error
+	// Webhookを取得する
+	GetWebhookById(webhook *model.Webhook, webhookId uint) error
+	// 組織のWebhook(チーム単位のものを含む)を取得する
+	GetWebhooksByOrganizationId(webhooks *[]model.Webhook, organizationId uint) error
+	// チーム単位のWebhookを取得する
+	GetWebhooksByTeamId(webhooks *[]model.Webhook, teamId uint) error
+	// URL・イベント・有効状態を更新する
+	UpdateWebhook(webhook *model.Webhook, webhookId uint) error
+	// Webhookを削除する
+	DeleteWebhook(webhookId uint) error
+	// チームのイベントを受け取る有効なWebhookを取得する
+	GetActiveWebhooksForTeam(webhooks *[]model.Webhook, teamId uint) error
+	// 配信の成否を記録し、連続失敗回数が上限に達したら無効にする
+	RecordWebhookResult(webhookId uint, succeeded bool, maxFailures int) error
+	// 配信を作成する
+	CreateDeliveries(deliveries *[]model.WebhookDelivery) error
+	// 送信時刻になった配信を取得し、他のインスタンスが同時に送らないよう次の送信時刻を先送りする
+	ClaimDueDeliveries(deliveries *[]model.WebhookDelivery, now time.Time, lease time.Duration, limit int) error
+	// 配信の結果を保存する
+	UpdateDelivery(delivery *model.WebhookDelivery) error
+	// 配信履歴を新しい順に取得する
+	GetDeliveriesByWebhookId(deliveries *[]model.WebhookDelivery, webhookId uint, offset int, limit int) error
+	// 配信を取得する
+	GetDeliveryById(delivery *model.WebhookDelivery, webhookId uint, deliveryId uint) error
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) IWebhookRepository {
+	return &webhookRepository{db}
+}
+
+func (wr *webhookRepository) CreateWebhook(webhook *model.Webhook) error {
+	if err := wr.db.Create(webhook).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) GetWebhookById(webhook *model.Webhook, webhookId uint) error {
+	if err := wr.db.First(webhook, webhookId).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) GetWebhooksByOrganizationId(webhooks *[]model.Webhook, organizationId uint) error {
+	if err := wr.db.Where("organization_id=?", organizationId).Order("id").Find(webhooks).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) GetWebhooksByTeamId(webhooks *[]model.Webhook, teamId uint) error {
+	if err := wr.db.Where("team_id=?", teamId).Order("id").Find(webhooks).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) UpdateWebhook(webhook *model.Webhook, webhookId uint) error {
+	result := wr.db.Model(webhook).Clauses(clause.Returning{}).Where("id=?", webhookId).Select("url", "events", "active", "failure_count", "disabled_at").Updates(webhook)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
+
+func (wr *webhookRepository) DeleteWebhook(webhookId uint) error {
+	result := wr.db.Where("id=?", webhookId).Delete(&model.Webhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return fmt.Errorf("object does not exist")
+	}
+	return nil
+}
+
+func (wr *webhookRepository) GetActiveWebhooksForTeam(webhooks *[]model.Webhook, teamId uint) error {
+	if err := wr.db.
+		Where("active=?", true).
+		Where("team_id=? OR (team_id=0 AND organization_id IN (?))", teamId, wr.db.Model(&model.Team{}).Select("organization_id").Where("id=?", teamId)).
+		Order("id").Find(webhooks).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) RecordWebhookResult(webhookId uint, succeeded bool, maxFailures int) error {
+	db := wr.db.Model(&model.Webhook{}).Where("id=?", webhookId)
+	if succeeded {
+		return db.Update("failure_count", 0).Error
+	}
+	// 右辺のfailure_countは更新前の値
+	return db.Updates(map[string]interface{}{
+		"failure_count": gorm.Expr("failure_count + 1"),
+		"active":        gorm.Expr("active AND failure_count + 1 < ?", maxFailures),
+		"disabled_at":   gorm.Expr("CASE WHEN active AND failure_count + 1 >= ? THEN ? ELSE disabled_at END", maxFailures, time.Now()),
+	}).Error
+}
+
+func (wr *webhookRepository) CreateDeliveries(deliveries *[]model.WebhookDelivery) error {
+	if err := wr.db.Create(deliveries).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) ClaimDueDeliveries(deliveries *[]model.WebhookDelivery, now time.Time, lease time.Duration, limit int) error {
+	return wr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status=? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+			Order("next_attempt_at").Limit(limit).Find(deliveries).Error; err != nil {
+			return err
+		}
+		if len(*deliveries) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(*deliveries))
+		for i, v := range *deliveries {
+			ids[i] = v.ID
+		}
+		return tx.Model(&model.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+}
+
+func (wr *webhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	if err := wr.db.Select("status", "attempts", "next_attempt_at", "response_status", "response_body", "error", "delivered_at", "updated_at").Updates(delivery).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) GetDeliveriesByWebhookId(deliveries *[]model.WebhookDelivery, webhookId uint, offset int, limit int) error {
+	if err := wr.db.Where("webhook_id=?", webhookId).Order("id desc").Offset(offset).Limit(limit).Find(deliveries).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) GetDeliveryById(delivery *model.WebhookDelivery, webhookId uint, deliveryId uint) error {
+	if err := wr.db.Where("id=? AND webhook_id=?", deliveryId, webhookId).First(delivery).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
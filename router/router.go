@@ -2,16 +2,20 @@ package router
 
 import (
 	"go-rest-api/controller"
+	"log"
+	"net"
 	"net/http"
 	"os"
+	"strings"
 
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
-func NewRouter(uc controller.IUserController, tc controller.ITaskController, oc controller.IOrganizationController, tec controller.ITeamController) *echo.Echo {
+func NewRouter(uc controller.IUserController, tc controller.ITaskController, oc controller.IOrganizationController, tec controller.ITeamController, tvc controller.ITaskViewController, bc controller.IBoardController, cc controller.ICalendarController, csvc controller.ICsvController, ac controller.IArchiveController, ic controller.IImporterController, cmc controller.ICommentController, mc controller.IMentionController, nc controller.INotificationController, wc controller.IWebhookController, sc controller.IStreamController, wtc controller.IWatcherController, spc controller.ISprintController, pc controller.IProjectController, rc controller.IReportController, ssc controller.ISessionController, pwc controller.IPasswordController, vc controller.IVerificationController, tfc controller.ITwoFactorController) *echo.Echo {
 	e := echo.New()
+	e.IPExtractor = ipExtractor()
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"https://localhost:3000", os.Getenv("FE_URL")},
 		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept,
@@ -31,8 +35,19 @@ func NewRouter(uc controller.IUserController, tc controller.ITaskController, oc
 
 	e.POST("/signup", uc.SignUp)
 	e.POST("/login", uc.LogIn)
+	// 2段階認証が有効なユーザーは /login で返したチャレンジとコードでログインする: {"challenge_token": "...", "code": "..."}
+	e.POST("/login/2fa", tfc.LogInWithCode)
 	e.GET("/csrf", uc.CsrfToken)
 	e.POST("/logout", uc.LogOut)
+	// アクセストークンの期限が切れたらリフレッシュトークンのCookieで取り直す
+	e.POST("/refresh", ssc.Refresh)
+	// パスワードの再設定: {"email": "..."} でメールを送り、{"token": "...", "password": "..."} で再設定する
+	e.POST("/password/forgot", pwc.ForgotPassword)
+	e.POST("/password/reset", pwc.ResetPassword)
+	// メールアドレスの確認: {"token": "..."}
+	e.POST("/email/verify", vc.VerifyEmail)
+	// カレンダーアプリからの購読用(JWTの代わりにURL内のトークンで認証する)
+	e.GET("/calendar/feed/:token", cc.GetCalendarFeed)
 
 	// ユーザー
 	u := e.Group("/users")
@@ -40,11 +55,27 @@ func NewRouter(uc controller.IUserController, tc controller.ITaskController, oc
 		SigningKey: []byte(os.Getenv("SECRET")),
 		TokenLookup: "cookie:jwtToken",
 	}))
+	u.Use(ssc.CheckRevoked)
 	u.GET("/userDetails", uc.GetLoggedInUserDetails)
 	u.PUT("/updateName", uc.UpdateUserName)
 	u.PUT("/assignToOrganization", uc.AssignUserToOrganization)
 	u.POST("/assignToTeam", uc.AssignUserToTeam)
 	u.PUT("/unassignFromTeam", uc.UnassignFromTeam)
+	u.GET("/mentions", mc.GetMyMentions)
+	u.GET("/sessions", ssc.GetSessions)
+	u.DELETE("/sessions", ssc.RevokeAllSessions)
+	u.DELETE("/sessions/:sessionId", ssc.RevokeSession)
+	u.POST("/email/verification", vc.ResendVerification)
+	// {"current_password": "...", "new_password": "..."} 他のセッションはログアウトされる
+	u.PUT("/password", pwc.ChangePassword)
+	// {"email": "...", "current_password": "..."} 新しいメールアドレスを確認すると変更される
+	u.PUT("/email", vc.ChangeEmail)
+	// 2段階認証(TOTP): setup で返したURIを認証アプリに登録し、enable でコードを確認すると有効になる
+	u.GET("/2fa", tfc.GetStatus)
+	u.POST("/2fa/setup", tfc.Setup)
+	u.POST("/2fa/enable", tfc.Enable)
+	u.POST("/2fa/disable", tfc.Disable)
+	u.POST("/2fa/recovery-codes", tfc.RegenerateRecoveryCodes)
 
 	// 組織
 	o := e.Group("/organization")
@@ -52,10 +83,16 @@ func NewRouter(uc controller.IUserController, tc controller.ITaskController, oc
 		SigningKey: []byte(os.Getenv("SECRET")),
 		TokenLookup: "cookie:jwtToken",
 	}))
+	o.Use(ssc.CheckRevoked)
 	o.GET("/:organizationId/users", uc.GetOrganizationUsers)
+	// 組織の作成者がユーザーのすべてのセッションを失効させる
+	o.POST("/:organizationId/users/:userId/logout", ssc.ForceLogout)
 	o.GET("/created", oc.GetCreatedOrganizationsByUserId)
 	o.GET("/lists", oc.ListOrganizations)
 	o.POST("/create", oc.CreateOrganization)
+	// バックアップと復元(復元は常に新しい組織として作成し、IDを振り直す)
+	o.GET("/:organizationId/backup", ac.BackupOrganization)
+	o.POST("/restore", ac.RestoreOrganization)
 
 	// チーム
 	te := o.Group("/team")
@@ -63,12 +100,16 @@ func NewRouter(uc controller.IUserController, tc controller.ITaskController, oc
 	te.GET("/:organizationId", tec.GetTeamsByOrganizationId)
 	te.POST("/:organizationId/create", tec.CreateTeam)
 	te.DELETE("/:teamId", tec.DeleteTeam)
+	// 見積もりの尺度: {"scale": "fibonacci" または "tshirt"}
+	te.GET("/:teamId/estimate-scale", tec.GetEstimateScale)
+	te.PUT("/:teamId/estimate-scale", tec.UpdateEstimateScale)
 
 	t := e.Group("/tasks")
 	t.Use(echojwt.WithConfig(echojwt.Config{
 		SigningKey: []byte(os.Getenv("SECRET")),
 		TokenLookup: "cookie:jwtToken",
 	}))
+	t.Use(ssc.CheckRevoked)
 	t.GET("", tc.GetAllTasks)
 	t.GET("/:taskId", tc.GetTaskById)
 	// http://localhost:8080/tasks/status?taskStatus={Started, Unstarted or Completed}
@@ -76,10 +117,189 @@ func NewRouter(uc controller.IUserController, tc controller.ITaskController, oc
 	t.GET("/status", tc.NarrowDownStatus)
 	t.GET("/search/status", tc.FuzzySearch)
 	t.GET("/by-deadlined", tc.GetTasksByDeadline)
+	// http://localhost:8080/tasks/query?status=Unstarted&overdue=true&assigned_to_me=true&sort_by=dead_line&order=asc
+	t.GET("/query", tc.QueryTasks)
 	// t.POST("", tc.CreateTask)
 	t.PUT("/:taskId", tc.UpdateTask)
 	t.PUT("/:taskId/statusUpdate", tc.UpdateTaskStatus)
+	// メモの index 番目(0始まり)の "- [ ]" を {"checked": true} で更新する
+	t.PUT("/:taskId/memo/checkboxes/:index", tc.UpdateMemoCheckbox)
+	// 担当者: {"user_id": 2}
+	t.GET("/:taskId/assignees", tc.GetAssignees)
+	t.POST("/:taskId/assignees", tc.AssignTask)
+	t.DELETE("/:taskId/assignees/:userId", tc.UnassignTask)
+	// 見積もり: {"estimate": "5"} (空文字で見積もりを外す)
+	t.PUT("/:taskId/estimate", tc.EstimateTask)
+	t.GET("/:taskId/watchers", wtc.GetWatchers)
+	t.POST("/:taskId/watchers", wtc.Watch)
+	t.DELETE("/:taskId/watchers", wtc.Unwatch)
+	// コメント
+	t.GET("/:taskId/comments", cmc.GetComments)
+	t.POST("/:taskId/comments", cmc.CreateComment)
+	t.PUT("/:taskId/comments/:commentId", cmc.UpdateComment)
+	t.DELETE("/:taskId/comments/:commentId", cmc.DeleteComment)
+	// http://localhost:8080/tasks/{taskId}/mentions/candidates?q={prefix}
+	t.GET("/:taskId/mentions/candidates", mc.GetMentionCandidates)
+	// カンバンボード: {"status": 1, "before_id": 10, "after_id": 12}
+	t.GET("/board/:teamId", bc.GetBoard)
+	// http://localhost:8080/tasks/calendar.ics?team_id={teamId} (省略時は自分の担当タスク)
+	t.GET("/calendar.ics", cc.ExportCalendar)
+	// /tasks/query と同じ条件を指定できる
+	t.GET("/export.csv", csvc.ExportTasks)
+	t.POST("/import/:teamId", csvc.ImportTasks)
+	t.POST("/import/:teamId/trello", ic.ImportTrello)
+	t.POST("/import/:teamId/github", ic.ImportGitHubIssues)
+	t.PUT("/:taskId/move", bc.MoveTask)
 	t.DELETE("/:taskId", tc.DeleteTask)
 
+	// 保存済みビュー
+	tv := t.Group("/views")
+	tv.GET("", tvc.GetTaskViews)
+	tv.POST("", tvc.CreateTaskView)
+	tv.GET("/team/:teamId", tvc.GetTeamTaskViews)
+	tv.GET("/:viewId/tasks", tvc.ExecuteTaskView)
+	tv.PUT("/:viewId", tvc.UpdateTaskView)
+	tv.PUT("/:viewId/pin", tvc.PinTaskView)
+	tv.PUT("/:viewId/unpin", tvc.UnpinTaskView)
+	tv.DELETE("/:viewId", tvc.DeleteTaskView)
+
+	// 通知
+	// http://localhost:8080/notifications?page=1&per_page=20&unread=true
+	n := e.Group("/notifications")
+	n.Use(echojwt.WithConfig(echojwt.Config{
+		SigningKey: []byte(os.Getenv("SECRET")),
+		TokenLookup: "cookie:jwtToken",
+	}))
+	n.Use(ssc.CheckRevoked)
+	n.GET("", nc.GetNotifications)
+	n.GET("/unread-count", nc.GetUnreadCount)
+	n.PUT("/read-all", nc.MarkAllRead)
+	n.PUT("/:notificationId/read", nc.MarkRead)
+	// [{"type": "mentioned", "in_app": false, "email": true}] (省略した項目は変更しない)
+	n.GET("/preferences", nc.GetPreferences)
+	n.PUT("/preferences", nc.UpdatePreferences)
+	// {"language": "en", "digest": true} (digest: 即時ではなく1日1回まとめて送る)
+	n.GET("/email", nc.GetEmailSetting)
+	n.PUT("/email", nc.UpdateEmailSetting)
+
+	// Webhook: {"team_id": 1, "url": "https://example.com/hook", "events": ["task.created", "task.updated"]}
+	// team_id を省略して organization_id を指定すると組織内のすべてのチームが対象になる
+	// 更新時は "active" も指定する(false にすると配信を止める)
+	w := e.Group("/webhooks")
+	w.Use(echojwt.WithConfig(echojwt.Config{
+		SigningKey: []byte(os.Getenv("SECRET")),
+		TokenLookup: "cookie:jwtToken",
+	}))
+	w.Use(ssc.CheckRevoked)
+	w.POST("", wc.CreateWebhook)
+	w.GET("/organization/:organizationId", wc.GetOrganizationWebhooks)
+	w.GET("/team/:teamId", wc.GetTeamWebhooks)
+	w.PUT("/:webhookId", wc.UpdateWebhook)
+	w.DELETE("/:webhookId", wc.DeleteWebhook)
+	w.GET("/:webhookId/deliveries", wc.GetDeliveries)
+	w.POST("/:webhookId/deliveries/:deliveryId/redeliver", wc.Redeliver)
+
+	// リアルタイム配信(Server-Sent Events): new EventSource("/stream", {withCredentials: true})
+	s := e.Group("/stream")
+	s.Use(echojwt.WithConfig(echojwt.Config{
+		SigningKey: []byte(os.Getenv("SECRET")),
+		TokenLookup: "cookie:jwtToken",
+	}))
+	s.Use(ssc.CheckRevoked)
+	s.GET("", sc.Stream)
+
+	// スプリント: {"team_id": 1, "name": "Sprint 1", "start_date": "2024-04-01T00:00:00Z", "end_date": "2024-04-12T00:00:00Z"}
+	sp := e.Group("/sprints")
+	sp.Use(echojwt.WithConfig(echojwt.Config{
+		SigningKey: []byte(os.Getenv("SECRET")),
+		TokenLookup: "cookie:jwtToken",
+	}))
+	sp.Use(ssc.CheckRevoked)
+	sp.POST("", spc.CreateSprint)
+	sp.GET("/team/:teamId", spc.GetTeamSprints)
+	sp.GET("/:sprintId", spc.GetSprint)
+	sp.PUT("/:sprintId", spc.UpdateSprint)
+	sp.DELETE("/:sprintId", spc.DeleteSprint)
+	// {"task_ids": [1, 2]}
+	sp.GET("/:sprintId/tasks", spc.GetSprintTasks)
+	sp.POST("/:sprintId/tasks", spc.AddSprintTasks)
+	sp.DELETE("/:sprintId/tasks/:taskId", spc.RemoveSprintTask)
+	// {"carry_over_to": 3} (省略時は未完了のタスクをバックログに戻す)
+	sp.POST("/:sprintId/close", spc.CloseSprint)
+	sp.GET("/:sprintId/report", spc.GetSprintReport)
+	// ?unit=points でストーリーポイントの合計にする(既定はタスク数)
+	sp.GET("/:sprintId/burndown", spc.GetBurndown)
+	sp.GET("/:sprintId/burnup", spc.GetBurnup)
+
+	// プロジェクト: {"team_id": 1, "name": "Renewal", "status": "active", "shared_team_ids": [2]}
+	p := e.Group("/projects")
+	p.Use(echojwt.WithConfig(echojwt.Config{
+		SigningKey: []byte(os.Getenv("SECRET")),
+		TokenLookup: "cookie:jwtToken",
+	}))
+	p.Use(ssc.CheckRevoked)
+	p.POST("", pc.CreateProject)
+	p.GET("/team/:teamId", pc.GetTeamProjects)
+	p.GET("/:projectId", pc.GetProject)
+	p.PUT("/:projectId", pc.UpdateProject)
+	// {"team_ids": [2, 3]}
+	p.PUT("/:projectId/teams", pc.UpdateProjectTeams)
+	p.DELETE("/:projectId", pc.DeleteProject)
+	// /tasks/query と同じ条件を指定できる
+	p.GET("/:projectId/tasks", pc.GetProjectTasks)
+	// {"task_ids": [1, 2]}
+	p.POST("/:projectId/tasks", pc.AddProjectTasks)
+	p.DELETE("/:projectId/tasks/:taskId", pc.RemoveProjectTask)
+	p.GET("/:projectId/progress", pc.GetProjectProgress)
+
+	// レポート
+	r := e.Group("/reports")
+	r.Use(echojwt.WithConfig(echojwt.Config{
+		SigningKey: []byte(os.Getenv("SECRET")),
+		TokenLookup: "cookie:jwtToken",
+	}))
+	r.Use(ssc.CheckRevoked)
+	// http://localhost:8080/reports/velocity/team/{teamId}?period=week&count=12 (period=sprint で終了したスプリントごと)
+	r.GET("/velocity/team/:teamId", rc.GetVelocity)
+	// http://localhost:8080/reports/stats/team/{teamId}?from=2024-01-01&to=2024-01-31&interval=day (interval=week で週ごと)
+	r.GET("/stats/team/:teamId", rc.GetTeamStats)
+	// http://localhost:8080/reports/stats/organization/{organizationId}?from=2024-01-01&to=2024-01-31&interval=week
+	r.GET("/stats/organization/:organizationId", rc.GetOrganizationStats)
+	// http://localhost:8080/reports/workload/team/{teamId}
+	r.GET("/workload/team/:teamId", rc.GetWorkload)
+	// http://localhost:8080/reports/cycle-time/team/{teamId}?from=2024-01-01&to=2024-03-31
+	r.GET("/cycle-time/team/:teamId", rc.GetCycleTime)
+	// http://localhost:8080/reports/cumulative-flow/team/{teamId}?from=2024-01-01&to=2024-03-31&interval=week
+	r.GET("/cumulative-flow/team/:teamId", rc.GetCumulativeFlow)
+
+	// カレンダー購読
+	ca := e.Group("/calendar/tokens")
+	ca.Use(echojwt.WithConfig(echojwt.Config{
+		SigningKey: []byte(os.Getenv("SECRET")),
+		TokenLookup: "cookie:jwtToken",
+	}))
+	ca.Use(ssc.CheckRevoked)
+	ca.GET("", cc.GetCalendarTokens)
+	ca.POST("", cc.CreateCalendarToken)
+	ca.DELETE("/:tokenId", cc.RevokeCalendarToken)
+
 	return e
-}
\ No newline at end of file
+}
+
+// クライアントのIPアドレスの取り方。レート制限やセッション一覧に使うため、送られてきたヘッダーをそのまま信用しない
+// TRUSTED_PROXIES(カンマ区切りのCIDR)を指定した場合は、そのプロキシが付けた X-Forwarded-For だけを使う
+func ipExtractor() echo.IPExtractor {
+	proxies := os.Getenv("TRUSTED_PROXIES")
+	if proxies == "" {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, v := range strings.Split(proxies, ",") {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(v))
+		if err != nil {
+			log.Fatalf("invalid TRUSTED_PROXIES %q: %v", v, err)
+		}
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+	"time"
+)
+
+type IArchiveUseCase interface {
+	// 組織をバックアップする(作成者のみ)
+	BackupOrganization(userId uint, organizationId uint) (model.OrganizationArchive, error)
+	// バックアップを新しい組織として復元する
+	RestoreOrganization(userId uint, archive model.OrganizationArchive) (model.OrganizationRestoreResponse, error)
+}
+
+type archiveUseCase struct {
+	ar repository.IArchiveRepository
+	or repository.IOrganizationRepository
+	tv validator.ITaskValidator
+	ev validator.IEstimateValidator
+}
+
+func NewArchiveUseCase(ar repository.IArchiveRepository, or repository.IOrganizationRepository, tv validator.ITaskValidator, ev validator.IEstimateValidator) IArchiveUseCase {
+	return &archiveUseCase{ar, or, tv, ev}
+}
+
+func (au *archiveUseCase) BackupOrganization(userId uint, organizationId uint) (model.OrganizationArchive, error) {
+	organization := model.Organization{}
+	if err := au.or.GetOrganizationById(&organization, organizationId); err != nil {
+		return model.OrganizationArchive{}, err
+	}
+	if organization.Founder != userId {
+		return model.OrganizationArchive{}, fmt.Errorf("only the founder can back up the organization")
+	}
+
+	archive := model.OrganizationArchive{
+		Version: model.OrganizationArchiveVersion,
+		ExportedAt: time.Now(),
+		Users: []model.ArchiveUser{},
+		Teams: []model.ArchiveTeam{},
+		TeamMembers: []model.ArchiveTeamMember{},
+		Tasks: []model.ArchiveTask{},
+		InCharges: []model.ArchiveInCharge{},
+		Comments: []model.ArchiveComment{},
+	}
+	if err := au.ar.GetOrganizationArchive(&archive, organizationId); err != nil {
+		return model.OrganizationArchive{}, err
+	}
+
+	return archive, nil
+}
+
+func (au *archiveUseCase) RestoreOrganization(userId uint, archive model.OrganizationArchive) (model.OrganizationRestoreResponse, error) {
+	if err := upgradeOrganizationArchive(&archive); err != nil {
+		return model.OrganizationRestoreResponse{}, err
+	}
+	if archive.Organization.Name == "" {
+		return model.OrganizationRestoreResponse{}, fmt.Errorf("organization name is required")
+	}
+	scales := map[uint]model.EstimateScale{}
+	for i, v := range archive.Teams {
+		if v.EstimateScale == "" {
+			archive.Teams[i].EstimateScale = model.EstimateScaleFibonacci
+		} else if err := au.ev.EstimateScaleValidate(model.EstimateScaleRequest{Scale: v.EstimateScale}); err != nil {
+			return model.OrganizationRestoreResponse{}, fmt.Errorf("team %d: %w", v.ID, err)
+		}
+		scales[v.ID] = archive.Teams[i].EstimateScale
+	}
+	// 画面から作成したタスクと同じ検証をしてから復元する
+	for i, v := range archive.Tasks {
+		task := model.Task{Title: v.Title, Status: v.Status}
+		if err := au.tv.TaskValidate(task); err != nil {
+			return model.OrganizationRestoreResponse{}, fmt.Errorf("task %d: %w", v.ID, err)
+		}
+		if err := au.tv.TaskStatusValidate(task); err != nil {
+			return model.OrganizationRestoreResponse{}, fmt.Errorf("task %d: %w", v.ID, err)
+		}
+		// 壊れたランクキーがあるとボードの並び替えができなくなる
+		if v.Position != "" && !isRankKey(v.Position) {
+			return model.OrganizationRestoreResponse{}, fmt.Errorf("task %d: invalid position %q", v.ID, v.Position)
+		}
+		// ポイントはバックアップの値を使わず、チームの尺度から求め直す
+		scale := scales[v.TeamId]
+		if err := au.ev.TaskEstimateValidate(model.TaskEstimate{Estimate: v.Estimate}, scale); err != nil {
+			return model.OrganizationRestoreResponse{}, fmt.Errorf("task %d: %w", v.ID, err)
+		}
+		archive.Tasks[i].StoryPoints = nil
+		if points, ok := scale.Points(v.Estimate); ok {
+			archive.Tasks[i].StoryPoints = &points
+		}
+	}
+
+	// 復元した組織の作成者は復元を実行したユーザーになる
+	resRestore := model.OrganizationRestoreResponse{}
+	if err := au.ar.RestoreOrganizationArchive(&archive, userId, &resRestore); err != nil {
+		return model.OrganizationRestoreResponse{}, err
+	}
+
+	return resRestore, nil
+}
+
+// 古い形式のバックアップを現在の形式に変換する
+func upgradeOrganizationArchive(archive *model.OrganizationArchive) error {
+	if archive.Version < 1 || archive.Version > model.OrganizationArchiveVersion {
+		return fmt.Errorf("unsupported archive version %d", archive.Version)
+	}
+	// バージョン1にはラベルがない。含まれていても形式外のため無視する
+	if archive.Version < 2 {
+		for i := range archive.Tasks {
+			archive.Tasks[i].Labels = []string{}
+		}
+	}
+	// バージョン2以前にはコメントがない
+	if archive.Version < 3 {
+		archive.Comments = []model.ArchiveComment{}
+	}
+	// バージョン3以前には見積もりがない。見積もりスケールは復元時の既定値にする
+	if archive.Version < 4 {
+		for i := range archive.Teams {
+			archive.Teams[i].EstimateScale = ""
+		}
+		for i := range archive.Tasks {
+			archive.Tasks[i].Estimate = ""
+			archive.Tasks[i].StoryPoints = nil
+		}
+	}
+	archive.Version = model.OrganizationArchiveVersion
+	return nil
+}
@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+)
+
+type IBoardUseCase interface {
+	// チームのタスクをステータスごとの列にまとめて取得する
+	GetBoard(userId uint, teamId uint) (model.BoardResponse, error)
+	// カードのステータスと列内の位置を変更する
+	MoveTask(userId uint, taskId uint, move model.TaskMove) (model.TaskResponse, error)
+}
+
+type boardUseCase struct {
+	tr  repository.ITaskRepository
+	tmr repository.ITeamMemberRepository
+	tv  validator.ITaskValidator
+	nu  INotificationUseCase
+	ep  IEventPublisher
+}
+
+func NewBoardUseCase(tr repository.ITaskRepository, tmr repository.ITeamMemberRepository, tv validator.ITaskValidator, nu INotificationUseCase, ep IEventPublisher) IBoardUseCase {
+	return &boardUseCase{tr, tmr, tv, nu, ep}
+}
+
+func (bu *boardUseCase) GetBoard(userId uint, teamId uint) (model.BoardResponse, error) {
+	if err := bu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return model.BoardResponse{}, fmt.Errorf("the user is not a member of the team")
+	}
+	tasks := make([]model.Task, 0)
+	if err := bu.tr.GetBoardTasks(&tasks, teamId); err != nil {
+		return model.BoardResponse{}, err
+	}
+
+	resBoard := model.BoardResponse{TeamId: teamId, Columns: make([]model.BoardColumn, len(model.TaskStatuses))}
+	for i, status := range model.TaskStatuses {
+		resBoard.Columns[i] = model.BoardColumn{Status: status, Name: status.String(), Cards: []model.TaskResponse{}}
+	}
+	for _, v := range tasks {
+		for i := range resBoard.Columns {
+			if resBoard.Columns[i].Status == v.Status {
+				resBoard.Columns[i].Cards = append(resBoard.Columns[i].Cards, toBoardCard(v))
+			}
+		}
+	}
+
+	return resBoard, nil
+}
+
+func (bu *boardUseCase) MoveTask(userId uint, taskId uint, move model.TaskMove) (model.TaskResponse, error) {
+	if err := bu.tv.TaskStatusValidate(model.Task{Status: move.Status}); err != nil {
+		return model.TaskResponse{}, err
+	}
+	task := model.Task{}
+	if err := bu.tr.GetMemberTaskById(&task, userId, taskId); err != nil {
+		return model.TaskResponse{}, err
+	}
+
+	column := make([]model.Task, 0)
+	if err := bu.tr.GetColumnTasks(&column, task.TeamId, move.Status); err != nil {
+		return model.TaskResponse{}, err
+	}
+	cards := make([]model.Task, 0, len(column))
+	for _, v := range column {
+		if v.ID != task.ID {
+			cards = append(cards, v)
+		}
+	}
+	if err := bu.fillPositions(cards); err != nil {
+		return model.TaskResponse{}, err
+	}
+
+	before, after, err := neighborPositions(cards, move)
+	if err != nil {
+		return model.TaskResponse{}, err
+	}
+	position, err := rankBetween(before, after)
+	if err != nil {
+		return model.TaskResponse{}, err
+	}
+
+	movedTask := model.Task{}
+	if err := bu.tr.MoveTask(&movedTask, userId, task.ID, move.Status, position); err != nil {
+		return model.TaskResponse{}, err
+	}
+	notifyStatusChange(bu.nu, movedTask, task.Status, userId)
+	bu.ep.Publish(taskEvent(model.EventTaskUpdated, movedTask, userId, toBoardCard(movedTask)))
+
+	return toBoardCard(movedTask), nil
+}
+
+// 位置が未設定のカード(列の末尾に並んでいる)にだけ末尾から順にキーを振る
+func (bu *boardUseCase) fillPositions(cards []model.Task) error {
+	prev := ""
+	unpositioned := make([]model.Task, 0)
+	for i := range cards {
+		if cards[i].Position != "" {
+			prev = cards[i].Position
+			continue
+		}
+		position, err := rankBetween(prev, "")
+		if err != nil {
+			return err
+		}
+		cards[i].Position = position
+		prev = position
+		unpositioned = append(unpositioned, cards[i])
+	}
+	if len(unpositioned) == 0 {
+		return nil
+	}
+	return bu.tr.UpdateTaskPositions(unpositioned)
+}
+
+// 移動先の直上・直下のカードのキーを返す。どちらも指定がなければ列の末尾に置く
+func neighborPositions(cards []model.Task, move model.TaskMove) (string, string, error) {
+	indexOf := func(id uint) int {
+		for i, v := range cards {
+			if v.ID == id {
+				return i
+			}
+		}
+		return -1
+	}
+
+	switch {
+	case move.BeforeId != 0:
+		i := indexOf(move.BeforeId)
+		if i < 0 {
+			return "", "", fmt.Errorf("before_id is not in the target column")
+		}
+		if i+1 < len(cards) {
+			if move.AfterId != 0 && cards[i+1].ID != move.AfterId {
+				return "", "", fmt.Errorf("before_id and after_id are not adjacent, reload the board")
+			}
+			return cards[i].Position, cards[i+1].Position, nil
+		}
+		if move.AfterId != 0 {
+			return "", "", fmt.Errorf("before_id and after_id are not adjacent, reload the board")
+		}
+		return cards[i].Position, "", nil
+	case move.AfterId != 0:
+		j := indexOf(move.AfterId)
+		if j < 0 {
+			return "", "", fmt.Errorf("after_id is not in the target column")
+		}
+		if j > 0 {
+			return cards[j-1].Position, cards[j].Position, nil
+		}
+		return "", cards[j].Position, nil
+	case len(cards) > 0:
+		return cards[len(cards)-1].Position, "", nil
+	}
+	return "", "", nil
+}
+
+// 新しく作成するタスクにステータスごとの列の末尾の位置を振る
+func appendToColumns(tr repository.ITaskRepository, tasks []model.Task, teamId uint) error {
+	last := map[model.TaskStatus]string{}
+	for _, status := range model.TaskStatuses {
+		column := make([]model.Task, 0)
+		if err := tr.GetColumnTasks(&column, teamId, status); err != nil {
+			return err
+		}
+		for _, v := range column {
+			if v.Position != "" {
+				last[status] = v.Position
+			}
+		}
+	}
+	for i := range tasks {
+		position, err := rankBetween(last[tasks[i].Status], "")
+		if err != nil {
+			return err
+		}
+		tasks[i].Position = position
+		last[tasks[i].Status] = position
+	}
+
+	return nil
+}
+
+func toBoardCard(task model.Task) model.TaskResponse {
+	return model.TaskResponse{
+		ID: task.ID,
+		Title: task.Title,
+		Status: task.Status,
+		Memo: task.Memo,
+		MemoHTML: renderMarkdown(task.Memo),
+		DeadLine: task.DeadLine,
+		CreatedAt: task.CreatedAt,
+		UpdatedAt: task.UpdatedAt,
+		Position: task.Position,
+		Labels: task.Labels,
+		SprintId: task.SprintId,
+		ProjectId: task.ProjectId,
+		Estimate: task.Estimate,
+		StoryPoints: task.StoryPoints,
+	}
+}
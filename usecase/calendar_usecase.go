@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"os"
+	"strings"
+	"time"
+)
+
+type ICalendarUseCase interface {
+	// チームまたは自分の担当タスクの期限をiCalendar形式で出力する
+	ExportCalendar(userId uint, teamId uint) ([]byte, error)
+	// 購読用トークンを発行する
+	CreateCalendarToken(userId uint, teamId uint) (model.CalendarTokenResponse, error)
+	// 購読用トークンの一覧を取得する
+	GetCalendarTokens(userId uint) ([]model.CalendarTokenResponse, error)
+	// 購読用トークンを無効にする
+	RevokeCalendarToken(userId uint, calendarTokenId uint) (model.CalendarTokenResponse, error)
+	// 購読用トークンでカレンダーを取得する(JWTなし)
+	GetCalendarFeed(token string) ([]byte, error)
+}
+
+type calendarUseCase struct {
+	ctr repository.ICalendarTokenRepository
+	tr  repository.ITaskRepository
+	tmr repository.ITeamMemberRepository
+}
+
+func NewCalendarUseCase(ctr repository.ICalendarTokenRepository, tr repository.ITaskRepository, tmr repository.ITeamMemberRepository) ICalendarUseCase {
+	return &calendarUseCase{ctr, tr, tmr}
+}
+
+func (cu *calendarUseCase) ExportCalendar(userId uint, teamId uint) ([]byte, error) {
+	if teamId != 0 {
+		if err := cu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+			return nil, fmt.Errorf("the user is not a member of the team")
+		}
+	}
+	// チーム指定がなければ自分の担当タスク、あればチームのタスク
+	tasks := make([]model.Task, 0)
+	query := model.TaskQuery{TeamId: teamId, AssignedToMe: teamId == 0, SortBy: "dead_line"}
+	if err := cu.tr.SearchTasks(&tasks, userId, query); err != nil {
+		return nil, err
+	}
+
+	name := "My tasks"
+	if teamId != 0 {
+		name = fmt.Sprintf("Team %d tasks", teamId)
+	}
+	return renderCalendar(name, tasks, time.Now()), nil
+}
+
+func (cu *calendarUseCase) CreateCalendarToken(userId uint, teamId uint) (model.CalendarTokenResponse, error) {
+	if teamId != 0 {
+		if err := cu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+			return model.CalendarTokenResponse{}, fmt.Errorf("the user is not a member of the team")
+		}
+	}
+	token, err := generateSecretToken()
+	if err != nil {
+		return model.CalendarTokenResponse{}, err
+	}
+
+	calendarToken := model.CalendarToken{TokenHash: hashSecretToken(token), UserId: userId, TeamId: teamId}
+	if err := cu.ctr.CreateCalendarToken(&calendarToken); err != nil {
+		return model.CalendarTokenResponse{}, err
+	}
+
+	resCalendarToken := toCalendarTokenResponse(calendarToken)
+	resCalendarToken.URL = fmt.Sprintf("%s/calendar/feed/%s.ics", calendarBaseURL(), token)
+
+	return resCalendarToken, nil
+}
+
+func (cu *calendarUseCase) GetCalendarTokens(userId uint) ([]model.CalendarTokenResponse, error) {
+	calendarTokens := make([]model.CalendarToken, 0)
+	if err := cu.ctr.GetCalendarTokensByUserId(&calendarTokens, userId); err != nil {
+		return nil, err
+	}
+
+	resCalendarTokens := make([]model.CalendarTokenResponse, len(calendarTokens))
+	for i, v := range calendarTokens {
+		resCalendarTokens[i] = toCalendarTokenResponse(v)
+	}
+
+	return resCalendarTokens, nil
+}
+
+func (cu *calendarUseCase) RevokeCalendarToken(userId uint, calendarTokenId uint) (model.CalendarTokenResponse, error) {
+	calendarToken := model.CalendarToken{}
+	if err := cu.ctr.RevokeCalendarToken(&calendarToken, userId, calendarTokenId); err != nil {
+		return model.CalendarTokenResponse{}, err
+	}
+
+	return toCalendarTokenResponse(calendarToken), nil
+}
+
+func (cu *calendarUseCase) GetCalendarFeed(token string) ([]byte, error) {
+	calendarToken := model.CalendarToken{}
+	if err := cu.ctr.GetActiveCalendarTokenByHash(&calendarToken, hashSecretToken(token)); err != nil {
+		return nil, fmt.Errorf("calendar feed does not exist")
+	}
+	if err := cu.ctr.UpdateCalendarTokenLastUsedAt(calendarToken.ID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// 発行したユーザーの現在の権限で出力する(チームを抜けていれば取得できない)
+	return cu.ExportCalendar(calendarToken.UserId, calendarToken.TeamId)
+}
+
+func toCalendarTokenResponse(calendarToken model.CalendarToken) model.CalendarTokenResponse {
+	return model.CalendarTokenResponse{
+		ID: calendarToken.ID,
+		TeamId: calendarToken.TeamId,
+		LastUsedAt: calendarToken.LastUsedAt,
+		RevokedAt: calendarToken.RevokedAt,
+		CreatedAt: calendarToken.CreatedAt,
+	}
+}
+
+// URLに載せられるランダムなトークンを生成する
+func generateSecretToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// 購読URLの起点。リクエストのHostヘッダーは詐称できるため設定値から組み立てる
+func calendarBaseURL() string {
+	if apiURL := os.Getenv("API_URL"); apiURL != "" {
+		return strings.TrimSuffix(apiURL, "/")
+	}
+	return "https://" + os.Getenv("API_DOMAIN")
+}
+
+// タスクの期限を終日の予定として出力する
+// UIDはタスクIDから決まるため、再取得するとカレンダー側で同じ予定が更新される
+// DTSTAMPは出力した時刻、SEQUENCEはタスクの版数とする(RFC 5545 3.8.7.2, 3.8.7.4)
+func renderCalendar(name string, tasks []model.Task, now time.Time) []byte {
+	var b strings.Builder
+	writeLine := func(line string) {
+		// 75オクテットごとに折り返す(RFC 5545 3.1)
+		for len(line) > 75 {
+			cut := 75
+			for cut > 0 && !isRuneStart(line[cut]) {
+				cut--
+			}
+			b.WriteString(line[:cut] + "\r\n")
+			line = " " + line[cut:]
+		}
+		b.WriteString(line + "\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//go_echo_todo//tasks//JA")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:" + escapeICalText(name))
+	for _, v := range tasks {
+		summary := v.Title
+		if v.Status == model.TaskStatusCompleted {
+			summary = "✔ " + summary
+		}
+		writeLine("BEGIN:VEVENT")
+		writeLine(fmt.Sprintf("UID:task-%d@go_echo_todo", v.ID))
+		writeLine("DTSTAMP:" + now.UTC().Format("20060102T150405Z"))
+		writeLine("LAST-MODIFIED:" + v.UpdatedAt.UTC().Format("20060102T150405Z"))
+		writeLine(fmt.Sprintf("SEQUENCE:%d", v.Sequence))
+		writeLine("DTSTART;VALUE=DATE:" + v.DeadLine.Format("20060102"))
+		writeLine("DTEND;VALUE=DATE:" + v.DeadLine.AddDate(0, 0, 1).Format("20060102"))
+		writeLine("SUMMARY:" + escapeICalText(summary))
+		if v.Memo != "" {
+			writeLine("DESCRIPTION:" + escapeICalText(v.Memo))
+		}
+		writeLine("CATEGORIES:" + v.Status.String())
+		writeLine("TRANSP:TRANSPARENT")
+		writeLine("END:VEVENT")
+	}
+	writeLine("END:VCALENDAR")
+
+	return []byte(b.String())
+}
+
+func escapeICalText(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, ";", "\\;")
+	s = strings.ReplaceAll(s, ",", "\\,")
+	s = strings.ReplaceAll(s, "\r\n", "\\n")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return s
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
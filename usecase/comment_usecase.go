@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+)
+
+type ICommentUseCase interface {
+	// タスクのコメント一覧を取得する
+	GetComments(userId uint, taskId uint) ([]model.TaskCommentResponse, error)
+	// コメントを投稿する
+	CreateComment(comment model.TaskComment, userId uint, taskId uint) (model.TaskCommentResponse, error)
+	// コメントを編集する(投稿者のみ)
+	UpdateComment(comment model.TaskComment, userId uint, taskId uint, commentId uint) (model.TaskCommentResponse, error)
+	// コメントを削除する(投稿者のみ)
+	DeleteComment(userId uint, taskId uint, commentId uint) error
+}
+
+type commentUseCase struct {
+	cr repository.ICommentRepository
+	tr repository.ITaskRepository
+	mr repository.IMentionRepository
+	mu IMentionUseCase
+	nu INotificationUseCase
+	wu IWatcherUseCase
+	ep IEventPublisher
+	cv validator.ICommentValidator
+}
+
+func NewCommentUseCase(cr repository.ICommentRepository, tr repository.ITaskRepository, mr repository.IMentionRepository, mu IMentionUseCase, nu INotificationUseCase, wu IWatcherUseCase, ep IEventPublisher, cv validator.ICommentValidator) ICommentUseCase {
+	return &commentUseCase{cr, tr, mr, mu, nu, wu, ep, cv}
+}
+
+func (cu *commentUseCase) GetComments(userId uint, taskId uint) ([]model.TaskCommentResponse, error) {
+	if err := cu.tr.GetMemberTaskById(&model.Task{}, userId, taskId); err != nil {
+		return nil, err
+	}
+	comments := make([]model.TaskComment, 0)
+	if err := cu.cr.GetCommentsByTaskId(&comments, taskId); err != nil {
+		return nil, err
+	}
+	mentions := make([]model.Mention, 0)
+	if err := cu.mr.GetMentionsByTaskId(&mentions, taskId); err != nil {
+		return nil, err
+	}
+	commentMentions := map[uint][]model.MentionResponse{}
+	for _, v := range mentions {
+		commentMentions[v.CommentId] = append(commentMentions[v.CommentId], toMentionResponse(v))
+	}
+
+	resComments := make([]model.TaskCommentResponse, len(comments))
+	for i, v := range comments {
+		resComments[i] = toCommentResponse(v, commentMentions[v.ID])
+	}
+
+	return resComments, nil
+}
+
+func (cu *commentUseCase) CreateComment(comment model.TaskComment, userId uint, taskId uint) (model.TaskCommentResponse, error) {
+	if err := cu.cv.CommentValidate(comment); err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+	task := model.Task{}
+	if err := cu.tr.GetMemberTaskById(&task, userId, taskId); err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+
+	newComment := model.TaskComment{Body: comment.Body, TaskId: taskId, UserId: userId}
+	if err := cu.cr.CreateComment(&newComment); err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+	mentions, err := cu.mu.SyncMentions(taskId, newComment.ID, userId, newComment.Body)
+	if err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+	notifyMentions(cu.nu, task, mentions)
+	notifyComment(cu.nu, task, userId)
+	cu.wu.AutoWatch(taskId, userId)
+	resComment, err := cu.commentResponse(newComment)
+	if err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+	cu.ep.Publish(taskEvent(model.EventCommentCreated, task, userId, resComment))
+
+	return resComment, nil
+}
+
+func (cu *commentUseCase) UpdateComment(comment model.TaskComment, userId uint, taskId uint, commentId uint) (model.TaskCommentResponse, error) {
+	if err := cu.cv.CommentValidate(comment); err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+	task := model.Task{}
+	if err := cu.tr.GetMemberTaskById(&task, userId, taskId); err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+	storedComment := model.TaskComment{}
+	if err := cu.cr.GetCommentById(&storedComment, taskId, commentId); err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+	if storedComment.UserId != userId {
+		return model.TaskCommentResponse{}, fmt.Errorf("only the author can edit the comment")
+	}
+
+	updatedComment := model.TaskComment{Body: comment.Body}
+	if err := cu.cr.UpdateComment(&updatedComment, commentId); err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+	mentions, err := cu.mu.SyncMentions(taskId, commentId, userId, updatedComment.Body)
+	if err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+	notifyMentions(cu.nu, task, mentions)
+	resComment, err := cu.commentResponse(updatedComment)
+	if err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+	cu.ep.Publish(taskEvent(model.EventCommentUpdated, task, userId, resComment))
+
+	return resComment, nil
+}
+
+func (cu *commentUseCase) DeleteComment(userId uint, taskId uint, commentId uint) error {
+	task := model.Task{}
+	if err := cu.tr.GetMemberTaskById(&task, userId, taskId); err != nil {
+		return err
+	}
+	storedComment := model.TaskComment{}
+	if err := cu.cr.GetCommentById(&storedComment, taskId, commentId); err != nil {
+		return err
+	}
+	if storedComment.UserId != userId {
+		return fmt.Errorf("only the author can delete the comment")
+	}
+	mentions := make([]model.Mention, 0)
+	if err := cu.mr.GetMentions(&mentions, taskId, commentId); err != nil {
+		return err
+	}
+	if len(mentions) > 0 {
+		ids := make([]uint, len(mentions))
+		for i, v := range mentions {
+			ids[i] = v.ID
+		}
+		if err := cu.mr.DeleteMentions(ids); err != nil {
+			return err
+		}
+	}
+	if err := cu.cr.DeleteComment(commentId); err != nil {
+		return err
+	}
+	cu.ep.Publish(taskEvent(model.EventCommentDeleted, task, userId, map[string]uint{"id": commentId, "task_id": taskId}))
+
+	return nil
+}
+
+func (cu *commentUseCase) commentResponse(comment model.TaskComment) (model.TaskCommentResponse, error) {
+	mentions := make([]model.Mention, 0)
+	if err := cu.mr.GetMentions(&mentions, comment.TaskId, comment.ID); err != nil {
+		return model.TaskCommentResponse{}, err
+	}
+	resMentions := make([]model.MentionResponse, len(mentions))
+	for i, v := range mentions {
+		resMentions[i] = toMentionResponse(v)
+	}
+
+	return toCommentResponse(comment, resMentions), nil
+}
+
+func toCommentResponse(comment model.TaskComment, mentions []model.MentionResponse) model.TaskCommentResponse {
+	if mentions == nil {
+		mentions = []model.MentionResponse{}
+	}
+	return model.TaskCommentResponse{
+		ID: comment.ID,
+		Body: comment.Body,
+		BodyHTML: renderMarkdown(comment.Body),
+		TaskId: comment.TaskId,
+		UserId: comment.UserId,
+		Mentions: mentions,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+}
@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Excelで文字化けしないように先頭に付けるBOM
+const utf8BOM = "\uFEFF"
+
+// 1回の取り込みで扱う最大行数
+const csvImportMaxRows = 5000
+
+var csvExportHeader = []string{"id", "title", "status", "memo", "dead_line", "team_id", "created_at", "updated_at"}
+
+type ICsvUseCase interface {
+	// 条件に合うタスクをCSVで出力する
+	ExportTasks(userId uint, query model.TaskQuery) ([]byte, error)
+	// CSVからチームにタスクを取り込む。dryRunのときは検証結果のみ返す
+	ImportTasks(userId uint, teamId uint, file io.Reader, mapping model.CsvColumnMapping, dryRun bool) (model.CsvImportResponse, error)
+}
+
+type csvUseCase struct {
+	tr  repository.ITaskRepository
+	tmr repository.ITeamMemberRepository
+	tv  validator.ITaskValidator
+	wu  IWatcherUseCase
+}
+
+func NewCsvUseCase(tr repository.ITaskRepository, tmr repository.ITeamMemberRepository, tv validator.ITaskValidator, wu IWatcherUseCase) ICsvUseCase {
+	return &csvUseCase{tr, tmr, tv, wu}
+}
+
+func (cu *csvUseCase) ExportTasks(userId uint, query model.TaskQuery) ([]byte, error) {
+	if err := cu.tv.TaskQueryValidate(query); err != nil {
+		return nil, err
+	}
+	tasks := make([]model.Task, 0)
+	if err := cu.tr.SearchTasks(&tasks, userId, query); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(utf8BOM)
+	w := csv.NewWriter(&buf)
+	w.UseCRLF = true
+	if err := w.Write(csvExportHeader); err != nil {
+		return nil, err
+	}
+	for _, v := range tasks {
+		record := []string{
+			strconv.FormatUint(uint64(v.ID), 10),
+			escapeCsvFormula(v.Title),
+			v.Status.String(),
+			escapeCsvFormula(v.Memo),
+			v.DeadLine.Format("2006-01-02"),
+			strconv.FormatUint(uint64(v.TeamId), 10),
+			v.CreatedAt.Format(time.RFC3339),
+			v.UpdatedAt.Format(time.RFC3339),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (cu *csvUseCase) ImportTasks(userId uint, teamId uint, file io.Reader, mapping model.CsvColumnMapping, dryRun bool) (model.CsvImportResponse, error) {
+	if err := cu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return model.CsvImportResponse{}, fmt.Errorf("the user is not a member of the team")
+	}
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return model.CsvImportResponse{}, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], utf8BOM)
+	}
+	columns, err := resolveCsvColumns(header, mapping)
+	if err != nil {
+		return model.CsvImportResponse{}, err
+	}
+
+	resImport := model.CsvImportResponse{DryRun: dryRun, Errors: []model.CsvRowError{}, Preview: []model.TaskResponse{}}
+	tasks := make([]model.Task, 0)
+	for row := 2; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return model.CsvImportResponse{}, fmt.Errorf("failed to read csv row %d: %w", row, err)
+		}
+		resImport.Total++
+		if resImport.Total > csvImportMaxRows {
+			return model.CsvImportResponse{}, fmt.Errorf("too many rows: limited max %d rows", csvImportMaxRows)
+		}
+
+		task, rowErrors := cu.parseCsvRow(record, columns)
+		task.TeamId = teamId
+		task.CreatedBy = userId
+		if len(rowErrors) > 0 {
+			resImport.Errors = append(resImport.Errors, model.CsvRowError{Row: row, Errors: rowErrors})
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	for _, v := range tasks {
+		resImport.Preview = append(resImport.Preview, model.TaskResponse{
+			Title: v.Title,
+			Status: v.Status,
+			Memo: v.Memo,
+			DeadLine: v.DeadLine,
+		})
+	}
+	// 1行でもエラーがあれば何も登録しない
+	if dryRun || len(resImport.Errors) > 0 || len(tasks) == 0 {
+		return resImport, nil
+	}
+
+	if err := appendToColumns(cu.tr, tasks, teamId); err != nil {
+		return model.CsvImportResponse{}, err
+	}
+	if err := cu.tr.CreateTasks(&tasks); err != nil {
+		return model.CsvImportResponse{}, err
+	}
+	resImport.Created = len(tasks)
+	for i, v := range tasks {
+		resImport.Preview[i] = toBoardCard(v)
+		cu.wu.AutoWatch(v.ID, userId)
+	}
+
+	return resImport, nil
+}
+
+// 表計算ソフトで数式として解釈される先頭の文字
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// 数式として実行されないよう、数式になる文字で始まるセルの先頭に ' を付ける
+func escapeCsvFormula(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// 出力時に付けた先頭の ' を取り除く
+func unescapeCsvFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+type csvColumns struct {
+	title, status, memo, deadLine int
+}
+
+// 列名から列番号を引く。タイトル以外の列は省略できる
+func resolveCsvColumns(header []string, mapping model.CsvColumnMapping) (csvColumns, error) {
+	indexOf := func(name string, fallback string) int {
+		if name == "" {
+			name = fallback
+		}
+		for i, v := range header {
+			if strings.TrimSpace(v) == name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	columns := csvColumns{
+		title: indexOf(mapping.Title, "title"),
+		status: indexOf(mapping.Status, "status"),
+		memo: indexOf(mapping.Memo, "memo"),
+		deadLine: indexOf(mapping.DeadLine, "dead_line"),
+	}
+	if columns.title < 0 {
+		return csvColumns{}, fmt.Errorf("title column is not found in csv header")
+	}
+
+	return columns, nil
+}
+
+func (cu *csvUseCase) parseCsvRow(record []string, columns csvColumns) (model.Task, []string) {
+	field := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(unescapeCsvFormula(strings.TrimSpace(record[i])))
+	}
+
+	rowErrors := make([]string, 0)
+	task := model.Task{Title: field(columns.title), Memo: field(columns.memo), DeadLine: time.Now().Truncate(24 * time.Hour)}
+	if v := field(columns.status); v != "" {
+		status, err := parseTaskStatus(v)
+		if err != nil {
+			rowErrors = append(rowErrors, err.Error())
+		}
+		task.Status = status
+	}
+	if v := field(columns.deadLine); v != "" {
+		deadLine, err := parseCsvDate(v)
+		if err != nil {
+			rowErrors = append(rowErrors, err.Error())
+		}
+		task.DeadLine = deadLine
+	}
+	if err := cu.tv.TaskValidate(task); err != nil {
+		rowErrors = append(rowErrors, err.Error())
+	}
+	if err := cu.tv.TaskStatusValidate(task); err != nil {
+		rowErrors = append(rowErrors, err.Error())
+	}
+
+	return task, rowErrors
+}
+
+func parseTaskStatus(v string) (model.TaskStatus, error) {
+	switch v {
+	case "Unstarted", "0", "未着手":
+		return model.TaskStatusUnstarted, nil
+	case "Started", "1", "着手中":
+		return model.TaskStatusStarted, nil
+	case "Completed", "2", "完了":
+		return model.TaskStatusCompleted, nil
+	}
+	return 0, fmt.Errorf("status must be one of Unstarted, Started or Completed: %q", v)
+}
+
+func parseCsvDate(v string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006/01/02", "2006/1/2"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("dead_line must be YYYY-MM-DD: %q", v)
+}
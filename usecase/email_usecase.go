@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"go-rest-api/mailer"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"log"
+	"time"
+)
+
+const (
+	// 最初の送信を含めた最大試行回数
+	maxEmailAttempts = 5
+	// 再送の間隔は 1分, 2分, 4分, ... と倍にしていく
+	emailRetryBase  = time.Minute
+	emailClaimLease = 2 * time.Minute
+	emailBatchSize  = 20
+)
+
+type IEmailUseCase interface {
+	// テンプレートからメールを作成して送信待ちに登録する
+	Enqueue(to string, language string, template string, data interface{}) error
+	// 送信時刻になったメールを送る。失敗したものは間隔を空けて再送する
+	ProcessQueue(now time.Time) error
+}
+
+type emailUseCase struct {
+	er repository.IEmailRepository
+	m  mailer.IMailer
+}
+
+func NewEmailUseCase(er repository.IEmailRepository, m mailer.IMailer) IEmailUseCase {
+	return &emailUseCase{er, m}
+}
+
+func (eu *emailUseCase) Enqueue(to string, language string, template string, data interface{}) error {
+	message, err := mailer.Render(language, template, to, data)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	job := model.EmailJob{
+		To:            message.To,
+		Subject:       message.Subject,
+		Text:          message.Text,
+		HTML:          message.HTML,
+		Status:        model.EmailJobPending,
+		NextAttemptAt: &now,
+	}
+	if err := eu.er.CreateEmailJob(&job); err != nil {
+		return err
+	}
+	go func() {
+		if err := eu.ProcessQueue(time.Now()); err != nil {
+			log.Printf("failed to send emails: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (eu *emailUseCase) ProcessQueue(now time.Time) error {
+	jobs := make([]model.EmailJob, 0)
+	if err := eu.er.ClaimDueEmailJobs(&jobs, now, emailClaimLease, emailBatchSize); err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		job.Attempts++
+		err := eu.m.Send(mailer.Message{To: job.To, Subject: job.Subject, Text: job.Text, HTML: job.HTML})
+		sentAt := time.Now()
+		switch {
+		case err == nil:
+			job.Status = model.EmailJobSent
+			job.NextAttemptAt = nil
+			job.SentAt = &sentAt
+			job.LastError = ""
+		case job.Attempts >= maxEmailAttempts:
+			job.Status = model.EmailJobFailed
+			job.NextAttemptAt = nil
+			job.LastError = err.Error()
+		default:
+			next := sentAt.Add(emailRetryBase << (job.Attempts - 1))
+			job.NextAttemptAt = &next
+			job.LastError = err.Error()
+		}
+		if err := eu.er.UpdateEmailJob(&job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
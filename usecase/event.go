@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"go-rest-api/model"
+	"time"
+)
+
+// タスク・コメントの変更を受け取る先(Webhookなど)
+type IEventPublisher interface {
+	// イベントを送る。失敗しても呼び出し元の操作は失敗させない
+	Publish(event model.Event)
+}
+
+type eventPublishers []IEventPublisher
+
+// 複数の送り先にまとめてイベントを送る
+func NewEventPublisher(publishers ...IEventPublisher) IEventPublisher {
+	return eventPublishers(publishers)
+}
+
+func (ep eventPublishers) Publish(event model.Event) {
+	for _, v := range ep {
+		v.Publish(event)
+	}
+}
+
+func taskEvent(eventType model.EventType, task model.Task, actorId uint, data interface{}) model.Event {
+	return model.Event{
+		Type: eventType,
+		TeamId: task.TeamId,
+		TaskId: task.ID,
+		ActorId: actorId,
+		Data: data,
+		OccurredAt: time.Now(),
+	}
+}
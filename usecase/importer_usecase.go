@@ -0,0 +1,344 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+	"io"
+	"strings"
+	"time"
+)
+
+// タスクのメモの文字数上限(memo列のサイズ)
+const maxTaskMemoLength = 65535
+
+type IImporterUseCase interface {
+	// Trelloのボードのエクスポート(JSON)を取り込む
+	ImportTrello(userId uint, teamId uint, file io.Reader, options model.ImportOptions) (model.ImportReport, error)
+	// GitHub IssuesのJSONダンプを取り込む
+	ImportGitHubIssues(userId uint, teamId uint, file io.Reader, options model.ImportOptions) (model.ImportReport, error)
+}
+
+type importerUseCase struct {
+	tr  repository.ITaskRepository
+	ur  repository.IUserRepository
+	tmr repository.ITeamMemberRepository
+	tv  validator.ITaskValidator
+	wu  IWatcherUseCase
+}
+
+func NewImporterUseCase(tr repository.ITaskRepository, ur repository.IUserRepository, tmr repository.ITeamMemberRepository, tv validator.ITaskValidator, wu IWatcherUseCase) IImporterUseCase {
+	return &importerUseCase{tr, ur, tmr, tv, wu}
+}
+
+// 取り込み元に依存しない中間形式
+type importItem struct {
+	sourceId   string
+	title      string
+	memo       string
+	url        string
+	status     model.TaskStatus
+	labels     []string
+	due        *time.Time
+	members    []string
+	skipReason string
+}
+
+type trelloBoard struct {
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		ID        string     `json:"id"`
+		Name      string     `json:"name"`
+		Desc      string     `json:"desc"`
+		IDList    string     `json:"idList"`
+		Closed    bool       `json:"closed"`
+		Due       *time.Time `json:"due"`
+		ShortURL  string     `json:"shortUrl"`
+		IDMembers []string   `json:"idMembers"`
+		Labels    []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+	Members []struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"members"`
+}
+
+type githubIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	Labels  []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Assignees []struct {
+		Login string `json:"login"`
+	} `json:"assignees"`
+	Milestone *struct {
+		DueOn *time.Time `json:"due_on"`
+	} `json:"milestone"`
+	PullRequest json.RawMessage `json:"pull_request"`
+}
+
+func (iu *importerUseCase) ImportTrello(userId uint, teamId uint, file io.Reader, options model.ImportOptions) (model.ImportReport, error) {
+	board := trelloBoard{}
+	if err := json.NewDecoder(file).Decode(&board); err != nil {
+		return model.ImportReport{}, fmt.Errorf("failed to read trello export: %w", err)
+	}
+
+	lists := map[string]string{}
+	closedLists := map[string]bool{}
+	for _, v := range board.Lists {
+		lists[v.ID] = v.Name
+		closedLists[v.ID] = v.Closed
+	}
+	members := map[string]string{}
+	for _, v := range board.Members {
+		members[v.ID] = v.Username
+	}
+
+	items := make([]importItem, 0, len(board.Cards))
+	for _, v := range board.Cards {
+		item := importItem{sourceId: v.ID, title: v.Name, memo: v.Desc, url: v.ShortURL, due: v.Due}
+		listName, ok := lists[v.IDList]
+		if !ok {
+			item.skipReason = "the list of the card is not in the export"
+		} else if (v.Closed || closedLists[v.IDList]) && !options.IncludeArchived {
+			item.skipReason = "archived"
+		}
+		if status, ok := options.StatusMapping[listName]; ok {
+			item.status = status
+		} else {
+			item.status = guessTaskStatus(listName)
+		}
+		for _, label := range v.Labels {
+			name := label.Name
+			if name == "" {
+				name = label.Color
+			}
+			item.labels = append(item.labels, name)
+		}
+		for _, id := range v.IDMembers {
+			if username, ok := members[id]; ok {
+				item.members = append(item.members, username)
+			}
+		}
+		items = append(items, item)
+	}
+
+	return iu.importItems(userId, teamId, "trello", items, options)
+}
+
+func (iu *importerUseCase) ImportGitHubIssues(userId uint, teamId uint, file io.Reader, options model.ImportOptions) (model.ImportReport, error) {
+	issues := make([]githubIssue, 0)
+	if err := json.NewDecoder(file).Decode(&issues); err != nil {
+		return model.ImportReport{}, fmt.Errorf("failed to read github issues: %w", err)
+	}
+
+	items := make([]importItem, 0, len(issues))
+	for _, v := range issues {
+		item := importItem{sourceId: fmt.Sprintf("#%d", v.Number), title: v.Title, memo: v.Body, url: v.HTMLURL}
+		if v.Milestone != nil {
+			item.due = v.Milestone.DueOn
+		}
+		if len(v.PullRequest) > 0 && string(v.PullRequest) != "null" {
+			item.skipReason = "pull request"
+		} else if v.State == "closed" && !options.IncludeArchived {
+			item.skipReason = "closed"
+		}
+
+		// クローズ済みは完了、それ以外は対応表にあるラベルからステータスを決める
+		item.status = model.TaskStatusUnstarted
+		for _, label := range v.Labels {
+			item.labels = append(item.labels, label.Name)
+			if status, ok := options.StatusMapping[label.Name]; ok {
+				item.status = status
+			}
+		}
+		if v.State == "closed" {
+			item.status = model.TaskStatusCompleted
+		}
+		for _, assignee := range v.Assignees {
+			item.members = append(item.members, assignee.Login)
+		}
+		items = append(items, item)
+	}
+
+	return iu.importItems(userId, teamId, "github", items, options)
+}
+
+func (iu *importerUseCase) importItems(userId uint, teamId uint, source string, items []importItem, options model.ImportOptions) (model.ImportReport, error) {
+	if err := iu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return model.ImportReport{}, fmt.Errorf("the user is not a member of the team")
+	}
+	memberIds, unmatched, err := iu.resolveMembers(teamId, items, options.MemberEmails)
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+
+	report := model.ImportReport{
+		Source: source,
+		DryRun: options.DryRun,
+		Created: []model.ImportedTask{},
+		Skipped: []model.ImportSkipped{},
+		UnmatchedMembers: unmatched,
+	}
+	tasks := make([]model.Task, 0)
+	assignees := make([][]uint, 0)
+	for _, v := range items {
+		if v.skipReason != "" {
+			report.Skipped = append(report.Skipped, model.ImportSkipped{SourceId: v.sourceId, Title: v.title, Reason: v.skipReason})
+			continue
+		}
+
+		task := model.Task{Title: v.title, Status: v.status, Labels: v.labels, TeamId: teamId, CreatedBy: userId, DeadLine: time.Now().Truncate(24 * time.Hour)}
+		if v.due != nil {
+			task.DeadLine = *v.due
+		}
+		// タイトルの文字数制限を超える分はメモの先頭に残す
+		header := ""
+		if title := []rune(v.title); len(title) > model.MaxTaskTitleLength {
+			task.Title = string(title[:model.MaxTaskTitleLength])
+			header = v.title
+		}
+		memo, truncated := importMemo(header, v.memo, v.url)
+		task.Memo = memo
+		if err := iu.tv.TaskValidate(task); err != nil {
+			report.Skipped = append(report.Skipped, model.ImportSkipped{SourceId: v.sourceId, Title: v.title, Reason: err.Error()})
+			continue
+		}
+		if err := iu.tv.TaskStatusValidate(task); err != nil {
+			report.Skipped = append(report.Skipped, model.ImportSkipped{SourceId: v.sourceId, Title: v.title, Reason: err.Error()})
+			continue
+		}
+
+		ids := make([]uint, 0)
+		for _, member := range v.members {
+			if id, ok := memberIds[member]; ok {
+				ids = append(ids, id)
+			}
+		}
+		tasks = append(tasks, task)
+		assignees = append(assignees, ids)
+		report.Created = append(report.Created, model.ImportedTask{SourceId: v.sourceId, Title: task.Title, Status: task.Status, Labels: task.Labels, Assignees: ids, MemoTruncated: truncated})
+	}
+	if options.DryRun || len(tasks) == 0 {
+		return report, nil
+	}
+
+	if err := appendToColumns(iu.tr, tasks, teamId); err != nil {
+		return model.ImportReport{}, err
+	}
+	if err := iu.tr.CreateTasksWithAssignees(&tasks, assignees); err != nil {
+		return model.ImportReport{}, err
+	}
+	for i, v := range tasks {
+		report.Created[i].TaskId = v.ID
+		iu.wu.AutoWatch(v.ID, append([]uint{userId}, assignees[i]...)...)
+	}
+
+	return report, nil
+}
+
+// 取り込み元のメンバー名をメールアドレス経由でチームのメンバーに対応付ける
+func (iu *importerUseCase) resolveMembers(teamId uint, items []importItem, memberEmails map[string]string) (map[string]uint, []string, error) {
+	names := make([]string, 0)
+	seen := map[string]bool{}
+	for _, v := range items {
+		for _, member := range v.members {
+			if !seen[member] {
+				seen[member] = true
+				names = append(names, member)
+			}
+		}
+	}
+
+	emails := make([]string, 0)
+	for _, name := range names {
+		if email, ok := memberEmails[name]; ok {
+			emails = append(emails, email)
+		}
+	}
+	users := make([]model.User, 0)
+	if len(emails) > 0 {
+		if err := iu.ur.GetUsersByEmails(&users, emails); err != nil {
+			return nil, nil, err
+		}
+	}
+	userIds := map[string]uint{}
+	for _, v := range users {
+		if err := iu.tmr.GetActiveTeamMember(&model.TeamMember{}, v.ID, teamId); err == nil {
+			userIds[v.Email] = v.ID
+		}
+	}
+
+	memberIds := map[string]uint{}
+	unmatched := make([]string, 0)
+	for _, name := range names {
+		if id, ok := userIds[memberEmails[name]]; ok {
+			memberIds[name] = id
+			continue
+		}
+		unmatched = append(unmatched, name)
+	}
+
+	return memberIds, unmatched, nil
+}
+
+// 元のタイトル・本文・URLからメモを組み立てる。
+// 上限を超える場合はタイトルとURLを残して本文の末尾を切り詰める
+func importMemo(header string, body string, url string) (string, bool) {
+	join := func(body string) string {
+		parts := make([]string, 0, 3)
+		for _, v := range []string{header, body, url} {
+			if v = strings.TrimSpace(v); v != "" {
+				parts = append(parts, v)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	}
+
+	memo := join(body)
+	over := len([]rune(memo)) - maxTaskMemoLength
+	if over <= 0 {
+		return memo, false
+	}
+	runes := []rune(strings.TrimSpace(body))
+	if over < len(runes) {
+		if memo = join(string(runes[:len(runes)-over])); len([]rune(memo)) <= maxTaskMemoLength {
+			return memo, true
+		}
+	}
+	// 本文を削っても収まらない場合は全体を切り詰める
+	memo = join("")
+	if runes := []rune(memo); len(runes) > maxTaskMemoLength {
+		memo = string(runes[:maxTaskMemoLength])
+	}
+	return memo, true
+}
+
+// 対応表にないリスト名はよくある名前からステータスを推測する
+func guessTaskStatus(name string) model.TaskStatus {
+	lower := strings.ToLower(name)
+	for _, keyword := range []string{"done", "complete", "closed", "完了"} {
+		if strings.Contains(lower, keyword) {
+			return model.TaskStatusCompleted
+		}
+	}
+	for _, keyword := range []string{"doing", "progress", "review", "進行", "着手", "対応中"} {
+		if strings.Contains(lower, keyword) {
+			return model.TaskStatusStarted
+		}
+	}
+	return model.TaskStatusUnstarted
+}
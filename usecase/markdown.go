@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// メモはCommonMark(+GFMのテーブル・取り消し線・タスクリスト・自動リンク)として扱う
+// 生のHTMLは出力せず、さらにサニタイズしてから返す
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+var markdownPolicy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// タスクリストのチェックボックスだけは残す
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}()
+
+func renderMarkdown(source string) string {
+	if source == "" {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		// 変換できない場合はエスケープしたテキストとして返す
+		return markdownPolicy.Sanitize("<p>" + strings.ReplaceAll(source, "<", "&lt;") + "</p>")
+	}
+	return markdownPolicy.Sanitize(buf.String())
+}
+
+// index番目(0始まり)のタスクリストのチェックボックスを書き換えたメモを返す
+// 表示したHTMLと同じ順番になるよう、パースした構文木のチェックボックスを数える
+func setTaskListItem(source string, index int, checked bool) (string, error) {
+	src := []byte(source)
+	doc := markdown.Parser().Parse(text.NewReader(src))
+
+	offset := -1
+	n := 0
+	err := ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || node.Kind() != east.KindTaskCheckBox {
+			return ast.WalkContinue, nil
+		}
+		if n < index {
+			n++
+			return ast.WalkContinue, nil
+		}
+		offset = taskCheckBoxOffset(node)
+		return ast.WalkStop, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	// "[" + 印 + "]" の位置を確認してから印だけを書き換える
+	if offset < 0 || offset+2 >= len(src) || src[offset] != '[' || src[offset+2] != ']' {
+		return "", fmt.Errorf("checkbox %d does not exist in the memo", index)
+	}
+
+	mark := byte(' ')
+	if checked {
+		mark = 'x'
+	}
+	src[offset+1] = mark
+	return string(src), nil
+}
+
+// チェックボックスのノードは位置を持たないため、直前のテキストか親の行の先頭から求める
+func taskCheckBoxOffset(node ast.Node) int {
+	if prev, ok := node.PreviousSibling().(*ast.Text); ok {
+		return prev.Segment.Stop
+	}
+	if parent := node.Parent(); parent != nil && parent.Lines().Len() > 0 {
+		return parent.Lines().At(0).Start
+	}
+	return -1
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"regexp"
+	"strings"
+)
+
+// 1回の候補検索で返す最大件数
+const mentionCandidateLimit = 10
+
+var (
+	// 直前が英数字や@でない "@" から始まるトークン。メールアドレスを先に試す
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}|[^\s\p{Zs}@,.;:!?()\[\]<>"'` + "`" + `、。，．！？「」『』（）【】]+)`)
+	fencedCode     = regexp.MustCompile("(?s)(```|~~~).*?(```|~~~)")
+	inlineCode     = regexp.MustCompile("`[^`\n]*`")
+)
+
+type IMentionUseCase interface {
+	// メモ(commentId=0)またはコメントのメンションを本文に合わせて更新し、新しく追加されたものを返す
+	SyncMentions(taskId uint, commentId uint, authorId uint, text string) ([]model.Mention, error)
+	// メンションの入力候補を取得する
+	GetMentionCandidates(userId uint, taskId uint, prefix string) ([]model.MentionCandidate, error)
+	// 自分へのメンション一覧を取得する
+	GetMyMentions(userId uint) ([]model.MentionResponse, error)
+}
+
+type mentionUseCase struct {
+	mr repository.IMentionRepository
+	tr repository.ITaskRepository
+}
+
+func NewMentionUseCase(mr repository.IMentionRepository, tr repository.ITaskRepository) IMentionUseCase {
+	return &mentionUseCase{mr, tr}
+}
+
+func (mu *mentionUseCase) SyncMentions(taskId uint, commentId uint, authorId uint, text string) ([]model.Mention, error) {
+	tokens := parseMentionTokens(text)
+	users := make([]model.User, 0)
+	if len(tokens) > 0 {
+		if err := mu.mr.GetMentionableUsers(&users, taskId); err != nil {
+			return nil, err
+		}
+	}
+	resolved := resolveMentions(tokens, users)
+
+	existing := make([]model.Mention, 0)
+	if err := mu.mr.GetMentions(&existing, taskId, commentId); err != nil {
+		return nil, err
+	}
+	existingUsers := map[uint]bool{}
+	removed := make([]uint, 0)
+	for _, v := range existing {
+		existingUsers[v.UserId] = true
+		if _, ok := resolved[v.UserId]; !ok {
+			removed = append(removed, v.ID)
+		}
+	}
+	added := make([]model.Mention, 0)
+	for userId, token := range resolved {
+		if !existingUsers[userId] {
+			added = append(added, model.Mention{TaskId: taskId, CommentId: commentId, UserId: userId, MentionedBy: authorId, Text: token})
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := mu.mr.DeleteMentions(removed); err != nil {
+			return nil, err
+		}
+	}
+	if len(added) > 0 {
+		if err := mu.mr.CreateMentions(&added); err != nil {
+			return nil, err
+		}
+	}
+
+	return added, nil
+}
+
+func (mu *mentionUseCase) GetMentionCandidates(userId uint, taskId uint, prefix string) ([]model.MentionCandidate, error) {
+	if err := mu.tr.GetMemberTaskById(&model.Task{}, userId, taskId); err != nil {
+		return nil, err
+	}
+	candidates := make([]model.MentionCandidate, 0)
+	if err := mu.mr.GetMentionCandidates(&candidates, taskId, strings.TrimPrefix(prefix, "@"), mentionCandidateLimit); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+func (mu *mentionUseCase) GetMyMentions(userId uint) ([]model.MentionResponse, error) {
+	mentions := make([]model.Mention, 0)
+	if err := mu.mr.GetMentionsByUserId(&mentions, userId); err != nil {
+		return nil, err
+	}
+
+	resMentions := make([]model.MentionResponse, len(mentions))
+	for i, v := range mentions {
+		resMentions[i] = toMentionResponse(v)
+	}
+
+	return resMentions, nil
+}
+
+func toMentionResponse(mention model.Mention) model.MentionResponse {
+	return model.MentionResponse{
+		ID: mention.ID,
+		TaskId: mention.TaskId,
+		CommentId: mention.CommentId,
+		UserId: mention.UserId,
+		Name: mention.User.Name,
+		Text: mention.Text,
+		MentionedBy: mention.MentionedBy,
+		CreatedAt: mention.CreatedAt,
+	}
+}
+
+// コードブロック・インラインコード内の "@" はメンションとして扱わない
+func parseMentionTokens(text string) []string {
+	text = fencedCode.ReplaceAllString(text, "")
+	text = inlineCode.ReplaceAllString(text, "")
+	tokens := make([]string, 0)
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		tokens = append(tokens, m[1])
+	}
+	return tokens
+}
+
+// トークンをユーザーに対応付ける。メールアドレスは完全一致、名前は空白を除いて大文字小文字を区別せずに比較し、
+// 同じ名前のユーザーが複数いる場合は対応付けない
+func resolveMentions(tokens []string, users []model.User) map[uint]string {
+	byEmail := map[string]uint{}
+	byName := map[string][]uint{}
+	for _, v := range users {
+		byEmail[strings.ToLower(v.Email)] = v.ID
+		name := strings.ToLower(strings.Join(strings.Fields(v.Name), ""))
+		if name != "" {
+			byName[name] = append(byName[name], v.ID)
+		}
+	}
+
+	resolved := map[uint]string{}
+	for _, token := range tokens {
+		key := strings.ToLower(token)
+		if id, ok := byEmail[key]; ok {
+			resolved[id] = "@" + token
+			continue
+		}
+		if ids := byName[key]; len(ids) == 1 {
+			resolved[ids[0]] = "@" + token
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,535 @@
+package usecase
+
+import (
+	"fmt"
+	"go-rest-api/mailer"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultNotificationsPerPage = 20
+	maxNotificationsPerPage     = 100
+	// 期限が今日か翌日の未完了タスクを担当者に通知し、この間は同じタスクを再通知しない
+	deadlineSoonWindow = 24 * time.Hour
+	// まとめて受け取る設定のユーザーに送る間隔
+	digestInterval = 24 * time.Hour
+)
+
+type INotificationUseCase interface {
+	// 受け取り設定を確認して通知を作成する。通知に失敗しても呼び出し元の操作は失敗させない
+	Notify(recipients []uint, notification model.Notification)
+	// タスクをフォローしているユーザーのうち操作した本人以外へ通知する
+	NotifyTaskWatchers(taskId uint, notification model.Notification)
+	// 受信箱の通知を新しい順に取得する
+	GetNotifications(userId uint, page int, perPage int, unreadOnly bool) (model.NotificationListResponse, error)
+	// 未読件数を取得する
+	GetUnreadCount(userId uint) (int64, error)
+	// 通知を既読にする
+	MarkRead(userId uint, notificationId uint) (model.NotificationResponse, error)
+	// すべての通知を既読にする
+	MarkAllRead(userId uint) error
+	// 種類ごとの受け取り設定を取得する
+	GetPreferences(userId uint) ([]model.NotificationPreferenceResponse, error)
+	// 種類ごとの受け取り設定を更新する
+	UpdatePreferences(userId uint, preferences []model.NotificationPreferenceUpdate) ([]model.NotificationPreferenceResponse, error)
+	// メールの言語とまとめて受け取るかどうかを取得する
+	GetEmailSetting(userId uint) (model.EmailSettingResponse, error)
+	// メールの言語とまとめて受け取るかどうかを更新する
+	UpdateEmailSetting(userId uint, setting model.EmailSettingResponse) (model.EmailSettingResponse, error)
+	// 期限が迫ったタスクを担当者に通知する(同じタスクは期間内に1回だけ)
+	NotifyDeadlines(now time.Time) error
+	// まとめて受け取る設定のユーザーに、前回以降の未読の通知をメールで送る
+	SendDigests(now time.Time) error
+}
+
+type notificationUseCase struct {
+	nr repository.INotificationRepository
+	tr repository.ITaskRepository
+	wr repository.IWatcherRepository
+	ur repository.IUserRepository
+	eu IEmailUseCase
+	nv validator.INotificationValidator
+}
+
+func NewNotificationUseCase(nr repository.INotificationRepository, tr repository.ITaskRepository, wr repository.IWatcherRepository, ur repository.IUserRepository, eu IEmailUseCase, nv validator.INotificationValidator) INotificationUseCase {
+	return &notificationUseCase{nr, tr, wr, ur, eu, nv}
+}
+
+type notificationEmail struct {
+	Name    string
+	Type    model.NotificationType
+	Message string
+	URL     string
+}
+
+type digestEmail struct {
+	Name          string
+	Notifications []digestItem
+	InboxURL      string
+}
+
+type digestItem struct {
+	Message   string
+	URL       string
+	CreatedAt time.Time
+}
+
+func (nu *notificationUseCase) Notify(recipients []uint, notification model.Notification) {
+	if err := nu.notify(recipients, notification); err != nil {
+		log.Printf("failed to create %s notification: %v", notification.Type, err)
+	}
+}
+
+func (nu *notificationUseCase) notify(recipients []uint, notification model.Notification) error {
+	// 自分の操作は自分に通知しない
+	seen := map[uint]bool{notification.ActorId: true}
+	userIds := make([]uint, 0, len(recipients))
+	for _, v := range recipients {
+		if v != 0 && !seen[v] {
+			seen[v] = true
+			userIds = append(userIds, v)
+		}
+	}
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	preferences := make([]model.NotificationPreference, 0)
+	if err := nu.nr.GetNotificationPreferencesByUserIds(&preferences, userIds, notification.Type); err != nil {
+		return err
+	}
+	saved := map[uint]model.NotificationPreference{}
+	for _, v := range preferences {
+		saved[v.UserId] = v
+	}
+
+	// 設定がなければアプリ内・メールの両方で知らせる
+	notifications := make([]model.Notification, 0, len(userIds))
+	emailed := make([]model.Notification, 0)
+	for _, v := range userIds {
+		inApp, email := true, true
+		if p, ok := saved[v]; ok {
+			inApp, email = p.InApp, p.Email
+		}
+		if !inApp && !email {
+			continue
+		}
+		n := notification
+		n.ID = 0
+		n.UserId = v
+		n.Email = email
+		n.EmailOnly = !inApp
+		notifications = append(notifications, n)
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := nu.nr.CreateNotifications(&notifications); err != nil {
+		return err
+	}
+
+	for _, v := range notifications {
+		if v.Email {
+			emailed = append(emailed, v)
+		}
+	}
+	return nu.sendNotificationEmails(emailed)
+}
+
+// まとめて受け取る設定のユーザー以外にすぐメールを送る
+func (nu *notificationUseCase) sendNotificationEmails(notifications []model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	userIds := make([]uint, len(notifications))
+	for i, v := range notifications {
+		userIds[i] = v.UserId
+	}
+	users := make([]model.User, 0)
+	if err := nu.ur.GetUsersByIds(&users, userIds); err != nil {
+		return err
+	}
+	settings, err := nu.emailSettings(userIds)
+	if err != nil {
+		return err
+	}
+	usersById := map[uint]model.User{}
+	for _, v := range users {
+		usersById[v.ID] = v
+	}
+
+	for _, v := range notifications {
+		user, ok := usersById[v.UserId]
+		if !ok || settings[v.UserId].Digest {
+			continue
+		}
+		data := notificationEmail{Name: user.Name, Type: v.Type, Message: v.Message, URL: taskURL(v.TaskId)}
+		if err := nu.eu.Enqueue(user.Email, settings[v.UserId].Language, "notification", data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 保存されていないユーザーは既定の設定にする
+func (nu *notificationUseCase) emailSettings(userIds []uint) (map[uint]model.EmailSettingResponse, error) {
+	settings := make([]model.EmailSetting, 0)
+	if err := nu.nr.GetEmailSettingsByUserIds(&settings, userIds); err != nil {
+		return nil, err
+	}
+	resSettings := map[uint]model.EmailSettingResponse{}
+	for _, v := range userIds {
+		resSettings[v] = model.EmailSettingResponse{Language: mailer.DefaultLanguage}
+	}
+	for _, v := range settings {
+		resSettings[v.UserId] = model.EmailSettingResponse{Language: v.Language, Digest: v.Digest}
+	}
+	return resSettings, nil
+}
+
+func (nu *notificationUseCase) NotifyTaskWatchers(taskId uint, notification model.Notification) {
+	userIds := make([]uint, 0)
+	if err := nu.wr.GetWatcherIds(&userIds, taskId); err != nil {
+		log.Printf("failed to get watchers of task %d: %v", taskId, err)
+		return
+	}
+	notification.TaskId = taskId
+	nu.Notify(userIds, notification)
+}
+
+func (nu *notificationUseCase) GetNotifications(userId uint, page int, perPage int, unreadOnly bool) (model.NotificationListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = defaultNotificationsPerPage
+	}
+	if perPage > maxNotificationsPerPage {
+		perPage = maxNotificationsPerPage
+	}
+
+	notifications := make([]model.Notification, 0)
+	if err := nu.nr.GetNotificationsByUserId(&notifications, userId, unreadOnly, (page-1)*perPage, perPage); err != nil {
+		return model.NotificationListResponse{}, err
+	}
+	var total int64
+	if err := nu.nr.CountNotifications(&total, userId, unreadOnly); err != nil {
+		return model.NotificationListResponse{}, err
+	}
+	unreadCount, err := nu.GetUnreadCount(userId)
+	if err != nil {
+		return model.NotificationListResponse{}, err
+	}
+
+	resNotifications := make([]model.NotificationResponse, len(notifications))
+	for i, v := range notifications {
+		resNotifications[i] = toNotificationResponse(v)
+	}
+
+	return model.NotificationListResponse{
+		Notifications: resNotifications,
+		UnreadCount: unreadCount,
+		Total: total,
+		Page: page,
+		PerPage: perPage,
+	}, nil
+}
+
+func (nu *notificationUseCase) GetUnreadCount(userId uint) (int64, error) {
+	var count int64
+	if err := nu.nr.CountNotifications(&count, userId, true); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (nu *notificationUseCase) MarkRead(userId uint, notificationId uint) (model.NotificationResponse, error) {
+	notification := model.Notification{}
+	if err := nu.nr.MarkNotificationRead(&notification, userId, notificationId); err != nil {
+		return model.NotificationResponse{}, err
+	}
+
+	return toNotificationResponse(notification), nil
+}
+
+func (nu *notificationUseCase) MarkAllRead(userId uint) error {
+	if err := nu.nr.MarkAllNotificationsRead(userId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nu *notificationUseCase) GetPreferences(userId uint) ([]model.NotificationPreferenceResponse, error) {
+	preferences := make([]model.NotificationPreference, 0)
+	if err := nu.nr.GetNotificationPreferences(&preferences, userId); err != nil {
+		return nil, err
+	}
+	saved := map[model.NotificationType]model.NotificationPreference{}
+	for _, v := range preferences {
+		saved[v.Type] = v
+	}
+
+	// 保存されていない種類は受け取る設定として返す
+	resPreferences := make([]model.NotificationPreferenceResponse, len(model.NotificationTypes))
+	for i, t := range model.NotificationTypes {
+		resPreferences[i] = model.NotificationPreferenceResponse{Type: t, InApp: true, Email: true}
+		if v, ok := saved[t]; ok {
+			resPreferences[i].InApp = v.InApp
+			resPreferences[i].Email = v.Email
+		}
+	}
+
+	return resPreferences, nil
+}
+
+func (nu *notificationUseCase) UpdatePreferences(userId uint, preferences []model.NotificationPreferenceUpdate) ([]model.NotificationPreferenceResponse, error) {
+	for _, v := range preferences {
+		if err := nu.nv.NotificationPreferenceValidate(v); err != nil {
+			return nil, err
+		}
+	}
+	current, err := nu.GetPreferences(userId)
+	if err != nil {
+		return nil, err
+	}
+	currentByType := map[model.NotificationType]model.NotificationPreferenceResponse{}
+	for _, v := range current {
+		currentByType[v.Type] = v
+	}
+
+	for _, v := range preferences {
+		preference := model.NotificationPreference{UserId: userId, Type: v.Type, InApp: currentByType[v.Type].InApp, Email: currentByType[v.Type].Email}
+		if v.InApp != nil {
+			preference.InApp = *v.InApp
+		}
+		if v.Email != nil {
+			preference.Email = *v.Email
+		}
+		if err := nu.nr.UpsertNotificationPreference(&preference); err != nil {
+			return nil, err
+		}
+	}
+
+	return nu.GetPreferences(userId)
+}
+
+func (nu *notificationUseCase) GetEmailSetting(userId uint) (model.EmailSettingResponse, error) {
+	settings, err := nu.emailSettings([]uint{userId})
+	if err != nil {
+		return model.EmailSettingResponse{}, err
+	}
+
+	return settings[userId], nil
+}
+
+func (nu *notificationUseCase) UpdateEmailSetting(userId uint, setting model.EmailSettingResponse) (model.EmailSettingResponse, error) {
+	if err := nu.nv.EmailSettingValidate(setting); err != nil {
+		return model.EmailSettingResponse{}, err
+	}
+	newSetting := model.EmailSetting{UserId: userId, Language: setting.Language, Digest: setting.Digest}
+	if err := nu.nr.UpsertEmailSetting(&newSetting); err != nil {
+		return model.EmailSettingResponse{}, err
+	}
+
+	return nu.GetEmailSetting(userId)
+}
+
+func (nu *notificationUseCase) NotifyDeadlines(now time.Time) error {
+	tasks := make([]model.Task, 0)
+	// 期限は日付のみのため、時刻を切り捨てた日付で比べる
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if err := nu.tr.GetDueTasks(&tasks, today, today.Add(deadlineSoonWindow)); err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		users := make([]model.User, 0)
+		if err := nu.tr.GetAssignees(&users, task.ID); err != nil {
+			return err
+		}
+		recipients := make([]uint, 0, len(users))
+		for _, v := range users {
+			exists := false
+			if err := nu.nr.ExistsNotification(&exists, v.ID, model.NotificationTypeDeadlineSoon, task.ID, now.Add(-deadlineSoonWindow)); err != nil {
+				return err
+			}
+			if !exists {
+				recipients = append(recipients, v.ID)
+			}
+		}
+		if err := nu.notify(recipients, model.Notification{
+			Type: model.NotificationTypeDeadlineSoon,
+			TaskId: task.ID,
+			TeamId: task.TeamId,
+			Message: fmt.Sprintf("The deadline of %q is %s", task.Title, task.DeadLine.Format("2006-01-02")),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (nu *notificationUseCase) SendDigests(now time.Time) error {
+	settings := make([]model.EmailSetting, 0)
+	if err := nu.nr.GetDueDigestSettings(&settings, now.Add(-digestInterval)); err != nil {
+		return err
+	}
+	for _, setting := range settings {
+		since := now.Add(-digestInterval)
+		if setting.LastDigestAt != nil {
+			since = *setting.LastDigestAt
+		}
+		notifications := make([]model.Notification, 0)
+		if err := nu.nr.GetDigestNotifications(&notifications, setting.UserId, since); err != nil {
+			return err
+		}
+		if len(notifications) > 0 {
+			users := make([]model.User, 0)
+			if err := nu.ur.GetUsersByIds(&users, []uint{setting.UserId}); err != nil {
+				return err
+			}
+			if len(users) == 0 {
+				continue
+			}
+			data := digestEmail{Name: users[0].Name, Notifications: make([]digestItem, len(notifications))}
+			if feURL := os.Getenv("FE_URL"); feURL != "" {
+				data.InboxURL = feURL + "/notifications"
+			}
+			for i, v := range notifications {
+				data.Notifications[i] = digestItem{Message: v.Message, URL: taskURL(v.TaskId), CreatedAt: v.CreatedAt}
+			}
+			if err := nu.eu.Enqueue(users[0].Email, setting.Language, "digest", data); err != nil {
+				return err
+			}
+		}
+		if err := nu.nr.UpdateLastDigestAt(setting.UserId, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// メールに載せるタスクのURL。FE_URLが未設定なら載せない
+func taskURL(taskId uint) string {
+	feURL := os.Getenv("FE_URL")
+	if feURL == "" || taskId == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/tasks/%d", feURL, taskId)
+}
+
+func toNotificationResponse(notification model.Notification) model.NotificationResponse {
+	return model.NotificationResponse{
+		ID: notification.ID,
+		Type: notification.Type,
+		TaskId: notification.TaskId,
+		TeamId: notification.TeamId,
+		ActorId: notification.ActorId,
+		Message: notification.Message,
+		ReadAt: notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+}
+
+// 新しく追加されたメンションを、メンションされたユーザーに通知する
+func notifyMentions(nu INotificationUseCase, task model.Task, mentions []model.Mention) {
+	for _, v := range mentions {
+		message := fmt.Sprintf("You were mentioned in the memo of %q", task.Title)
+		if v.CommentId != 0 {
+			message = fmt.Sprintf("You were mentioned in a comment on %q", task.Title)
+		}
+		nu.Notify([]uint{v.UserId}, model.Notification{
+			Type: model.NotificationTypeMentioned,
+			TaskId: task.ID,
+			TeamId: task.TeamId,
+			ActorId: v.MentionedBy,
+			Message: message,
+		})
+	}
+}
+
+// ステータスが変わった場合だけフォローしているユーザーに通知する
+func notifyStatusChange(nu INotificationUseCase, task model.Task, oldStatus model.TaskStatus, actorId uint) {
+	if task.Status == oldStatus {
+		return
+	}
+	nu.NotifyTaskWatchers(task.ID, model.Notification{
+		Type: model.NotificationTypeStatusChanged,
+		TeamId: task.TeamId,
+		ActorId: actorId,
+		Message: fmt.Sprintf("%q was moved from %s to %s", task.Title, oldStatus.String(), task.Status.String()),
+	})
+}
+
+// タイトル・メモ・期限の変更をフォローしているユーザーに通知する
+func notifyTaskEdit(nu INotificationUseCase, task model.Task, oldTask model.Task, actorId uint) {
+	changes := make([]string, 0, 3)
+	if task.Title != oldTask.Title {
+		changes = append(changes, "title")
+	}
+	if task.Memo != oldTask.Memo {
+		changes = append(changes, "memo")
+	}
+	if !task.DeadLine.Equal(oldTask.DeadLine) {
+		changes = append(changes, "deadline")
+	}
+	if len(changes) == 0 {
+		return
+	}
+	nu.NotifyTaskWatchers(task.ID, model.Notification{
+		Type: model.NotificationTypeTaskUpdated,
+		TeamId: task.TeamId,
+		ActorId: actorId,
+		Message: fmt.Sprintf("The %s of %q was updated", strings.Join(changes, ", "), task.Title),
+	})
+}
+
+// 見積もりの変更をフォローしているユーザーに通知する
+func notifyEstimateChange(nu INotificationUseCase, task model.Task, oldEstimate string, actorId uint) {
+	if task.Estimate == oldEstimate {
+		return
+	}
+	nu.NotifyTaskWatchers(task.ID, model.Notification{
+		Type: model.NotificationTypeTaskUpdated,
+		TeamId: task.TeamId,
+		ActorId: actorId,
+		Message: fmt.Sprintf("The estimate of %q was changed from %s to %s", task.Title, estimateLabel(oldEstimate), estimateLabel(task.Estimate)),
+	})
+}
+
+func estimateLabel(estimate string) string {
+	if estimate == "" {
+		return "none"
+	}
+	return estimate
+}
+
+// タスクの削除をフォローしていたユーザーに通知する。削除するとフォローも消えるため、通知先は削除前に取得しておく
+func notifyTaskDeleted(nu INotificationUseCase, task model.Task, watcherIds []uint, actorId uint) {
+	nu.Notify(watcherIds, model.Notification{
+		Type: model.NotificationTypeTaskDeleted,
+		TeamId: task.TeamId,
+		ActorId: actorId,
+		Message: fmt.Sprintf("%q was deleted", task.Title),
+	})
+}
+
+// 新しいコメントをフォローしているユーザーに通知する
+func notifyComment(nu INotificationUseCase, task model.Task, actorId uint) {
+	nu.NotifyTaskWatchers(task.ID, model.Notification{
+		Type: model.NotificationTypeCommented,
+		TeamId: task.TeamId,
+		ActorId: actorId,
+		Message: fmt.Sprintf("New comment on %q", task.Title),
+	})
+}
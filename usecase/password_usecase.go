@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	passwordResetTTL = time.Hour
+	// 同じメールアドレス・同じIPアドレスから再設定を依頼できる回数
+	passwordResetEmailLimit = 3
+	passwordResetIPLimit    = 10
+	passwordResetWindow     = time.Hour
+	// 現在のパスワードを確認できる回数
+	passwordCheckLimit  = 5
+	passwordCheckWindow = 15 * time.Minute
+	// 回数制限の記録を残す期間
+	rateLimitRetention = 24 * time.Hour
+)
+
+var ErrInvalidPassword = errors.New("the current password is incorrect")
+
+type IPasswordUseCase interface {
+	// 再設定用のメールを送る。メールアドレスが登録されているかどうかは返さない
+	ForgotPassword(request model.ForgotPasswordRequest, client model.SessionClient) error
+	// トークンを使ってパスワードを再設定し、すべてのセッションを失効させる
+	ResetPassword(request model.ResetPasswordRequest) error
+	// 現在のパスワードを確認してパスワードを変更し、他のセッションを失効させる
+	ChangePassword(userId uint, currentSessionId uint, request model.ChangePasswordRequest) error
+	// 期限の過ぎたトークンと古い回数制限の記録を削除する
+	PurgeExpired(now time.Time) error
+}
+
+type passwordUseCase struct {
+	ur  repository.IUserRepository
+	pr  repository.IPasswordRepository
+	rlr repository.IRateLimitRepository
+	su  ISessionUseCase
+	nu  INotificationUseCase
+	eu  IEmailUseCase
+	uv  validator.IUserValidator
+}
+
+func NewPasswordUseCase(ur repository.IUserRepository, pr repository.IPasswordRepository, rlr repository.IRateLimitRepository, su ISessionUseCase, nu INotificationUseCase, eu IEmailUseCase, uv validator.IUserValidator) IPasswordUseCase {
+	return &passwordUseCase{ur, pr, rlr, su, nu, eu, uv}
+}
+
+// アカウントに関するメールのテンプレートに渡す値
+type accountEmail struct {
+	Name string
+	// FE_URLが未設定の場合はトークンをそのまま載せる
+	URL              string
+	Token            string
+	ExpiresInMinutes int
+	ExpiresInHours   int
+}
+
+func (pu *passwordUseCase) ForgotPassword(request model.ForgotPasswordRequest, client model.SessionClient) error {
+	if err := pu.uv.EmailValidate(request.Email); err != nil {
+		return err
+	}
+	now := time.Now()
+	email := strings.ToLower(strings.TrimSpace(request.Email))
+	if err := checkRateLimits(pu.rlr, now,
+		model.RateLimit{Key: "password_reset:email:" + email, Limit: passwordResetEmailLimit, Window: passwordResetWindow},
+		model.RateLimit{Key: "password_reset:ip:" + client.IPAddress, Limit: passwordResetIPLimit, Window: passwordResetWindow},
+	); err != nil {
+		return err
+	}
+
+	// 登録されていない場合も同じ結果を返す
+	// 復元で作成したユーザーは、元のメールアドレスで再設定するとアカウントを引き継げる
+	user := model.User{}
+	to := request.Email
+	if err := pu.ur.GetUserByEmail(&user, request.Email); err != nil {
+		if err := pu.ur.GetUserByClaimEmail(&user, request.Email); err != nil {
+			return nil
+		}
+		to = user.ClaimEmail
+	} else {
+		to = user.Email
+	}
+	token, err := generateSecretToken()
+	if err != nil {
+		return err
+	}
+	resetToken := model.PasswordResetToken{
+		UserId: user.ID,
+		TokenHash: hashSecretToken(token),
+		ExpiresAt: now.Add(passwordResetTTL),
+		CreatedAt: now,
+	}
+	if err := pu.pr.CreateResetToken(&resetToken); err != nil {
+		return err
+	}
+	sendAccountEmail(pu.nu, pu.eu, user, to, "password_reset", "/reset-password", token, passwordResetTTL)
+
+	return nil
+}
+
+func (pu *passwordUseCase) ResetPassword(request model.ResetPasswordRequest) error {
+	if err := pu.uv.PasswordValidate(request.Password); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(request.Password), 10)
+	if err != nil {
+		return err
+	}
+	resetToken := model.PasswordResetToken{}
+	if err := pu.pr.ResetPassword(&resetToken, hashSecretToken(request.Token), string(hash), time.Now()); err != nil {
+		return err
+	}
+	// 他人に使われているセッションを残さないよう、失効できなければ失敗として返す
+	if err := pu.su.RevokeAllSessions(resetToken.UserId, 0, model.SessionRevokedPasswordChanged); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (pu *passwordUseCase) ChangePassword(userId uint, currentSessionId uint, request model.ChangePasswordRequest) error {
+	if err := pu.uv.PasswordValidate(request.NewPassword); err != nil {
+		return err
+	}
+	if _, err := checkPassword(pu.ur, pu.rlr, userId, request.CurrentPassword); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(request.NewPassword), 10)
+	if err != nil {
+		return err
+	}
+	if err := pu.ur.UpdatePassword(userId, string(hash)); err != nil {
+		return err
+	}
+	if err := pu.su.RevokeAllSessions(userId, currentSessionId, model.SessionRevokedPasswordChanged); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (pu *passwordUseCase) PurgeExpired(now time.Time) error {
+	if err := pu.pr.DeleteExpiredResetTokens(now); err != nil {
+		return err
+	}
+	if err := pu.rlr.DeleteEventsBefore(now.Add(-rateLimitRetention)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// 本人確認のためにユーザーの現在のパスワードを確かめる。総当たりを防ぐため回数を制限する
+func checkPassword(ur repository.IUserRepository, rlr repository.IRateLimitRepository, userId uint, password string) (model.User, error) {
+	if err := checkRateLimits(rlr, time.Now(),
+		model.RateLimit{Key: fmt.Sprintf("password_check:user:%d", userId), Limit: passwordCheckLimit, Window: passwordCheckWindow},
+	); err != nil {
+		return model.User{}, err
+	}
+	user := model.User{}
+	if err := ur.GetLoggedInUserDetails(&user, userId); err != nil {
+		return model.User{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return model.User{}, ErrInvalidPassword
+	}
+
+	return user, nil
+}
+
+// トークンを載せたメールをユーザーの言語で to に送る。失敗しても依頼自体は成功とする
+func sendAccountEmail(nu INotificationUseCase, eu IEmailUseCase, user model.User, to string, template string, path string, token string, ttl time.Duration) {
+	data := accountEmail{Name: user.Name, Token: token, ExpiresInMinutes: int(ttl.Minutes()), ExpiresInHours: int(ttl.Hours())}
+	if feURL := os.Getenv("FE_URL"); feURL != "" {
+		data.URL = feURL + path + "?token=" + token
+	}
+	setting, err := nu.GetEmailSetting(user.ID)
+	if err != nil {
+		log.Printf("failed to get email setting of user %d: %v", user.ID, err)
+	}
+	if err := eu.Enqueue(to, setting.Language, template, data); err != nil {
+		log.Printf("failed to enqueue %s email: %v", template, err)
+	}
+}
@@ -0,0 +1,393 @@
+package usecase
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+)
+
+type IProjectUseCase interface {
+	// プロジェクトを作成する
+	CreateProject(userId uint, project model.Project) (model.ProjectResponse, error)
+	// チームが持っている、または共有されているプロジェクトの一覧を取得する
+	GetTeamProjects(userId uint, teamId uint) ([]model.ProjectResponse, error)
+	// プロジェクトを取得する
+	GetProject(userId uint, projectId uint) (model.ProjectResponse, error)
+	// プロジェクトを更新する(持ち主のチームのメンバーのみ)
+	UpdateProject(userId uint, projectId uint, project model.Project) (model.ProjectResponse, error)
+	// 共有するチームを置き換える(持ち主のチームのメンバーのみ)
+	UpdateProjectTeams(userId uint, projectId uint, request model.ProjectTeamsRequest) (model.ProjectResponse, error)
+	// プロジェクトを削除する(持ち主のチームのメンバーのみ)
+	DeleteProject(userId uint, projectId uint) error
+	// プロジェクトのタスクを検索する
+	GetProjectTasks(userId uint, projectId uint, query model.TaskQuery) ([]model.TaskResponse, error)
+	// 所属チームのタスクをプロジェクトに追加する
+	AddProjectTasks(userId uint, projectId uint, request model.ProjectTasksRequest) (model.ProjectProgressResponse, error)
+	// タスクをプロジェクトから外す
+	RemoveProjectTask(userId uint, projectId uint, taskId uint) (model.ProjectProgressResponse, error)
+	// プロジェクト全体とチームごとの進捗を取得する
+	GetProjectProgress(userId uint, projectId uint) (model.ProjectProgressResponse, error)
+}
+
+type projectUseCase struct {
+	pr  repository.IProjectRepository
+	tr  repository.ITaskRepository
+	ter repository.ITeamRepository
+	tmr repository.ITeamMemberRepository
+	tv  validator.ITaskValidator
+	pv  validator.IProjectValidator
+}
+
+func NewProjectUseCase(pr repository.IProjectRepository, tr repository.ITaskRepository, ter repository.ITeamRepository, tmr repository.ITeamMemberRepository, tv validator.ITaskValidator, pv validator.IProjectValidator) IProjectUseCase {
+	return &projectUseCase{pr, tr, ter, tmr, tv, pv}
+}
+
+func (pu *projectUseCase) CreateProject(userId uint, project model.Project) (model.ProjectResponse, error) {
+	if project.Status == "" {
+		project.Status = model.ProjectStatusPlanned
+	}
+	if err := pu.pv.ProjectValidate(project); err != nil {
+		return model.ProjectResponse{}, err
+	}
+	if err := pu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, project.TeamId); err != nil {
+		return model.ProjectResponse{}, err
+	}
+	team := model.Team{}
+	if err := pu.ter.GetTeamById(&team, project.TeamId); err != nil {
+		return model.ProjectResponse{}, err
+	}
+	sharedTeamIds, err := pu.sharedTeams(team, project.SharedTeamIds)
+	if err != nil {
+		return model.ProjectResponse{}, err
+	}
+
+	newProject := model.Project{
+		OrganizationId: team.OrganizationId,
+		TeamId: team.ID,
+		Name: project.Name,
+		Description: project.Description,
+		Status: project.Status,
+		StartDate: project.StartDate,
+		EndDate: project.EndDate,
+		CreatedBy: userId,
+	}
+	if err := pu.pr.CreateProject(&newProject, sharedTeamIds); err != nil {
+		return model.ProjectResponse{}, err
+	}
+
+	return pu.projectResponse(newProject)
+}
+
+func (pu *projectUseCase) GetTeamProjects(userId uint, teamId uint) ([]model.ProjectResponse, error) {
+	if err := pu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return nil, err
+	}
+	projects := make([]model.Project, 0)
+	if err := pu.pr.GetProjectsByTeamId(&projects, teamId); err != nil {
+		return nil, err
+	}
+	projectIds := make([]uint, len(projects))
+	for i, v := range projects {
+		projectIds[i] = v.ID
+	}
+	projectTeams := make([]model.ProjectTeam, 0)
+	if err := pu.pr.GetProjectTeams(&projectTeams, projectIds); err != nil {
+		return nil, err
+	}
+	sharedTeamIds := map[uint][]uint{}
+	for _, v := range projectTeams {
+		sharedTeamIds[v.ProjectId] = append(sharedTeamIds[v.ProjectId], v.TeamId)
+	}
+	counts := make([]model.ProjectTaskCount, 0)
+	if err := pu.pr.CountProjectTasks(&counts, projectIds); err != nil {
+		return nil, err
+	}
+	projectCounts := map[uint][]model.ProjectTaskCount{}
+	for _, v := range counts {
+		projectCounts[v.ProjectId] = append(projectCounts[v.ProjectId], v)
+	}
+
+	resProjects := make([]model.ProjectResponse, len(projects))
+	for i, v := range projects {
+		resProjects[i] = toProjectResponse(v, sharedTeamIds[v.ID], rollupProgress(projectCounts[v.ID]))
+	}
+
+	return resProjects, nil
+}
+
+func (pu *projectUseCase) GetProject(userId uint, projectId uint) (model.ProjectResponse, error) {
+	project, _, err := pu.memberProject(userId, projectId)
+	if err != nil {
+		return model.ProjectResponse{}, err
+	}
+
+	return pu.projectResponse(project)
+}
+
+func (pu *projectUseCase) UpdateProject(userId uint, projectId uint, project model.Project) (model.ProjectResponse, error) {
+	if project.Status == "" {
+		project.Status = model.ProjectStatusPlanned
+	}
+	if err := pu.pv.ProjectValidate(project); err != nil {
+		return model.ProjectResponse{}, err
+	}
+	storedProject, err := pu.ownedProject(userId, projectId)
+	if err != nil {
+		return model.ProjectResponse{}, err
+	}
+
+	updatedProject := model.Project{
+		Name: project.Name,
+		Description: project.Description,
+		Status: project.Status,
+		StartDate: project.StartDate,
+		EndDate: project.EndDate,
+	}
+	if err := pu.pr.UpdateProject(&updatedProject, storedProject.ID); err != nil {
+		return model.ProjectResponse{}, err
+	}
+
+	return pu.projectResponse(updatedProject)
+}
+
+func (pu *projectUseCase) UpdateProjectTeams(userId uint, projectId uint, request model.ProjectTeamsRequest) (model.ProjectResponse, error) {
+	project, err := pu.ownedProject(userId, projectId)
+	if err != nil {
+		return model.ProjectResponse{}, err
+	}
+	team := model.Team{}
+	if err := pu.ter.GetTeamById(&team, project.TeamId); err != nil {
+		return model.ProjectResponse{}, err
+	}
+	sharedTeamIds, err := pu.sharedTeams(team, request.TeamIds)
+	if err != nil {
+		return model.ProjectResponse{}, err
+	}
+
+	if err := pu.pr.SetSharedTeams(project.ID, project.TeamId, sharedTeamIds); err != nil {
+		return model.ProjectResponse{}, err
+	}
+
+	return pu.projectResponse(project)
+}
+
+func (pu *projectUseCase) DeleteProject(userId uint, projectId uint) error {
+	project, err := pu.ownedProject(userId, projectId)
+	if err != nil {
+		return err
+	}
+
+	return pu.pr.DeleteProject(project.ID)
+}
+
+func (pu *projectUseCase) GetProjectTasks(userId uint, projectId uint, query model.TaskQuery) ([]model.TaskResponse, error) {
+	if err := pu.tv.TaskQueryValidate(query); err != nil {
+		return nil, err
+	}
+	project, _, err := pu.memberProject(userId, projectId)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]model.Task, 0)
+	if err := pu.tr.SearchProjectTasks(&tasks, userId, project.ID, query); err != nil {
+		return nil, err
+	}
+	resTasks := make([]model.TaskResponse, len(tasks))
+	for i, v := range tasks {
+		resTasks[i] = toBoardCard(v)
+	}
+
+	return resTasks, nil
+}
+
+func (pu *projectUseCase) AddProjectTasks(userId uint, projectId uint, request model.ProjectTasksRequest) (model.ProjectProgressResponse, error) {
+	if err := pu.pv.ProjectTasksValidate(request); err != nil {
+		return model.ProjectProgressResponse{}, err
+	}
+	project, teamIds, err := pu.memberProject(userId, projectId)
+	if err != nil {
+		return model.ProjectProgressResponse{}, err
+	}
+	taskIds := make([]uint, 0, len(request.TaskIds))
+	seen := map[uint]bool{}
+	for _, v := range request.TaskIds {
+		if !seen[v] {
+			seen[v] = true
+			taskIds = append(taskIds, v)
+		}
+	}
+
+	// 共有しているチームのうち、自分が参加しているチームのタスクだけ追加できる
+	if err := pu.pr.AddProjectTasks(project.ID, teamIds, taskIds); err != nil {
+		return model.ProjectProgressResponse{}, err
+	}
+
+	return pu.progress(project.ID)
+}
+
+func (pu *projectUseCase) RemoveProjectTask(userId uint, projectId uint, taskId uint) (model.ProjectProgressResponse, error) {
+	project, _, err := pu.memberProject(userId, projectId)
+	if err != nil {
+		return model.ProjectProgressResponse{}, err
+	}
+	if err := pu.tr.GetMemberTaskById(&model.Task{}, userId, taskId); err != nil {
+		return model.ProjectProgressResponse{}, err
+	}
+	if err := pu.pr.RemoveProjectTask(project.ID, taskId); err != nil {
+		return model.ProjectProgressResponse{}, err
+	}
+
+	return pu.progress(project.ID)
+}
+
+func (pu *projectUseCase) GetProjectProgress(userId uint, projectId uint) (model.ProjectProgressResponse, error) {
+	project, _, err := pu.memberProject(userId, projectId)
+	if err != nil {
+		return model.ProjectProgressResponse{}, err
+	}
+
+	return pu.progress(project.ID)
+}
+
+// 持ち主または共有しているチームのメンバーが見られるプロジェクトと、そのうち自分が参加しているチームのID
+func (pu *projectUseCase) memberProject(userId uint, projectId uint) (model.Project, []uint, error) {
+	project := model.Project{}
+	if err := pu.pr.GetProjectById(&project, projectId); err != nil {
+		return model.Project{}, nil, err
+	}
+	sharedTeamIds := make([]uint, 0)
+	if err := pu.pr.GetSharedTeamIds(&sharedTeamIds, project.ID); err != nil {
+		return model.Project{}, nil, err
+	}
+	memberTeamIds := make([]uint, 0)
+	if err := pu.tmr.GetActiveTeamIds(&memberTeamIds, userId); err != nil {
+		return model.Project{}, nil, err
+	}
+	projectTeams := map[uint]bool{project.TeamId: true}
+	for _, v := range sharedTeamIds {
+		projectTeams[v] = true
+	}
+	teamIds := make([]uint, 0)
+	for _, v := range memberTeamIds {
+		if projectTeams[v] {
+			teamIds = append(teamIds, v)
+		}
+	}
+	if len(teamIds) == 0 {
+		return model.Project{}, nil, fmt.Errorf("you are not a member of the teams of the project")
+	}
+
+	return project, teamIds, nil
+}
+
+// 持ち主のチームのメンバーが管理できるプロジェクト
+func (pu *projectUseCase) ownedProject(userId uint, projectId uint) (model.Project, error) {
+	project := model.Project{}
+	if err := pu.pr.GetProjectById(&project, projectId); err != nil {
+		return model.Project{}, err
+	}
+	if err := pu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, project.TeamId); err != nil {
+		return model.Project{}, fmt.Errorf("only members of the owning team can manage the project")
+	}
+
+	return project, nil
+}
+
+// 共有先は持ち主と同じ組織の他のチームに限る
+func (pu *projectUseCase) sharedTeams(owner model.Team, teamIds []uint) ([]uint, error) {
+	sharedTeamIds := make([]uint, 0, len(teamIds))
+	seen := map[uint]bool{owner.ID: true}
+	for _, v := range teamIds {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		team := model.Team{}
+		if err := pu.ter.GetTeamById(&team, v); err != nil {
+			return nil, err
+		}
+		if team.OrganizationId != owner.OrganizationId {
+			return nil, fmt.Errorf("projects can only be shared with teams in the same organization")
+		}
+		sharedTeamIds = append(sharedTeamIds, v)
+	}
+
+	return sharedTeamIds, nil
+}
+
+func (pu *projectUseCase) projectResponse(project model.Project) (model.ProjectResponse, error) {
+	sharedTeamIds := make([]uint, 0)
+	if err := pu.pr.GetSharedTeamIds(&sharedTeamIds, project.ID); err != nil {
+		return model.ProjectResponse{}, err
+	}
+	counts := make([]model.ProjectTaskCount, 0)
+	if err := pu.pr.CountProjectTasks(&counts, []uint{project.ID}); err != nil {
+		return model.ProjectResponse{}, err
+	}
+
+	return toProjectResponse(project, sharedTeamIds, rollupProgress(counts)), nil
+}
+
+func (pu *projectUseCase) progress(projectId uint) (model.ProjectProgressResponse, error) {
+	counts := make([]model.ProjectTaskCount, 0)
+	if err := pu.pr.CountProjectTasks(&counts, []uint{projectId}); err != nil {
+		return model.ProjectProgressResponse{}, err
+	}
+	teamIds := make([]uint, 0)
+	teamCounts := map[uint][]model.ProjectTaskCount{}
+	for _, v := range counts {
+		if _, ok := teamCounts[v.TeamId]; !ok {
+			teamIds = append(teamIds, v.TeamId)
+		}
+		teamCounts[v.TeamId] = append(teamCounts[v.TeamId], v)
+	}
+
+	resProgress := model.ProjectProgressResponse{ProjectId: projectId, Progress: rollupProgress(counts), Teams: make([]model.ProjectTeamProgress, len(teamIds))}
+	for i, v := range teamIds {
+		resProgress.Teams[i] = model.ProjectTeamProgress{TeamId: v, Progress: rollupProgress(teamCounts[v])}
+	}
+
+	return resProgress, nil
+}
+
+// ステータスごとのタスク数を合計して進捗にする
+func rollupProgress(counts []model.ProjectTaskCount) model.ProjectProgress {
+	progress := model.ProjectProgress{}
+	for _, v := range counts {
+		progress.Total += v.Count
+		progress.Overdue += v.Overdue
+		switch v.Status {
+		case model.TaskStatusUnstarted:
+			progress.Unstarted += v.Count
+		case model.TaskStatusStarted:
+			progress.Started += v.Count
+		case model.TaskStatusCompleted:
+			progress.Completed += v.Count
+		}
+	}
+	if progress.Total > 0 {
+		progress.CompletionRate = float64(progress.Completed) / float64(progress.Total)
+	}
+	return progress
+}
+
+func toProjectResponse(project model.Project, sharedTeamIds []uint, progress model.ProjectProgress) model.ProjectResponse {
+	if sharedTeamIds == nil {
+		sharedTeamIds = make([]uint, 0)
+	}
+	return model.ProjectResponse{
+		ID: project.ID,
+		OrganizationId: project.OrganizationId,
+		TeamId: project.TeamId,
+		SharedTeamIds: sharedTeamIds,
+		Name: project.Name,
+		Description: project.Description,
+		Status: project.Status,
+		StartDate: project.StartDate,
+		EndDate: project.EndDate,
+		Progress: progress,
+		CreatedAt: project.CreatedAt,
+		UpdatedAt: project.UpdatedAt,
+	}
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ボード上の並び順を表すランクキー(fractional indexing)
+// キー同士はバイト順(COLLATE "C")で比較し、2つのキーの間には常に新しいキーを作れるため
+// 並び替えのたびに列全体を書き換える必要がない
+const rankDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// 末尾が0でなく、ランクキーに使える文字だけでできていれば true
+func isRankKey(key string) bool {
+	if key == "" || strings.HasSuffix(key, "0") {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(rankDigits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// aとbの間に入るキーを返す。aが空なら先頭、bが空なら末尾を表す
+func rankBetween(a string, b string) (string, error) {
+	if b != "" && a >= b {
+		return "", fmt.Errorf("invalid rank range: %q >= %q", a, b)
+	}
+	if strings.HasSuffix(a, "0") || strings.HasSuffix(b, "0") {
+		return "", fmt.Errorf("invalid rank key: trailing zero")
+	}
+	return rankMidpoint(a, b), nil
+}
+
+func rankMidpoint(a string, b string) string {
+	if b != "" {
+		// 共通の接頭辞はそのまま残し、残りの部分で中間を求める
+		n := 0
+		for n < len(b) && rankDigitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + rankMidpoint(rest, b[n:])
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(rankDigits, a[0])
+	}
+	digitB := len(rankDigits)
+	if b != "" {
+		digitB = strings.IndexByte(rankDigits, b[0])
+	}
+	if digitB-digitA > 1 {
+		return string(rankDigits[(digitA+digitB+1)/2])
+	}
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(rankDigits[digitA]) + rankMidpoint(rest, "")
+}
+
+func rankDigitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return rankDigits[0]
+}
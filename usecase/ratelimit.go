@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"errors"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"time"
+)
+
+var ErrTooManyRequests = errors.New("too many requests, please try again later")
+
+// すべての制限に収まる場合だけ操作を記録する。どれかを超えていれば ErrTooManyRequests を返す
+func checkRateLimits(rlr repository.IRateLimitRepository, now time.Time, limits ...model.RateLimit) error {
+	allowed, err := rlr.CreateEventsWithinLimits(limits, now)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrTooManyRequests
+	}
+
+	return nil
+}
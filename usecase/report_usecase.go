@@ -0,0 +1,535 @@
+package usecase
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+	"sort"
+	"time"
+)
+
+const (
+	defaultVelocityWeeks   = 12
+	defaultVelocitySprints = 6
+	// 移動平均をとる期間の数
+	velocityRollingPeriods = 3
+	// 期間を指定しない場合に集計する日数
+	defaultStatsDays = 30
+	// 集計できる最長の日数
+	maxStatsDays = 366
+)
+
+// 所要日数のヒストグラムの階級の境界(日)
+var durationBucketBounds = []float64{1, 2, 3, 5, 8, 13, 21, 34}
+
+type IReportUseCase interface {
+	// チームの週ごと、またはスプリントごとのベロシティを取得する
+	GetVelocity(userId uint, teamId uint, query model.VelocityQuery) (model.VelocityResponse, error)
+	// チームのタスクの統計を取得する
+	GetTeamStats(userId uint, teamId uint, query model.StatsQuery) (model.TaskStatsResponse, error)
+	// 組織内のすべてのチームのタスクの統計を取得する
+	GetOrganizationStats(userId uint, organizationId uint, query model.StatsQuery) (model.TaskStatsResponse, error)
+	// チームのメンバーごとに担当している未完了タスクの負荷を取得する
+	GetWorkload(userId uint, teamId uint) (model.WorkloadResponse, error)
+	// 期間内に完了したチームのタスクのリードタイムとサイクルタイムを取得する
+	GetCycleTime(userId uint, teamId uint, query model.StatsQuery) (model.CycleTimeResponse, error)
+	// チームの累積フロー図のデータを取得する
+	GetCumulativeFlow(userId uint, teamId uint, query model.StatsQuery) (model.CumulativeFlowResponse, error)
+}
+
+type reportUseCase struct {
+	rr  repository.IReportRepository
+	sr  repository.ISprintRepository
+	hr  repository.ITaskHistoryRepository
+	tmr repository.ITeamMemberRepository
+	or  repository.IOrganizationRepository
+	ur  repository.IUserRepository
+	rv  validator.IReportValidator
+}
+
+func NewReportUseCase(rr repository.IReportRepository, sr repository.ISprintRepository, hr repository.ITaskHistoryRepository, tmr repository.ITeamMemberRepository, or repository.IOrganizationRepository, ur repository.IUserRepository, rv validator.IReportValidator) IReportUseCase {
+	return &reportUseCase{rr, sr, hr, tmr, or, ur, rv}
+}
+
+func (ru *reportUseCase) GetVelocity(userId uint, teamId uint, query model.VelocityQuery) (model.VelocityResponse, error) {
+	if err := ru.rv.VelocityQueryValidate(query); err != nil {
+		return model.VelocityResponse{}, err
+	}
+	if err := ru.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return model.VelocityResponse{}, err
+	}
+
+	var periods []model.VelocityPeriod
+	var err error
+	if query.Period == model.VelocityPeriodSprint {
+		periods, err = ru.sprintVelocity(teamId, query.Count, time.Now())
+	} else {
+		query.Period = model.VelocityPeriodWeek
+		periods, err = ru.weeklyVelocity(teamId, query.Count, time.Now())
+	}
+	if err != nil {
+		return model.VelocityResponse{}, err
+	}
+
+	resVelocity := model.VelocityResponse{TeamId: teamId, Period: query.Period, Periods: periods}
+	completed := make([]float64, len(periods))
+	for i, v := range periods {
+		completed[i] = v.CompletedPoints
+		resVelocity.AveragePoints += v.CompletedPoints
+		resVelocity.AverageTasks += float64(v.CompletedTasks)
+		from := i - velocityRollingPeriods + 1
+		if from < 0 {
+			from = 0
+		}
+		periods[i].RollingAverage = average(completed[from : i+1])
+	}
+	if len(periods) > 0 {
+		resVelocity.AveragePoints /= float64(len(periods))
+		resVelocity.AverageTasks /= float64(len(periods))
+	}
+	resVelocity.Trend = slope(completed)
+
+	return resVelocity, nil
+}
+
+// 今週を除いた直近の週(月曜始まり)ごとに、その週に完了したポイントを集計する
+func (ru *reportUseCase) weeklyVelocity(teamId uint, count int, now time.Time) ([]model.VelocityPeriod, error) {
+	if count == 0 {
+		count = defaultVelocityWeeks
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	thisWeek := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	from := thisWeek.AddDate(0, 0, -7*count)
+	completions := make([]model.WeeklyCompletion, 0)
+	if err := ru.rr.GetWeeklyCompletions(&completions, teamId, from, thisWeek); err != nil {
+		return nil, err
+	}
+	weeks := map[time.Time]model.WeeklyCompletion{}
+	for _, v := range completions {
+		weeks[time.Date(v.PeriodStart.Year(), v.PeriodStart.Month(), v.PeriodStart.Day(), 0, 0, 0, 0, time.UTC)] = v
+	}
+
+	periods := make([]model.VelocityPeriod, count)
+	for i := range periods {
+		start := from.AddDate(0, 0, 7*i)
+		week := weeks[start]
+		periods[i] = model.VelocityPeriod{
+			Label: start.Format("2006-01-02"),
+			From: start,
+			To: start.AddDate(0, 0, 7),
+			CompletedPoints: week.Points,
+			CompletedTasks: week.Tasks,
+		}
+	}
+
+	return periods, nil
+}
+
+// 終了した直近のスプリントごとに、終了時に完了していたポイントを集計する
+func (ru *reportUseCase) sprintVelocity(teamId uint, count int, now time.Time) ([]model.VelocityPeriod, error) {
+	if count == 0 {
+		count = defaultVelocitySprints
+	}
+	sprints := make([]model.Sprint, 0)
+	if err := ru.sr.GetSprintsByTeamId(&sprints, teamId); err != nil {
+		return nil, err
+	}
+	closed := make([]model.Sprint, 0, count)
+	for _, v := range sprints {
+		if v.ClosedAt != nil && len(closed) < count {
+			closed = append(closed, v)
+		}
+	}
+
+	// 新しい順に取得しているので、古い順に並べ直す
+	periods := make([]model.VelocityPeriod, len(closed))
+	for i, v := range closed {
+		timeline, err := loadSprintTimeline(ru.sr, ru.hr, v.ID)
+		if err != nil {
+			return nil, err
+		}
+		committed := timeline.total(timeline.scopeAt(sprintStartAt(v, now)), model.ChartUnitPoints)
+		completed := timeline.completedTasksAt(sprintEndAt(v, now))
+		periods[len(closed)-1-i] = model.VelocityPeriod{
+			Label: v.Name,
+			SprintId: v.ID,
+			From: v.StartDate,
+			To: v.EndDate.AddDate(0, 0, 1),
+			CommittedPoints: &committed,
+			CompletedPoints: timeline.total(completed, model.ChartUnitPoints),
+			CompletedTasks: len(completed),
+		}
+	}
+
+	return periods, nil
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+// 期間の番号を x とした最小二乗法の傾き
+func slope(values []float64) float64 {
+	n := float64(len(values))
+	if n < 2 {
+		return 0
+	}
+	meanX := (n - 1) / 2
+	meanY := average(values)
+	num, den := 0.0, 0.0
+	for i, v := range values {
+		dx := float64(i) - meanX
+		num += dx * (v - meanY)
+		den += dx * dx
+	}
+	return num / den
+}
+
+func (ru *reportUseCase) GetTeamStats(userId uint, teamId uint, query model.StatsQuery) (model.TaskStatsResponse, error) {
+	if err := ru.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return model.TaskStatsResponse{}, err
+	}
+
+	resStats, err := ru.stats(model.StatsScope{TeamId: teamId}, query, time.Now())
+	if err != nil {
+		return model.TaskStatsResponse{}, err
+	}
+	resStats.TeamId = teamId
+
+	return resStats, nil
+}
+
+// 組織の作成者と組織に所属するユーザーが取得できる
+func (ru *reportUseCase) GetOrganizationStats(userId uint, organizationId uint, query model.StatsQuery) (model.TaskStatsResponse, error) {
+	organization := model.Organization{}
+	if err := ru.or.GetOrganizationById(&organization, organizationId); err != nil {
+		return model.TaskStatsResponse{}, err
+	}
+	if organization.Founder != userId {
+		user := model.User{}
+		if err := ru.ur.GetLoggedInUserDetails(&user, userId); err != nil {
+			return model.TaskStatsResponse{}, err
+		}
+		if user.OrganizationId != organizationId {
+			return model.TaskStatsResponse{}, fmt.Errorf("the user does not belong to the organization")
+		}
+	}
+
+	scope := model.StatsScope{OrganizationId: organizationId}
+	resStats, err := ru.stats(scope, query, time.Now())
+	if err != nil {
+		return model.TaskStatsResponse{}, err
+	}
+	resStats.OrganizationId = organizationId
+	teams := []model.TaskCounts{}
+	if err := ru.rr.CountTasksByTeam(&teams, scope); err != nil {
+		return model.TaskStatsResponse{}, err
+	}
+	resStats.Teams = teams
+
+	return resStats, nil
+}
+
+// 期間内の作成・完了数と現在のタスク数を集計する
+func (ru *reportUseCase) stats(scope model.StatsScope, query model.StatsQuery, now time.Time) (model.TaskStatsResponse, error) {
+	from, to, interval, err := ru.statsRange(query, now)
+	if err != nil {
+		return model.TaskStatsResponse{}, err
+	}
+	end := to.AddDate(0, 0, 1)
+
+	resStats := model.TaskStatsResponse{
+		From: from.Format("2006-01-02"),
+		To: to.Format("2006-01-02"),
+		Interval: interval,
+		Series: []model.ThroughputPoint{},
+	}
+	if err := ru.rr.CountTasks(&resStats.Current, scope); err != nil {
+		return model.TaskStatsResponse{}, err
+	}
+	counts := model.RangeCounts{}
+	if err := ru.rr.CountRange(&counts, scope, from, end); err != nil {
+		return model.TaskStatsResponse{}, err
+	}
+	resStats.Created = counts.Created
+	resStats.Completed = counts.Completed
+	if counts.Created > 0 {
+		resStats.CompletionRate = float64(counts.CreatedCompleted) / float64(counts.Created)
+	}
+	if err := ru.rr.GetThroughput(&resStats.Series, scope, from, end, interval); err != nil {
+		return model.TaskStatsResponse{}, err
+	}
+
+	return resStats, nil
+}
+
+func (ru *reportUseCase) GetWorkload(userId uint, teamId uint) (model.WorkloadResponse, error) {
+	if err := ru.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return model.WorkloadResponse{}, err
+	}
+	memberIds := []uint{}
+	if err := ru.tmr.GetActiveTeamMemberIds(&memberIds, teamId); err != nil {
+		return model.WorkloadResponse{}, err
+	}
+	users := []model.User{}
+	if err := ru.ur.GetUsersByIds(&users, memberIds); err != nil {
+		return model.WorkloadResponse{}, err
+	}
+	tasks := []model.AssignedTask{}
+	if err := ru.rr.GetOpenAssignedTasks(&tasks, teamId); err != nil {
+		return model.WorkloadResponse{}, err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	weekEnd := today.AddDate(0, 0, 7-(int(today.Weekday())+6)%7)
+	resWorkload := model.WorkloadResponse{TeamId: teamId, WeekEnd: weekEnd, Members: []model.MemberWorkload{}}
+	members := map[uint]*model.MemberWorkload{}
+	for _, v := range users {
+		members[v.ID] = &model.MemberWorkload{
+			User: model.UserResponse{ID: v.ID, Email: v.Email, Name: v.Name},
+			Tasks: []model.WorkloadTask{},
+		}
+	}
+	// 担当者がいないか、担当者が全員チームを抜けたタスクは未割り当てに数える
+	unassigned := make([]model.AssignedTask, 0)
+	seen := map[uint]bool{}
+	assigned := map[uint]bool{}
+	for _, v := range tasks {
+		bucket := deadlineBucket(v.DeadLine, today, weekEnd)
+		// チームを抜けたユーザーの担当分はメンバーの負荷に含めない
+		member, ok := members[v.UserId]
+		if !ok {
+			if !seen[v.TaskId] {
+				seen[v.TaskId] = true
+				unassigned = append(unassigned, v)
+			}
+			continue
+		}
+		assigned[v.TaskId] = true
+		addWorkload(&member.Summary, v, bucket)
+		member.Tasks = append(member.Tasks, model.WorkloadTask{
+			ID: v.TaskId,
+			Title: v.Title,
+			Status: v.Status,
+			DeadLine: v.DeadLine,
+			DeadlineBucket: bucket,
+			Estimate: v.Estimate,
+			StoryPoints: v.StoryPoints,
+		})
+	}
+
+	for _, v := range unassigned {
+		if !assigned[v.TaskId] {
+			addWorkload(&resWorkload.Unassigned, v, deadlineBucket(v.DeadLine, today, weekEnd))
+		}
+	}
+
+	for _, v := range members {
+		resWorkload.AveragePoints += v.Summary.Points
+		resWorkload.Members = append(resWorkload.Members, *v)
+	}
+	if len(resWorkload.Members) > 0 {
+		resWorkload.AveragePoints /= float64(len(resWorkload.Members))
+	}
+	for i := range resWorkload.Members {
+		if resWorkload.AveragePoints > 0 {
+			resWorkload.Members[i].LoadRatio = resWorkload.Members[i].Summary.Points / resWorkload.AveragePoints
+		}
+	}
+	// 負荷の高いメンバーから並べる
+	sort.SliceStable(resWorkload.Members, func(i, j int) bool {
+		a, b := resWorkload.Members[i], resWorkload.Members[j]
+		if a.Summary.Points != b.Summary.Points {
+			return a.Summary.Points > b.Summary.Points
+		}
+		if a.Summary.OpenTasks != b.Summary.OpenTasks {
+			return a.Summary.OpenTasks > b.Summary.OpenTasks
+		}
+		return a.User.ID < b.User.ID
+	})
+
+	return resWorkload, nil
+}
+
+// 期限が今日より前なら期限切れ、次の月曜日より前なら今週、それ以降は来週以降
+func deadlineBucket(deadLine time.Time, today time.Time, weekEnd time.Time) string {
+	switch {
+	case deadLine.Before(today):
+		return model.DeadlineBucketOverdue
+	case deadLine.Before(weekEnd):
+		return model.DeadlineBucketThisWeek
+	default:
+		return model.DeadlineBucketLater
+	}
+}
+
+func addWorkload(summary *model.WorkloadSummary, task model.AssignedTask, bucket string) {
+	summary.OpenTasks++
+	if task.Status == model.TaskStatusStarted {
+		summary.Started++
+	} else {
+		summary.Unstarted++
+	}
+	switch bucket {
+	case model.DeadlineBucketOverdue:
+		summary.Overdue++
+	case model.DeadlineBucketThisWeek:
+		summary.DueThisWeek++
+	default:
+		summary.DueLater++
+	}
+	if task.StoryPoints != nil {
+		summary.Points += *task.StoryPoints
+	} else {
+		summary.UnestimatedTasks++
+	}
+}
+
+// 集計の期間(to の日を含む)と間隔を決める
+// 期間は UTC の日単位で、週ごとの場合は開始日をその週の月曜日に揃える
+func (ru *reportUseCase) statsRange(query model.StatsQuery, now time.Time) (time.Time, time.Time, string, error) {
+	if err := ru.rv.StatsQueryValidate(query); err != nil {
+		return time.Time{}, time.Time{}, "", err
+	}
+	interval := query.Interval
+	if interval == "" {
+		interval = model.StatsIntervalDay
+	}
+
+	to := now.UTC().Truncate(24 * time.Hour)
+	if query.To != "" {
+		to, _ = time.Parse("2006-01-02", query.To)
+	}
+	from := to.AddDate(0, 0, -(defaultStatsDays - 1))
+	if query.From != "" {
+		from, _ = time.Parse("2006-01-02", query.From)
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, "", fmt.Errorf("from must be before to")
+	}
+	if to.Sub(from) >= maxStatsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, "", fmt.Errorf("the range must be within %d days", maxStatsDays)
+	}
+	if interval == model.StatsIntervalWeek {
+		from = from.AddDate(0, 0, -(int(from.Weekday())+6)%7)
+	}
+
+	return from, to, interval, nil
+}
+
+func (ru *reportUseCase) GetCycleTime(userId uint, teamId uint, query model.StatsQuery) (model.CycleTimeResponse, error) {
+	if err := ru.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return model.CycleTimeResponse{}, err
+	}
+	from, to, _, err := ru.statsRange(query, time.Now())
+	if err != nil {
+		return model.CycleTimeResponse{}, err
+	}
+
+	times := []model.TaskFlowTimes{}
+	if err := ru.rr.GetTaskFlowTimes(&times, teamId, from, to.AddDate(0, 0, 1)); err != nil {
+		return model.CycleTimeResponse{}, err
+	}
+	leadDays := []float64{}
+	cycleDays := []float64{}
+	for _, v := range times {
+		leadDays = append(leadDays, durationDays(v.CreatedAt, v.CompletedAt))
+		if v.StartedAt != nil {
+			cycleDays = append(cycleDays, durationDays(*v.StartedAt, v.CompletedAt))
+		}
+	}
+
+	return model.CycleTimeResponse{
+		TeamId: teamId,
+		From: from.Format("2006-01-02"),
+		To: to.Format("2006-01-02"),
+		LeadTime: durationStats(leadDays),
+		CycleTime: durationStats(cycleDays),
+	}, nil
+}
+
+func (ru *reportUseCase) GetCumulativeFlow(userId uint, teamId uint, query model.StatsQuery) (model.CumulativeFlowResponse, error) {
+	if err := ru.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return model.CumulativeFlowResponse{}, err
+	}
+	from, to, interval, err := ru.statsRange(query, time.Now())
+	if err != nil {
+		return model.CumulativeFlowResponse{}, err
+	}
+
+	resFlow := model.CumulativeFlowResponse{
+		TeamId: teamId,
+		From: from.Format("2006-01-02"),
+		To: to.Format("2006-01-02"),
+		Interval: interval,
+		Points: []model.CumulativeFlowPoint{},
+	}
+	if err := ru.rr.GetCumulativeFlow(&resFlow.Points, teamId, from, to.AddDate(0, 0, 1), interval); err != nil {
+		return model.CumulativeFlowResponse{}, err
+	}
+
+	return resFlow, nil
+}
+
+// 完了が開始より前に記録されている場合は0日とする
+func durationDays(from time.Time, to time.Time) float64 {
+	if to.Before(from) {
+		return 0
+	}
+	return to.Sub(from).Hours() / 24
+}
+
+func durationStats(days []float64) model.DurationStats {
+	stats := model.DurationStats{Count: len(days), Histogram: []model.DurationBucket{}}
+	min := 0.0
+	for i := range durationBucketBounds {
+		max := durationBucketBounds[i]
+		stats.Histogram = append(stats.Histogram, model.DurationBucket{MinDays: min, MaxDays: &max})
+		min = max
+	}
+	stats.Histogram = append(stats.Histogram, model.DurationBucket{MinDays: min})
+	if len(days) == 0 {
+		return stats
+	}
+
+	sorted := append([]float64{}, days...)
+	sort.Float64s(sorted)
+	stats.AverageDays = average(sorted)
+	stats.MinDays = sorted[0]
+	stats.MaxDays = sorted[len(sorted)-1]
+	stats.P50Days = percentile(sorted, 50)
+	stats.P75Days = percentile(sorted, 75)
+	stats.P85Days = percentile(sorted, 85)
+	stats.P95Days = percentile(sorted, 95)
+	for _, v := range sorted {
+		i := sort.SearchFloat64s(durationBucketBounds, v)
+		// 境界の値はその値から始まる階級に入れる
+		if i < len(durationBucketBounds) && durationBucketBounds[i] == v {
+			i++
+		}
+		stats.Histogram[i].Count++
+	}
+
+	return stats
+}
+
+// 昇順に並んだ値の百分位数を線形補間で求める
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (sorted[lower+1]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,304 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"log"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// アクセストークンは短くし、期限が切れたらリフレッシュトークンで取り直す
+	accessTokenTTL = 15 * time.Minute
+	// 最後に使ってからこの期間リフレッシュしなかったセッションは失効する
+	refreshTokenTTL = 14 * 24 * time.Hour
+)
+
+type ISessionUseCase interface {
+	// ログインしたユーザーのセッションを作成し、トークンを発行する
+	CreateSession(userId uint, client model.SessionClient) (model.AuthTokens, error)
+	// リフレッシュトークンを新しいトークンと交換する
+	// 使用済みのトークンが使われた場合は漏洩とみなし、そのセッションを失効させる
+	Refresh(refreshToken string, client model.SessionClient) (model.AuthTokens, error)
+	// アクセストークンを無効にし、リフレッシュトークンのセッションを終了する(ログアウト)
+	EndSession(accessToken string, refreshToken string) error
+	// ユーザーの有効なセッション一覧を取得する
+	GetSessions(userId uint, currentSessionId uint) ([]model.SessionResponse, error)
+	// セッションを失効させる
+	RevokeSession(userId uint, sessionId uint) error
+	// ユーザーのセッションをまとめて失効させる。exceptSessionId のセッションは残す
+	RevokeAllSessions(userId uint, exceptSessionId uint, reason string) error
+	// 組織の作成者が組織のユーザーを強制的にログアウトさせる
+	ForceLogout(adminId uint, organizationId uint, userId uint) error
+	// アクセストークンが無効にされているか確認する
+	IsTokenRevoked(jti string) (bool, error)
+	// 期限が過ぎた拒否リストを削除する
+	PurgeRevokedTokens(now time.Time) error
+}
+
+type sessionUseCase struct {
+	sr repository.ISessionRepository
+	or repository.IOrganizationRepository
+	ur repository.IUserRepository
+}
+
+func NewSessionUseCase(sr repository.ISessionRepository, or repository.IOrganizationRepository, ur repository.IUserRepository) ISessionUseCase {
+	return &sessionUseCase{sr, or, ur}
+}
+
+func (su *sessionUseCase) CreateSession(userId uint, client model.SessionClient) (model.AuthTokens, error) {
+	now := time.Now()
+	token, err := generateSecretToken()
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	jti, err := generateSecretToken()
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	session := model.Session{
+		UserId: userId,
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+		LastUsedAt: now,
+		ExpiresAt: now.Add(refreshTokenTTL),
+		AccessJti: jti,
+		AccessExpiresAt: now.Add(accessTokenTTL),
+	}
+	refreshToken := model.RefreshToken{
+		TokenHash: hashSecretToken(token),
+		ExpiresAt: session.ExpiresAt,
+		CreatedAt: now,
+	}
+	if err := su.sr.CreateSession(&session, &refreshToken); err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	return su.issueTokens(session, token)
+}
+
+func (su *sessionUseCase) Refresh(token string, client model.SessionClient) (model.AuthTokens, error) {
+	now := time.Now()
+	refreshToken := model.RefreshToken{}
+	if err := su.sr.GetRefreshTokenByHash(&refreshToken, hashSecretToken(token)); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("invalid refresh token")
+	}
+	session := refreshToken.Session
+	if session.RevokedAt != nil || !now.Before(session.ExpiresAt) || !now.Before(refreshToken.ExpiresAt) {
+		return model.AuthTokens{}, fmt.Errorf("the session has expired")
+	}
+	if refreshToken.UsedAt != nil {
+		su.revokeReusedSession(session)
+		return model.AuthTokens{}, fmt.Errorf("invalid refresh token")
+	}
+
+	newToken, err := generateSecretToken()
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	jti, err := generateSecretToken()
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	previous := session
+	session.UserAgent = client.UserAgent
+	session.IPAddress = client.IPAddress
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(refreshTokenTTL)
+	session.AccessJti = jti
+	session.AccessExpiresAt = now.Add(accessTokenTTL)
+	newRefreshToken := model.RefreshToken{
+		TokenHash: hashSecretToken(newToken),
+		ExpiresAt: session.ExpiresAt,
+		CreatedAt: now,
+	}
+	if err := su.sr.RotateRefreshToken(&session, refreshToken.ID, &newRefreshToken); err != nil {
+		// 他のリクエストが先に同じトークンを使った場合も再利用として扱う
+		if errors.Is(err, repository.ErrTokenReused) {
+			su.revokeReusedSession(previous)
+			return model.AuthTokens{}, fmt.Errorf("invalid refresh token")
+		}
+		return model.AuthTokens{}, err
+	}
+	// セッションで有効なアクセストークンは常に最後に発行した1つだけにする
+	su.revokeSessionTokens([]model.Session{previous})
+
+	return su.issueTokens(session, newToken)
+}
+
+func (su *sessionUseCase) EndSession(accessToken string, token string) error {
+	if claims, err := parseAccessToken(accessToken); err == nil {
+		if jti, ok := claims["jti"].(string); ok && jti != "" {
+			expiresAt := time.Now().Add(accessTokenTTL)
+			if exp, ok := claims["exp"].(float64); ok {
+				expiresAt = time.Unix(int64(exp), 0)
+			}
+			userId, _ := claims["user_id"].(float64)
+			if err := su.sr.RevokeTokens([]model.RevokedToken{{Jti: jti, UserId: uint(userId), ExpiresAt: expiresAt}}); err != nil {
+				return err
+			}
+		}
+	}
+	if token == "" {
+		return nil
+	}
+
+	refreshToken := model.RefreshToken{}
+	if err := su.sr.GetRefreshTokenByHash(&refreshToken, hashSecretToken(token)); err != nil {
+		return err
+	}
+	if refreshToken.Session.RevokedAt != nil {
+		return nil
+	}
+	session := model.Session{}
+	if err := su.sr.RevokeSession(&session, refreshToken.Session.UserId, refreshToken.SessionId, model.SessionRevokedLogout); err != nil {
+		return err
+	}
+	su.revokeSessionTokens([]model.Session{session})
+
+	return nil
+}
+
+func (su *sessionUseCase) GetSessions(userId uint, currentSessionId uint) ([]model.SessionResponse, error) {
+	sessions := []model.Session{}
+	if err := su.sr.GetActiveSessions(&sessions, userId, time.Now()); err != nil {
+		return nil, err
+	}
+
+	resSessions := make([]model.SessionResponse, len(sessions))
+	for i, v := range sessions {
+		resSessions[i] = model.SessionResponse{
+			ID: v.ID,
+			UserAgent: v.UserAgent,
+			IPAddress: v.IPAddress,
+			CreatedAt: v.CreatedAt,
+			LastUsedAt: v.LastUsedAt,
+			ExpiresAt: v.ExpiresAt,
+			Current: v.ID == currentSessionId,
+		}
+	}
+
+	return resSessions, nil
+}
+
+func (su *sessionUseCase) RevokeSession(userId uint, sessionId uint) error {
+	session := model.Session{}
+	if err := su.sr.RevokeSession(&session, userId, sessionId, model.SessionRevokedByUser); err != nil {
+		return err
+	}
+	su.revokeSessionTokens([]model.Session{session})
+
+	return nil
+}
+
+func (su *sessionUseCase) RevokeAllSessions(userId uint, exceptSessionId uint, reason string) error {
+	sessions := []model.Session{}
+	if err := su.sr.RevokeSessions(&sessions, userId, exceptSessionId, reason); err != nil {
+		return err
+	}
+	su.revokeSessionTokens(sessions)
+
+	return nil
+}
+
+func (su *sessionUseCase) ForceLogout(adminId uint, organizationId uint, userId uint) error {
+	organization := model.Organization{}
+	if err := su.or.GetOrganizationById(&organization, organizationId); err != nil {
+		return err
+	}
+	if organization.Founder != adminId {
+		return fmt.Errorf("only the founder can force users to log out")
+	}
+	user := model.User{}
+	if err := su.ur.GetLoggedInUserDetails(&user, userId); err != nil {
+		return err
+	}
+	if user.ID == 0 || user.OrganizationId != organizationId {
+		return fmt.Errorf("the user does not belong to the organization")
+	}
+
+	return su.RevokeAllSessions(userId, 0, model.SessionRevokedByAdmin)
+}
+
+func (su *sessionUseCase) IsTokenRevoked(jti string) (bool, error) {
+	return su.sr.IsTokenRevoked(jti)
+}
+
+func (su *sessionUseCase) PurgeRevokedTokens(now time.Time) error {
+	return su.sr.DeleteExpiredRevokedTokens(now)
+}
+
+func (su *sessionUseCase) revokeReusedSession(session model.Session) {
+	if session.RevokedAt != nil {
+		return
+	}
+	revoked := model.Session{}
+	if err := su.sr.RevokeSession(&revoked, session.UserId, session.ID, model.SessionRevokedReused); err != nil {
+		log.Printf("failed to revoke session %d: %v", session.ID, err)
+		return
+	}
+	su.revokeSessionTokens([]model.Session{revoked})
+	log.Printf("refresh token reuse detected, revoked session %d of user %d", session.ID, session.UserId)
+}
+
+// 失効させたセッションの、期限が残っているアクセストークンを拒否リストに入れる
+func (su *sessionUseCase) revokeSessionTokens(sessions []model.Session) {
+	now := time.Now()
+	revokedTokens := []model.RevokedToken{}
+	for _, v := range sessions {
+		if v.AccessJti == "" || !v.AccessExpiresAt.After(now) {
+			continue
+		}
+		revokedTokens = append(revokedTokens, model.RevokedToken{Jti: v.AccessJti, UserId: v.UserId, ExpiresAt: v.AccessExpiresAt})
+	}
+	if err := su.sr.RevokeTokens(revokedTokens); err != nil {
+		log.Printf("failed to revoke access tokens: %v", err)
+	}
+}
+
+// セッションID・jtiを含むアクセストークンを発行する
+func (su *sessionUseCase) issueTokens(session model.Session, refreshToken string) (model.AuthTokens, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": session.UserId,
+		"session_id": session.ID,
+		"jti": session.AccessJti,
+		"exp": session.AccessExpiresAt.Unix(),
+	})
+	tokenString, err := token.SignedString([]byte(os.Getenv("SECRET")))
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	return model.AuthTokens{
+		AccessToken: tokenString,
+		AccessExpiresAt: session.AccessExpiresAt,
+		RefreshToken: refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// 署名を検証してアクセストークンのクレームを取り出す。期限切れのトークンはエラーになる
+func parseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	if tokenString == "" {
+		return nil, fmt.Errorf("missing access token")
+	}
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(os.Getenv("SECRET")), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("invalid access token")
+	}
+	return claims, nil
+}
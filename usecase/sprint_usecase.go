@@ -0,0 +1,531 @@
+package usecase
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+	"time"
+)
+
+type ISprintUseCase interface {
+	// スプリントを作成する
+	CreateSprint(userId uint, sprint model.Sprint) (model.SprintResponse, error)
+	// チームのスプリント一覧を取得する
+	GetTeamSprints(userId uint, teamId uint) ([]model.SprintResponse, error)
+	// スプリントを取得する
+	GetSprint(userId uint, sprintId uint) (model.SprintResponse, error)
+	// スプリントの名前・目標・期間を更新する
+	UpdateSprint(userId uint, sprintId uint, sprint model.Sprint) (model.SprintResponse, error)
+	// スプリントを削除する(タスクはバックログに戻る)
+	DeleteSprint(userId uint, sprintId uint) error
+	// スプリントに含まれているタスクを取得する
+	GetSprintTasks(userId uint, sprintId uint) ([]model.TaskResponse, error)
+	// タスクをスプリントに追加する
+	AddSprintTasks(userId uint, sprintId uint, request model.SprintTasksRequest) ([]model.TaskResponse, error)
+	// タスクをスプリントから外す
+	RemoveSprintTask(userId uint, sprintId uint, taskId uint) ([]model.TaskResponse, error)
+	// スプリントを終了し、未完了のタスクを持ち越す
+	CloseSprint(userId uint, sprintId uint, request model.SprintCloseRequest) (model.SprintReportResponse, error)
+	// スコープ・完了・持ち越しの集計を取得する
+	GetSprintReport(userId uint, sprintId uint) (model.SprintReportResponse, error)
+	// 日ごとの残り(タスク数またはポイント)と理想線を取得する
+	GetBurndown(userId uint, sprintId uint, unit string) ([]model.BurndownPoint, error)
+	// 日ごとのスコープと完了(タスク数またはポイント)を取得する
+	GetBurnup(userId uint, sprintId uint, unit string) ([]model.BurnupPoint, error)
+}
+
+type sprintUseCase struct {
+	sr  repository.ISprintRepository
+	hr  repository.ITaskHistoryRepository
+	tmr repository.ITeamMemberRepository
+	sv  validator.ISprintValidator
+}
+
+func NewSprintUseCase(sr repository.ISprintRepository, hr repository.ITaskHistoryRepository, tmr repository.ITeamMemberRepository, sv validator.ISprintValidator) ISprintUseCase {
+	return &sprintUseCase{sr, hr, tmr, sv}
+}
+
+func (su *sprintUseCase) CreateSprint(userId uint, sprint model.Sprint) (model.SprintResponse, error) {
+	if err := su.sv.SprintValidate(sprint); err != nil {
+		return model.SprintResponse{}, err
+	}
+	if err := su.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, sprint.TeamId); err != nil {
+		return model.SprintResponse{}, err
+	}
+
+	newSprint := model.Sprint{
+		TeamId: sprint.TeamId,
+		Name: sprint.Name,
+		Goal: sprint.Goal,
+		StartDate: sprint.StartDate.Truncate(24 * time.Hour),
+		EndDate: sprint.EndDate.Truncate(24 * time.Hour),
+		CreatedBy: userId,
+	}
+	if err := su.sr.CreateSprint(&newSprint); err != nil {
+		return model.SprintResponse{}, err
+	}
+
+	return toSprintResponse(newSprint, time.Now()), nil
+}
+
+func (su *sprintUseCase) GetTeamSprints(userId uint, teamId uint) ([]model.SprintResponse, error) {
+	if err := su.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return nil, err
+	}
+	sprints := make([]model.Sprint, 0)
+	if err := su.sr.GetSprintsByTeamId(&sprints, teamId); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	resSprints := make([]model.SprintResponse, len(sprints))
+	for i, v := range sprints {
+		resSprints[i] = toSprintResponse(v, now)
+	}
+
+	return resSprints, nil
+}
+
+func (su *sprintUseCase) GetSprint(userId uint, sprintId uint) (model.SprintResponse, error) {
+	sprint, err := su.memberSprint(userId, sprintId)
+	if err != nil {
+		return model.SprintResponse{}, err
+	}
+
+	return toSprintResponse(sprint, time.Now()), nil
+}
+
+func (su *sprintUseCase) UpdateSprint(userId uint, sprintId uint, sprint model.Sprint) (model.SprintResponse, error) {
+	if err := su.sv.SprintValidate(sprint); err != nil {
+		return model.SprintResponse{}, err
+	}
+	storedSprint, err := su.openSprint(userId, sprintId)
+	if err != nil {
+		return model.SprintResponse{}, err
+	}
+
+	updatedSprint := model.Sprint{
+		Name: sprint.Name,
+		Goal: sprint.Goal,
+		StartDate: sprint.StartDate.Truncate(24 * time.Hour),
+		EndDate: sprint.EndDate.Truncate(24 * time.Hour),
+	}
+	if err := su.sr.UpdateSprint(&updatedSprint, storedSprint.ID); err != nil {
+		return model.SprintResponse{}, err
+	}
+
+	return toSprintResponse(updatedSprint, time.Now()), nil
+}
+
+func (su *sprintUseCase) DeleteSprint(userId uint, sprintId uint) error {
+	sprint, err := su.memberSprint(userId, sprintId)
+	if err != nil {
+		return err
+	}
+
+	return su.sr.DeleteSprint(sprint.ID)
+}
+
+func (su *sprintUseCase) GetSprintTasks(userId uint, sprintId uint) ([]model.TaskResponse, error) {
+	sprint, err := su.memberSprint(userId, sprintId)
+	if err != nil {
+		return nil, err
+	}
+
+	return su.sprintTasks(sprint.ID)
+}
+
+func (su *sprintUseCase) AddSprintTasks(userId uint, sprintId uint, request model.SprintTasksRequest) ([]model.TaskResponse, error) {
+	if err := su.sv.SprintTasksValidate(request); err != nil {
+		return nil, err
+	}
+	sprint, err := su.openSprint(userId, sprintId)
+	if err != nil {
+		return nil, err
+	}
+	taskIds := make([]uint, 0, len(request.TaskIds))
+	seen := map[uint]bool{}
+	for _, v := range request.TaskIds {
+		if !seen[v] {
+			seen[v] = true
+			taskIds = append(taskIds, v)
+		}
+	}
+
+	if err := su.sr.AddSprintTasks(sprint.ID, sprint.TeamId, taskIds); err != nil {
+		return nil, err
+	}
+
+	return su.sprintTasks(sprint.ID)
+}
+
+func (su *sprintUseCase) RemoveSprintTask(userId uint, sprintId uint, taskId uint) ([]model.TaskResponse, error) {
+	sprint, err := su.openSprint(userId, sprintId)
+	if err != nil {
+		return nil, err
+	}
+	if err := su.sr.RemoveSprintTask(sprint.ID, taskId); err != nil {
+		return nil, err
+	}
+
+	return su.sprintTasks(sprint.ID)
+}
+
+func (su *sprintUseCase) CloseSprint(userId uint, sprintId uint, request model.SprintCloseRequest) (model.SprintReportResponse, error) {
+	sprint, err := su.openSprint(userId, sprintId)
+	if err != nil {
+		return model.SprintReportResponse{}, err
+	}
+	if request.CarryOverTo != 0 {
+		next := model.Sprint{}
+		if err := su.sr.GetSprintById(&next, request.CarryOverTo); err != nil {
+			return model.SprintReportResponse{}, err
+		}
+		if next.ID == sprint.ID || next.TeamId != sprint.TeamId || next.ClosedAt != nil {
+			return model.SprintReportResponse{}, fmt.Errorf("tasks can only be carried over to another open sprint of the team")
+		}
+	}
+
+	closedSprint := model.Sprint{}
+	if err := su.sr.CloseSprint(&closedSprint, sprint.ID, request.CarryOverTo); err != nil {
+		return model.SprintReportResponse{}, err
+	}
+
+	return su.report(closedSprint, time.Now())
+}
+
+func (su *sprintUseCase) GetSprintReport(userId uint, sprintId uint) (model.SprintReportResponse, error) {
+	sprint, err := su.memberSprint(userId, sprintId)
+	if err != nil {
+		return model.SprintReportResponse{}, err
+	}
+
+	return su.report(sprint, time.Now())
+}
+
+func (su *sprintUseCase) GetBurndown(userId uint, sprintId uint, unit string) ([]model.BurndownPoint, error) {
+	if err := su.sv.ChartUnitValidate(unit); err != nil {
+		return nil, err
+	}
+	sprint, err := su.memberSprint(userId, sprintId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	timeline, err := loadSprintTimeline(su.sr, su.hr, sprint.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 理想線は開始日の終わりのスコープから終了日に0になるように引く
+	days := sprintDays(sprint, now)
+	committed := timeline.total(timeline.scopeAt(sprintStartAt(sprint, now)), unit)
+	planned := int(sprint.EndDate.Sub(sprint.StartDate).Hours()/24) + 1
+	points := make([]model.BurndownPoint, len(days))
+	for i, v := range days {
+		ideal := 0.0
+		if planned > 1 && i < planned {
+			ideal = committed * float64(planned-1-i) / float64(planned-1)
+		}
+		points[i] = model.BurndownPoint{Date: v, Ideal: ideal}
+		at, ok := dayEndAt(v, sprint, now)
+		if !ok {
+			continue
+		}
+		remaining := timeline.total(timeline.incompleteAt(at), unit)
+		points[i].Remaining = &remaining
+	}
+
+	return points, nil
+}
+
+func (su *sprintUseCase) GetBurnup(userId uint, sprintId uint, unit string) ([]model.BurnupPoint, error) {
+	if err := su.sv.ChartUnitValidate(unit); err != nil {
+		return nil, err
+	}
+	sprint, err := su.memberSprint(userId, sprintId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	timeline, err := loadSprintTimeline(su.sr, su.hr, sprint.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := sprintDays(sprint, now)
+	points := make([]model.BurnupPoint, len(days))
+	for i, v := range days {
+		points[i] = model.BurnupPoint{Date: v}
+		at, ok := dayEndAt(v, sprint, now)
+		if !ok {
+			continue
+		}
+		scope := timeline.total(timeline.scopeAt(at), unit)
+		completed := timeline.total(timeline.completedTasksAt(at), unit)
+		points[i].Scope = &scope
+		points[i].Completed = &completed
+	}
+
+	return points, nil
+}
+
+// 所属チームのスプリントを取得する
+func (su *sprintUseCase) memberSprint(userId uint, sprintId uint) (model.Sprint, error) {
+	sprint := model.Sprint{}
+	if err := su.sr.GetSprintById(&sprint, sprintId); err != nil {
+		return model.Sprint{}, err
+	}
+	if err := su.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, sprint.TeamId); err != nil {
+		return model.Sprint{}, err
+	}
+
+	return sprint, nil
+}
+
+// 終了したスプリントはスコープと期間を変更できない
+func (su *sprintUseCase) openSprint(userId uint, sprintId uint) (model.Sprint, error) {
+	sprint, err := su.memberSprint(userId, sprintId)
+	if err != nil {
+		return model.Sprint{}, err
+	}
+	if sprint.ClosedAt != nil {
+		return model.Sprint{}, fmt.Errorf("the sprint is already closed")
+	}
+
+	return sprint, nil
+}
+
+func (su *sprintUseCase) sprintTasks(sprintId uint) ([]model.TaskResponse, error) {
+	tasks := make([]model.Task, 0)
+	if err := su.sr.GetSprintTasks(&tasks, sprintId); err != nil {
+		return nil, err
+	}
+	resTasks := make([]model.TaskResponse, len(tasks))
+	for i, v := range tasks {
+		resTasks[i] = toBoardCard(v)
+	}
+
+	return resTasks, nil
+}
+
+func (su *sprintUseCase) report(sprint model.Sprint, now time.Time) (model.SprintReportResponse, error) {
+	timeline, err := loadSprintTimeline(su.sr, su.hr, sprint.ID)
+	if err != nil {
+		return model.SprintReportResponse{}, err
+	}
+	carryOvers := make([]model.SprintCarryOver, 0)
+	if err := su.sr.GetCarryOvers(&carryOvers, sprint.ID); err != nil {
+		return model.SprintReportResponse{}, err
+	}
+
+	startAt := sprintStartAt(sprint, now)
+	endAt := sprintEndAt(sprint, now)
+	resReport := model.SprintReportResponse{
+		Sprint: toSprintResponse(sprint, now),
+		Committed: timeline.scopeAt(startAt),
+		Added: make([]uint, 0),
+		Removed: make([]uint, 0),
+		Completed: timeline.completedTasksAt(endAt),
+		Incomplete: timeline.incompleteAt(endAt),
+		CarriedOver: make([]model.SprintCarryOverResponse, len(carryOvers)),
+	}
+	committed := map[uint]bool{}
+	for _, v := range resReport.Committed {
+		committed[v] = true
+	}
+	inScope := map[uint]bool{}
+	for _, v := range timeline.scopeAt(endAt) {
+		inScope[v] = true
+	}
+	for _, v := range timeline.taskIds {
+		if !committed[v] {
+			resReport.Added = append(resReport.Added, v)
+		}
+		if !inScope[v] {
+			resReport.Removed = append(resReport.Removed, v)
+		}
+	}
+	for i, v := range carryOvers {
+		resReport.CarriedOver[i] = model.SprintCarryOverResponse{TaskId: v.TaskId, Title: timeline.tasks[v.TaskId].Title, ToSprintId: v.ToSprintId}
+	}
+	if total := len(resReport.Completed) + len(resReport.Incomplete); total > 0 {
+		resReport.CompletionRate = float64(len(resReport.Completed)) / float64(total)
+	}
+	resReport.CommittedPoints = timeline.total(resReport.Committed, model.ChartUnitPoints)
+	resReport.CompletedPoints = timeline.total(resReport.Completed, model.ChartUnitPoints)
+
+	return resReport, nil
+}
+
+// スプリントに含まれたことのあるタスクと、スコープとステータスの変更履歴
+type sprintTimeline struct {
+	taskIds []uint
+	tasks   map[uint]model.Task
+	scope   map[uint][]model.SprintTaskChange
+	status  map[uint][]model.TaskStatusChange
+}
+
+func loadSprintTimeline(sr repository.ISprintRepository, hr repository.ITaskHistoryRepository, sprintId uint) (sprintTimeline, error) {
+	scopeChanges := make([]model.SprintTaskChange, 0)
+	if err := sr.GetSprintTaskChanges(&scopeChanges, sprintId); err != nil {
+		return sprintTimeline{}, err
+	}
+	timeline := sprintTimeline{taskIds: make([]uint, 0), tasks: map[uint]model.Task{}, scope: map[uint][]model.SprintTaskChange{}, status: map[uint][]model.TaskStatusChange{}}
+	for _, v := range scopeChanges {
+		if _, ok := timeline.scope[v.TaskId]; !ok {
+			timeline.taskIds = append(timeline.taskIds, v.TaskId)
+		}
+		timeline.scope[v.TaskId] = append(timeline.scope[v.TaskId], v)
+	}
+	tasks := make([]model.Task, 0)
+	if err := sr.GetTasksByIds(&tasks, timeline.taskIds); err != nil {
+		return sprintTimeline{}, err
+	}
+	for _, v := range tasks {
+		timeline.tasks[v.ID] = v
+	}
+	statusChanges := make([]model.TaskStatusChange, 0)
+	if err := hr.GetStatusChanges(&statusChanges, timeline.taskIds); err != nil {
+		return sprintTimeline{}, err
+	}
+	for _, v := range statusChanges {
+		timeline.status[v.TaskId] = append(timeline.status[v.TaskId], v)
+	}
+
+	return timeline, nil
+}
+
+// 指定した時点でスプリントに含まれていて完了していたタスク
+func (t sprintTimeline) completedTasksAt(at time.Time) []uint {
+	taskIds := make([]uint, 0)
+	for _, v := range t.scopeAt(at) {
+		if t.completedAt(v, at) {
+			taskIds = append(taskIds, v)
+		}
+	}
+	return taskIds
+}
+
+// 指定した時点でスプリントに含まれていて完了していなかったタスク
+func (t sprintTimeline) incompleteAt(at time.Time) []uint {
+	taskIds := make([]uint, 0)
+	for _, v := range t.scopeAt(at) {
+		if !t.completedAt(v, at) {
+			taskIds = append(taskIds, v)
+		}
+	}
+	return taskIds
+}
+
+// タスク数、またはストーリーポイントの合計(未見積もりのタスクは0)
+func (t sprintTimeline) total(taskIds []uint, unit string) float64 {
+	if unit != model.ChartUnitPoints {
+		return float64(len(taskIds))
+	}
+	total := 0.0
+	for _, v := range taskIds {
+		if points := t.tasks[v].StoryPoints; points != nil {
+			total += *points
+		}
+	}
+	return total
+}
+
+// 指定した時点でスプリントに含まれていたタスク
+func (t sprintTimeline) scopeAt(at time.Time) []uint {
+	taskIds := make([]uint, 0)
+	for _, taskId := range t.taskIds {
+		added := false
+		for _, v := range t.scope[taskId] {
+			if v.ChangedAt.After(at) {
+				break
+			}
+			added = v.Added
+		}
+		if added {
+			taskIds = append(taskIds, taskId)
+		}
+	}
+	return taskIds
+}
+
+// 指定した時点でタスクが完了していたか
+func (t sprintTimeline) completedAt(taskId uint, at time.Time) bool {
+	completed := false
+	for _, v := range t.status[taskId] {
+		if v.ChangedAt.After(at) {
+			break
+		}
+		completed = v.ToStatus == model.TaskStatusCompleted
+	}
+	return completed
+}
+
+// 集計の終わりの時点。終了したスプリントは終了時、それ以外は現在
+func sprintEndAt(sprint model.Sprint, now time.Time) time.Time {
+	if sprint.ClosedAt != nil {
+		return *sprint.ClosedAt
+	}
+	return now
+}
+
+// 計画時のスコープを確定する時点(開始日の終わり)
+func sprintStartAt(sprint model.Sprint, now time.Time) time.Time {
+	at := sprint.StartDate.Add(24 * time.Hour)
+	if end := sprintEndAt(sprint, now); end.Before(at) {
+		return end
+	}
+	return at
+}
+
+// 開始日から終了日までの日付。終了日を過ぎて終了した場合は終了した日、終了していない場合は今日まで延ばす
+// 期間の上限より前に作られたスプリントも含め、日数は開始日から上限の2倍までにする
+func sprintDays(sprint model.Sprint, now time.Time) []time.Time {
+	last := sprint.EndDate
+	if end := sprintEndAt(sprint, now).Truncate(24 * time.Hour); end.After(last) {
+		last = end
+	}
+	if limit := sprint.StartDate.AddDate(0, 0, 2*model.MaxSprintDays-1); last.After(limit) {
+		last = limit
+	}
+	days := make([]time.Time, 0)
+	for d := sprint.StartDate; !d.After(last); d = d.Add(24 * time.Hour) {
+		days = append(days, d)
+	}
+	return days
+}
+
+// 日の終わりの時点(集計の終わりを越える場合はその時点)。まだ来ていない日はfalse
+func dayEndAt(day time.Time, sprint model.Sprint, now time.Time) (time.Time, bool) {
+	end := sprintEndAt(sprint, now)
+	if day.After(end) {
+		return time.Time{}, false
+	}
+	at := day.Add(24 * time.Hour)
+	if end.Before(at) {
+		return end, true
+	}
+	return at, true
+}
+
+func toSprintResponse(sprint model.Sprint, now time.Time) model.SprintResponse {
+	state := model.SprintStateActive
+	if sprint.ClosedAt != nil {
+		state = model.SprintStateClosed
+	} else if now.Before(sprint.StartDate) {
+		state = model.SprintStatePlanned
+	}
+	return model.SprintResponse{
+		ID: sprint.ID,
+		TeamId: sprint.TeamId,
+		Name: sprint.Name,
+		Goal: sprint.Goal,
+		StartDate: sprint.StartDate,
+		EndDate: sprint.EndDate,
+		State: state,
+		ClosedAt: sprint.ClosedAt,
+		CreatedAt: sprint.CreatedAt,
+		UpdatedAt: sprint.UpdatedAt,
+	}
+}
@@ -0,0 +1,258 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// 再接続時に送り直す最大件数
+	maxStreamBacklog = 500
+	// 購読者ごとの送信待ちの上限。溢れた購読者は切断し、再接続時に送り直す
+	streamBufferSize = 64
+	// この期間より古いイベントは削除する(これより長く切断していた場合は画面を読み直してもらう)
+	streamEventRetention = 24 * time.Hour
+	// 期限が切れたか無効にされたトークンの購読を切断する間隔
+	streamAuthCheckInterval = 30 * time.Second
+)
+
+type IStreamUseCase interface {
+	IEventPublisher
+	// 参加中のチームのイベントを購読する。lastEventIdが0でなければ、それより後のイベントを先に返す
+	// トークンの期限(expiresAt)を過ぎるか、トークン(jti)が無効にされると購読を切断し、新しいトークンで接続し直してもらう
+	Subscribe(userId uint, lastEventId uint, jti string, expiresAt time.Time) (*StreamSubscription, error)
+	// 他のインスタンスを含めて保存されたイベントを待ち受け、購読者に配る。ctxが終了するまで戻らない
+	Run(ctx context.Context)
+}
+
+type StreamSubscription struct {
+	// 再接続までに発生していたイベント
+	Backlog []model.StreamEvent
+	// 0でなければ送り直せる件数を超えていたため、Backlogは空。クライアントは画面を読み直し、このIDから受け取る
+	ResetId uint
+	// 新しいイベント。購読が切断されると閉じる
+	Events <-chan model.StreamEvent
+	close  func()
+}
+
+// 購読をやめる
+func (ss *StreamSubscription) Close() {
+	ss.close()
+}
+
+type streamSubscriber struct {
+	userId    uint
+	teams     map[uint]bool
+	jti       string
+	expiresAt time.Time
+	events    chan model.StreamEvent
+}
+
+type streamUseCase struct {
+	sr          repository.IStreamEventRepository
+	tmr         repository.ITeamMemberRepository
+	ssr         repository.ISessionRepository
+	mu          sync.Mutex
+	subscribers map[*streamSubscriber]bool
+}
+
+func NewStreamUseCase(sr repository.IStreamEventRepository, tmr repository.ITeamMemberRepository, ssr repository.ISessionRepository) IStreamUseCase {
+	return &streamUseCase{sr: sr, tmr: tmr, ssr: ssr, subscribers: map[*streamSubscriber]bool{}}
+}
+
+func (su *streamUseCase) Publish(event model.Event) {
+	if event.TeamId == 0 {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("failed to encode %s stream event: %v", event.Type, err)
+		return
+	}
+	streamEvent := model.StreamEvent{
+		TeamId: event.TeamId,
+		Type: event.Type,
+		TaskId: event.TaskId,
+		ActorId: event.ActorId,
+		Payload: string(payload),
+	}
+	if err := su.sr.CreateStreamEvent(&streamEvent); err != nil {
+		log.Printf("failed to save %s stream event: %v", event.Type, err)
+	}
+}
+
+func (su *streamUseCase) Subscribe(userId uint, lastEventId uint, jti string, expiresAt time.Time) (*StreamSubscription, error) {
+	teamIds := make([]uint, 0)
+	if err := su.tmr.GetActiveTeamIds(&teamIds, userId); err != nil {
+		return nil, err
+	}
+	subscriber := &streamSubscriber{userId: userId, teams: map[uint]bool{}, jti: jti, expiresAt: expiresAt, events: make(chan model.StreamEvent, streamBufferSize)}
+	for _, v := range teamIds {
+		subscriber.teams[v] = true
+	}
+
+	// 取りこぼさないよう、先に購読を登録してから過去のイベントを読む
+	su.mu.Lock()
+	su.subscribers[subscriber] = true
+	su.mu.Unlock()
+	closeSubscription := func() {
+		su.mu.Lock()
+		defer su.mu.Unlock()
+		if su.subscribers[subscriber] {
+			delete(su.subscribers, subscriber)
+			close(subscriber.events)
+		}
+	}
+
+	backlog := make([]model.StreamEvent, 0)
+	if lastEventId != 0 && len(teamIds) > 0 {
+		if err := su.sr.GetStreamEventsAfter(&backlog, lastEventId, teamIds, maxStreamBacklog+1); err != nil {
+			closeSubscription()
+			return nil, err
+		}
+	}
+	// 送り直せる件数を超えた場合は途中までを送らず、読み直してもらう
+	if len(backlog) > maxStreamBacklog {
+		var latestId uint
+		if err := su.sr.GetLatestStreamEventId(&latestId); err != nil {
+			closeSubscription()
+			return nil, err
+		}
+		return &StreamSubscription{Backlog: []model.StreamEvent{}, ResetId: latestId, Events: subscriber.events, close: closeSubscription}, nil
+	}
+
+	return &StreamSubscription{Backlog: backlog, Events: subscriber.events, close: closeSubscription}, nil
+}
+
+func (su *streamUseCase) Run(ctx context.Context) {
+	go su.prune(ctx)
+	go su.expire(ctx)
+
+	// 接続が切れたら間隔を空けて待ち受けをやり直す
+	wait := time.Second
+	for {
+		started := time.Now()
+		err := su.sr.ListenStreamEvents(ctx, su.dispatch)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("stream event listener stopped: %v", err)
+		if time.Since(started) > time.Minute {
+			wait = time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if wait < 30*time.Second {
+			wait *= 2
+		}
+	}
+}
+
+func (su *streamUseCase) dispatch(eventId uint) {
+	event := model.StreamEvent{}
+	if err := su.sr.GetStreamEventById(&event, eventId); err != nil {
+		log.Printf("failed to load stream event %d: %v", eventId, err)
+		return
+	}
+
+	su.mu.Lock()
+	subscribed := false
+	for subscriber := range su.subscribers {
+		if subscriber.teams[event.TeamId] {
+			subscribed = true
+			break
+		}
+	}
+	su.mu.Unlock()
+	if !subscribed {
+		return
+	}
+	// 接続後にチームを抜けたユーザーには送らない
+	memberIds := make([]uint, 0)
+	if err := su.tmr.GetActiveTeamMemberIds(&memberIds, event.TeamId); err != nil {
+		log.Printf("failed to load members of team %d: %v", event.TeamId, err)
+		return
+	}
+	members := map[uint]bool{}
+	for _, v := range memberIds {
+		members[v] = true
+	}
+
+	su.mu.Lock()
+	defer su.mu.Unlock()
+	for subscriber := range su.subscribers {
+		if !subscriber.teams[event.TeamId] {
+			continue
+		}
+		if !members[subscriber.userId] {
+			delete(subscriber.teams, event.TeamId)
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+			delete(su.subscribers, subscriber)
+			close(subscriber.events)
+		}
+	}
+}
+
+// 期限が切れたか、ログアウトなどで無効にされたトークンの購読を切断する
+func (su *streamUseCase) expire(ctx context.Context) {
+	ticker := time.NewTicker(streamAuthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			su.mu.Lock()
+			jtis := make([]string, 0, len(su.subscribers))
+			for subscriber := range su.subscribers {
+				jtis = append(jtis, subscriber.jti)
+			}
+			su.mu.Unlock()
+
+			revokedJtis := make([]string, 0)
+			if err := su.ssr.GetRevokedJtis(&revokedJtis, jtis); err != nil {
+				log.Printf("failed to check revoked stream tokens: %v", err)
+			}
+			revoked := map[string]bool{}
+			for _, v := range revokedJtis {
+				revoked[v] = true
+			}
+
+			su.mu.Lock()
+			for subscriber := range su.subscribers {
+				if !now.Before(subscriber.expiresAt) || revoked[subscriber.jti] {
+					delete(su.subscribers, subscriber)
+					close(subscriber.events)
+				}
+			}
+			su.mu.Unlock()
+		}
+	}
+}
+
+func (su *streamUseCase) prune(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if err := su.sr.DeleteStreamEventsBefore(time.Now().Add(-streamEventRetention)); err != nil {
+			log.Printf("failed to delete old stream events: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
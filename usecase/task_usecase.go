@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"errors"
+	"fmt"
 	"go-rest-api/model"
 	"go-rest-api/repository"
 	"go-rest-api/validator"
@@ -17,15 +19,34 @@ type ITaskUseCase interface {
 	DeleteTask(userId uint, taskId uint) error
 	NarrowDownStatus(userId uint, taskStatus string) ([]model.TaskResponse, error)
 	FuzzySearch(userId uint, keyword string, taskStatus ...string)([]model.TaskResponse, error)
+	// 条件を指定してタスクを絞り込み・並び替える
+	QueryTasks(userId uint, query model.TaskQuery) ([]model.TaskResponse, error)
+	// メモ内のタスクリストのチェックボックスを切り替える
+	UpdateMemoCheckbox(userId uint, taskId uint, index int, checked bool) (model.TaskResponse, error)
+	// タスクの担当者一覧を取得する
+	GetAssignees(userId uint, taskId uint) ([]model.UserResponse, error)
+	// チームのメンバーをタスクの担当者にする
+	AssignTask(userId uint, taskId uint, assigneeId uint) ([]model.UserResponse, error)
+	// タスクの担当者から外す
+	UnassignTask(userId uint, taskId uint, assigneeId uint) ([]model.UserResponse, error)
+	// チームの尺度でタスクを見積もる
+	EstimateTask(userId uint, taskId uint, estimate model.TaskEstimate) (model.TaskResponse, error)
 }
 
 type taskUseCase struct {
-	tr repository.ITaskRepository
-	tv validator.ITaskValidator
+	tr  repository.ITaskRepository
+	tmr repository.ITeamMemberRepository
+	ter repository.ITeamRepository
+	tv  validator.ITaskValidator
+	ev  validator.IEstimateValidator
+	mu  IMentionUseCase
+	nu  INotificationUseCase
+	wu  IWatcherUseCase
+	ep  IEventPublisher
 }
 
-func NewTaskUsecase(tr repository.ITaskRepository, tv validator.ITaskValidator) ITaskUseCase {
-	return &taskUseCase{tr, tv}
+func NewTaskUsecase(tr repository.ITaskRepository, tmr repository.ITeamMemberRepository, ter repository.ITeamRepository, tv validator.ITaskValidator, ev validator.IEstimateValidator, mu IMentionUseCase, nu INotificationUseCase, wu IWatcherUseCase, ep IEventPublisher) ITaskUseCase {
+	return &taskUseCase{tr, tmr, ter, tv, ev, mu, nu, wu, ep}
 }
 
 func (tu *taskUseCase) GetAllTasks(userId uint) ([]model.TaskResponse, error) {
@@ -55,6 +76,9 @@ func (tu *taskUseCase) GetTaskById(userId uint, taskId uint) (model.TaskResponse
 	resTask := model.TaskResponse{
 		ID: task.ID,
 		Title: task.Title,
+		Status: task.Status,
+		Memo: task.Memo,
+		MemoHTML: renderMarkdown(task.Memo),
 		DeadLine: task.DeadLine,
 		CreatedAt: task.CreatedAt,
 		UpdatedAt: task.UpdatedAt,
@@ -75,6 +99,7 @@ func (tu *taskUseCase) GetTasksByDeadline(userId uint, fromDate time.Time, toDat
 			Title: v.Title,
 			Status: v.Status,
 			Memo: v.Memo,
+			MemoHTML: renderMarkdown(v.Memo),
 			DeadLine: v.DeadLine,
 			CreatedAt: v.CreatedAt,
 			UpdatedAt: v.UpdatedAt,
@@ -88,17 +113,37 @@ func (tu *taskUseCase) CreateTask(task model.Task) (model.TaskResponse, error) {
 	if err := tu.tv.TaskValidate(task); err != nil {
 		return model.TaskResponse{}, err
 	}
+	// ボードの列の末尾に追加する
+	column := make([]model.Task, 0)
+	if err := tu.tr.GetColumnTasks(&column, task.TeamId, task.Status); err != nil {
+		return model.TaskResponse{}, err
+	}
+	last := ""
+	for _, v := range column {
+		if v.Position != "" {
+			last = v.Position
+		}
+	}
+	position, err := rankBetween(last, "")
+	if err != nil {
+		return model.TaskResponse{}, err
+	}
+	task.Position = position
 	if err := tu.tr.CreateTask(&task); err != nil {
 		return model.TaskResponse{}, nil
 	}
+	tu.wu.AutoWatch(task.ID, task.CreatedBy)
+	tu.ep.Publish(taskEvent(model.EventTaskCreated, task, task.CreatedBy, toBoardCard(task)))
 	resTask := model.TaskResponse{
 		ID: task.ID,
 		Title: task.Title,
 		Status: task.Status,
 		Memo: task.Memo,
+		MemoHTML: renderMarkdown(task.Memo),
 		DeadLine: task.DeadLine,
 		CreatedAt: task.CreatedAt,
 		UpdatedAt: task.UpdatedAt,
+		Position: task.Position,
 	}
 
 	return resTask, nil
@@ -108,14 +153,28 @@ func (tu *taskUseCase) UpdateTask(task model.Task, userId uint, taskId uint) (mo
 	if err := tu.tv.TaskValidate(task); err != nil {
 		return model.TaskResponse{}, err
 	}
+	oldTask := model.Task{}
+	if err := tu.tr.GetMemberTaskById(&oldTask, userId, taskId); err != nil {
+		return model.TaskResponse{}, err
+	}
 	if err := tu.tr.UpdateTask(&task, userId, taskId); err != nil {
 		return model.TaskResponse{}, err
 	}
+	// メモ内の @name / @email をメンションとして保存する
+	mentions, err := tu.mu.SyncMentions(taskId, 0, userId, task.Memo)
+	if err != nil {
+		return model.TaskResponse{}, err
+	}
+	notifyMentions(tu.nu, task, mentions)
+	notifyStatusChange(tu.nu, task, oldTask.Status, userId)
+	notifyTaskEdit(tu.nu, task, oldTask, userId)
+	tu.ep.Publish(taskEvent(model.EventTaskUpdated, task, userId, toBoardCard(task)))
 	resTask := model.TaskResponse{
 		ID: task.ID,
 		Title: task.Title,
 		Status: task.Status,
 		Memo: task.Memo,
+		MemoHTML: renderMarkdown(task.Memo),
 		DeadLine: task.DeadLine,
 		CreatedAt: task.CreatedAt,
 		UpdatedAt: task.UpdatedAt,
@@ -128,14 +187,21 @@ func (tu *taskUseCase) UpdateTaskStatus(task model.Task, userId uint, taskId uin
 	if err := tu.tv.TaskStatusValidate(task); err != nil {
 		return model.TaskResponse{}, err
 	}
+	oldTask := model.Task{}
+	if err := tu.tr.GetMemberTaskById(&oldTask, userId, taskId); err != nil {
+		return model.TaskResponse{}, err
+	}
 	if err := tu.tr.UpdateTaskStatus(&task, userId, taskId); err != nil {
 		return model.TaskResponse{}, err
 	}
+	notifyStatusChange(tu.nu, task, oldTask.Status, userId)
+	tu.ep.Publish(taskEvent(model.EventTaskUpdated, task, userId, toBoardCard(task)))
 	resTask := model.TaskResponse{
 		ID: task.ID,
 		Title: task.Title,
 		Status: task.Status,
 		Memo: task.Memo,
+		MemoHTML: renderMarkdown(task.Memo),
 		DeadLine: task.DeadLine,
 		CreatedAt: task.CreatedAt,
 		UpdatedAt: task.UpdatedAt,
@@ -145,9 +211,19 @@ func (tu *taskUseCase) UpdateTaskStatus(task model.Task, userId uint, taskId uin
 }
 
 func (tu *taskUseCase) DeleteTask(userId uint, taskId uint) error {
+	task := model.Task{}
+	if err := tu.tr.GetMemberTaskById(&task, userId, taskId); err != nil {
+		return err
+	}
+	watcherIds, err := tu.wu.GetWatcherIds(taskId)
+	if err != nil {
+		return err
+	}
 	if err := tu.tr.DeleteTask(userId, taskId); err != nil {
 		return err
 	}
+	notifyTaskDeleted(tu.nu, task, watcherIds, userId)
+	tu.ep.Publish(taskEvent(model.EventTaskDeleted, task, userId, map[string]uint{"id": task.ID}))
 
 	return nil
 }
@@ -175,6 +251,7 @@ func (tu *taskUseCase) NarrowDownStatus(userId uint, taskStatus string) ([]model
 			Title: v.Title,
 			Status: v.Status,
 			Memo: v.Memo,
+			MemoHTML: renderMarkdown(v.Memo),
 			DeadLine: v.DeadLine,
 			CreatedAt: v.CreatedAt,
 			UpdatedAt: v.UpdatedAt,
@@ -216,11 +293,162 @@ func (tu *taskUseCase) FuzzySearch(userId uint, keyword string, taskStatus ...st
 			Title: v.Title,
 			Status: v.Status,
 			Memo: v.Memo,
+			MemoHTML: renderMarkdown(v.Memo),
+			DeadLine: v.DeadLine,
+			CreatedAt: v.CreatedAt,
+			UpdatedAt: v.UpdatedAt,
+		}
+	}
+
+	return resTasks, nil
+}
+
+func (tu *taskUseCase) QueryTasks(userId uint, query model.TaskQuery) ([]model.TaskResponse, error) {
+	if err := tu.tv.TaskQueryValidate(query); err != nil {
+		return nil, err
+	}
+	tasks := make([]model.Task, 0)
+	if err := tu.tr.SearchTasks(&tasks, userId, query); err != nil {
+		return nil, err
+	}
+	resTasks := make([]model.TaskResponse, len(tasks))
+	for i, v := range tasks {
+		resTasks[i] = model.TaskResponse{
+			ID: v.ID,
+			Title: v.Title,
+			Status: v.Status,
+			Memo: v.Memo,
+			MemoHTML: renderMarkdown(v.Memo),
 			DeadLine: v.DeadLine,
 			CreatedAt: v.CreatedAt,
 			UpdatedAt: v.UpdatedAt,
+			Position: v.Position,
+			Labels: v.Labels,
 		}
 	}
 
 	return resTasks, nil
-}
\ No newline at end of file
+}
+
+func (tu *taskUseCase) UpdateMemoCheckbox(userId uint, taskId uint, index int, checked bool) (model.TaskResponse, error) {
+	// 同時に別のチェックボックスが更新された場合は読み直してやり直す
+	for retry := 0; retry < 3; retry++ {
+		task := model.Task{}
+		if err := tu.tr.GetMemberTaskById(&task, userId, taskId); err != nil {
+			return model.TaskResponse{}, err
+		}
+		memo, err := setTaskListItem(task.Memo, index, checked)
+		if err != nil {
+			return model.TaskResponse{}, err
+		}
+		updatedTask := model.Task{}
+		if err := tu.tr.UpdateTaskMemo(&updatedTask, taskId, task.Memo, memo); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				continue
+			}
+			return model.TaskResponse{}, err
+		}
+
+		notifyTaskEdit(tu.nu, updatedTask, task, userId)
+		tu.ep.Publish(taskEvent(model.EventTaskUpdated, updatedTask, userId, toBoardCard(updatedTask)))
+
+		return toBoardCard(updatedTask), nil
+	}
+
+	return model.TaskResponse{}, fmt.Errorf("the memo was updated concurrently, try again")
+}
+
+func (tu *taskUseCase) GetAssignees(userId uint, taskId uint) ([]model.UserResponse, error) {
+	if err := tu.tr.GetMemberTaskById(&model.Task{}, userId, taskId); err != nil {
+		return nil, err
+	}
+
+	return tu.assignees(taskId)
+}
+
+func (tu *taskUseCase) AssignTask(userId uint, taskId uint, assigneeId uint) ([]model.UserResponse, error) {
+	task := model.Task{}
+	if err := tu.tr.GetMemberTaskById(&task, userId, taskId); err != nil {
+		return nil, err
+	}
+	if err := tu.tmr.GetActiveTeamMember(&model.TeamMember{}, assigneeId, task.TeamId); err != nil {
+		return nil, fmt.Errorf("the assignee is not a member of the team")
+	}
+	assignees, err := tu.assignees(taskId)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range assignees {
+		if v.ID == assigneeId {
+			return nil, fmt.Errorf("the user is already assigned to the task")
+		}
+	}
+
+	if err := tu.tr.AssignUser(&model.InCharge{TaskID: taskId, UserID: assigneeId}); err != nil {
+		return nil, err
+	}
+	tu.wu.AutoWatch(task.ID, assigneeId)
+	tu.nu.Notify([]uint{assigneeId}, model.Notification{
+		Type: model.NotificationTypeAssigned,
+		TaskId: task.ID,
+		TeamId: task.TeamId,
+		ActorId: userId,
+		Message: fmt.Sprintf("You were assigned to %q", task.Title),
+	})
+	tu.ep.Publish(taskEvent(model.EventTaskAssigned, task, userId, map[string]uint{"task_id": task.ID, "user_id": assigneeId}))
+
+	return tu.assignees(taskId)
+}
+
+func (tu *taskUseCase) UnassignTask(userId uint, taskId uint, assigneeId uint) ([]model.UserResponse, error) {
+	task := model.Task{}
+	if err := tu.tr.GetMemberTaskById(&task, userId, taskId); err != nil {
+		return nil, err
+	}
+	if err := tu.tr.UnassignUser(taskId, assigneeId); err != nil {
+		return nil, err
+	}
+	tu.ep.Publish(taskEvent(model.EventTaskUnassigned, task, userId, map[string]uint{"task_id": task.ID, "user_id": assigneeId}))
+
+	return tu.assignees(taskId)
+}
+
+func (tu *taskUseCase) EstimateTask(userId uint, taskId uint, estimate model.TaskEstimate) (model.TaskResponse, error) {
+	task := model.Task{}
+	if err := tu.tr.GetMemberTaskById(&task, userId, taskId); err != nil {
+		return model.TaskResponse{}, err
+	}
+	team := model.Team{}
+	if err := tu.ter.GetTeamById(&team, task.TeamId); err != nil {
+		return model.TaskResponse{}, err
+	}
+	if err := tu.ev.TaskEstimateValidate(estimate, team.EstimateScale); err != nil {
+		return model.TaskResponse{}, err
+	}
+
+	var storyPoints *float64
+	if points, ok := team.EstimateScale.Points(estimate.Estimate); ok {
+		storyPoints = &points
+	}
+	estimatedTask := model.Task{}
+	if err := tu.tr.UpdateTaskEstimate(&estimatedTask, task.ID, estimate.Estimate, storyPoints); err != nil {
+		return model.TaskResponse{}, err
+	}
+	notifyEstimateChange(tu.nu, estimatedTask, task.Estimate, userId)
+	tu.ep.Publish(taskEvent(model.EventTaskUpdated, estimatedTask, userId, toBoardCard(estimatedTask)))
+
+	return toBoardCard(estimatedTask), nil
+}
+
+func (tu *taskUseCase) assignees(taskId uint) ([]model.UserResponse, error) {
+	users := make([]model.User, 0)
+	if err := tu.tr.GetAssignees(&users, taskId); err != nil {
+		return nil, err
+	}
+	resUsers := make([]model.UserResponse, len(users))
+	for i, v := range users {
+		resUsers[i] = model.UserResponse{ID: v.ID, Email: v.Email, Name: v.Name}
+	}
+
+	return resUsers, nil
+}
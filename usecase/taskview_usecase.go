@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+)
+
+type ITaskViewUseCase interface {
+	// 保存済みビューを作成する
+	CreateTaskView(taskView model.TaskView, userId uint) (model.TaskViewResponse, error)
+	// 自分のビューと所属チームで共有されたビューを取得する
+	GetTaskViews(userId uint) ([]model.TaskViewResponse, error)
+	// チームで共有されたビューを取得する
+	GetTeamTaskViews(userId uint, teamId uint) ([]model.TaskViewResponse, error)
+	// 保存済みビューを更新する
+	UpdateTaskView(taskView model.TaskView, userId uint, taskViewId uint) (model.TaskViewResponse, error)
+	// 保存済みビューを削除する
+	DeleteTaskView(userId uint, taskViewId uint) error
+	// チームのビューをピン留め・解除する
+	PinTaskView(userId uint, taskViewId uint, pinned bool) (model.TaskViewResponse, error)
+	// 保存済みビューの条件でタスクを取得する
+	ExecuteTaskView(userId uint, taskViewId uint) ([]model.TaskResponse, error)
+}
+
+type taskViewUseCase struct {
+	tvr repository.ITaskViewRepository
+	tmr repository.ITeamMemberRepository
+	tu  ITaskUseCase
+	tv  validator.ITaskValidator
+	tvv validator.ITaskViewValidator
+}
+
+func NewTaskViewUseCase(tvr repository.ITaskViewRepository, tmr repository.ITeamMemberRepository, tu ITaskUseCase, tv validator.ITaskValidator, tvv validator.ITaskViewValidator) ITaskViewUseCase {
+	return &taskViewUseCase{tvr, tmr, tu, tv, tvv}
+}
+
+func (tvu *taskViewUseCase) CreateTaskView(taskView model.TaskView, userId uint) (model.TaskViewResponse, error) {
+	if err := tvu.validate(taskView); err != nil {
+		return model.TaskViewResponse{}, err
+	}
+	if taskView.TeamId != 0 {
+		if err := tvu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, taskView.TeamId); err != nil {
+			return model.TaskViewResponse{}, fmt.Errorf("the user is not a member of the team")
+		}
+	}
+
+	newTaskView := model.TaskView{Name: taskView.Name, Query: taskView.Query, UserId: userId, TeamId: taskView.TeamId}
+	if err := tvu.tvr.CreateTaskView(&newTaskView); err != nil {
+		return model.TaskViewResponse{}, err
+	}
+
+	return toTaskViewResponse(newTaskView), nil
+}
+
+func (tvu *taskViewUseCase) GetTaskViews(userId uint) ([]model.TaskViewResponse, error) {
+	taskViews := make([]model.TaskView, 0)
+	if err := tvu.tvr.GetTaskViewsByUserId(&taskViews, userId); err != nil {
+		return nil, err
+	}
+
+	resTaskViews := make([]model.TaskViewResponse, len(taskViews))
+	for i, v := range taskViews {
+		resTaskViews[i] = toTaskViewResponse(v)
+	}
+
+	return resTaskViews, nil
+}
+
+func (tvu *taskViewUseCase) GetTeamTaskViews(userId uint, teamId uint) ([]model.TaskViewResponse, error) {
+	if err := tvu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return nil, fmt.Errorf("the user is not a member of the team")
+	}
+	taskViews := make([]model.TaskView, 0)
+	if err := tvu.tvr.GetTaskViewsByTeamId(&taskViews, teamId); err != nil {
+		return nil, err
+	}
+
+	resTaskViews := make([]model.TaskViewResponse, len(taskViews))
+	for i, v := range taskViews {
+		resTaskViews[i] = toTaskViewResponse(v)
+	}
+
+	return resTaskViews, nil
+}
+
+func (tvu *taskViewUseCase) UpdateTaskView(taskView model.TaskView, userId uint, taskViewId uint) (model.TaskViewResponse, error) {
+	if err := tvu.validate(taskView); err != nil {
+		return model.TaskViewResponse{}, err
+	}
+	storedTaskView := model.TaskView{}
+	if err := tvu.tvr.GetTaskViewById(&storedTaskView, taskViewId); err != nil {
+		return model.TaskViewResponse{}, err
+	}
+	if storedTaskView.UserId != userId {
+		return model.TaskViewResponse{}, fmt.Errorf("only the owner can update the view")
+	}
+	if taskView.TeamId != 0 {
+		if err := tvu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, taskView.TeamId); err != nil {
+			return model.TaskViewResponse{}, fmt.Errorf("the user is not a member of the team")
+		}
+	}
+
+	updatedTaskView := model.TaskView{Name: taskView.Name, Query: taskView.Query, TeamId: taskView.TeamId}
+	if err := tvu.tvr.UpdateTaskView(&updatedTaskView, taskViewId); err != nil {
+		return model.TaskViewResponse{}, err
+	}
+
+	return toTaskViewResponse(updatedTaskView), nil
+}
+
+func (tvu *taskViewUseCase) DeleteTaskView(userId uint, taskViewId uint) error {
+	storedTaskView := model.TaskView{}
+	if err := tvu.tvr.GetTaskViewById(&storedTaskView, taskViewId); err != nil {
+		return err
+	}
+	if storedTaskView.UserId != userId {
+		return fmt.Errorf("only the owner can delete the view")
+	}
+	if err := tvu.tvr.DeleteTaskView(taskViewId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tvu *taskViewUseCase) PinTaskView(userId uint, taskViewId uint, pinned bool) (model.TaskViewResponse, error) {
+	storedTaskView := model.TaskView{}
+	if err := tvu.tvr.GetTaskViewById(&storedTaskView, taskViewId); err != nil {
+		return model.TaskViewResponse{}, err
+	}
+	if storedTaskView.TeamId == 0 {
+		return model.TaskViewResponse{}, fmt.Errorf("only team views can be pinned")
+	}
+	if err := tvu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, storedTaskView.TeamId); err != nil {
+		return model.TaskViewResponse{}, fmt.Errorf("the user is not a member of the team")
+	}
+
+	updatedTaskView := model.TaskView{}
+	if err := tvu.tvr.UpdateTaskViewPinned(&updatedTaskView, taskViewId, pinned); err != nil {
+		return model.TaskViewResponse{}, err
+	}
+
+	return toTaskViewResponse(updatedTaskView), nil
+}
+
+func (tvu *taskViewUseCase) ExecuteTaskView(userId uint, taskViewId uint) ([]model.TaskResponse, error) {
+	taskView := model.TaskView{}
+	if err := tvu.tvr.GetTaskViewById(&taskView, taskViewId); err != nil {
+		return nil, err
+	}
+	if taskView.UserId != userId {
+		if taskView.TeamId == 0 {
+			return nil, fmt.Errorf("the view is not shared with the user")
+		}
+		if err := tvu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, taskView.TeamId); err != nil {
+			return nil, fmt.Errorf("the view is not shared with the user")
+		}
+	}
+
+	// 実行するユーザーの権限で、アドホック検索と同じ処理を通す
+	return tvu.tu.QueryTasks(userId, taskView.Query)
+}
+
+func (tvu *taskViewUseCase) validate(taskView model.TaskView) error {
+	if err := tvu.tvv.TaskViewValidate(taskView); err != nil {
+		return err
+	}
+	return tvu.tv.TaskQueryValidate(taskView.Query)
+}
+
+func toTaskViewResponse(taskView model.TaskView) model.TaskViewResponse {
+	return model.TaskViewResponse{
+		ID: taskView.ID,
+		Name: taskView.Name,
+		Query: taskView.Query,
+		UserId: taskView.UserId,
+		TeamId: taskView.TeamId,
+		Pinned: taskView.Pinned,
+		CreatedAt: taskView.CreatedAt,
+		UpdatedAt: taskView.UpdatedAt,
+	}
+}
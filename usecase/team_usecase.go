@@ -3,6 +3,7 @@ package usecase
 import (
 	"go-rest-api/model"
 	"go-rest-api/repository"
+	"go-rest-api/validator"
 )
 
 type ITeamUseCase interface {
@@ -14,15 +15,20 @@ type ITeamUseCase interface {
 	GetTeamsByOrganizationId(organizationId uint) ([]model.TeamResponse, error)
 	// チームを削除する
 	DeleteTeam(teamId uint, userId uint) error
+	// 見積もりの尺度と選択肢を取得する
+	GetEstimateScale(userId uint, teamId uint) (model.EstimateScaleResponse, error)
+	// 見積もりの尺度を変更する(既存の見積もりのポイントはそのまま残す)
+	UpdateEstimateScale(userId uint, teamId uint, request model.EstimateScaleRequest) (model.EstimateScaleResponse, error)
 }
 
 type teamUseCase struct {
 	tr repository.ITeamRepository
 	tmr repository.ITeamMemberRepository
+	ev validator.IEstimateValidator
 }
 
-func NewTeamUseCase(tr repository.ITeamRepository, tmr repository.ITeamMemberRepository) ITeamUseCase {
-	return &teamUseCase{tr, tmr}
+func NewTeamUseCase(tr repository.ITeamRepository, tmr repository.ITeamMemberRepository, ev validator.IEstimateValidator) ITeamUseCase {
+	return &teamUseCase{tr, tmr, ev}
 }
 
 func (tu *teamUseCase) GetAssignTeamByUserId(userId uint) ([]model.TeamResponse, error) {
@@ -87,3 +93,37 @@ func (tu *teamUseCase) DeleteTeam(teamId uint, userId uint) error {
 	return nil
 }
 
+func (tu *teamUseCase) GetEstimateScale(userId uint, teamId uint) (model.EstimateScaleResponse, error) {
+	if err := tu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return model.EstimateScaleResponse{}, err
+	}
+	team := model.Team{}
+	if err := tu.tr.GetTeamById(&team, teamId); err != nil {
+		return model.EstimateScaleResponse{}, err
+	}
+
+	return toEstimateScaleResponse(team), nil
+}
+
+func (tu *teamUseCase) UpdateEstimateScale(userId uint, teamId uint, request model.EstimateScaleRequest) (model.EstimateScaleResponse, error) {
+	if err := tu.ev.EstimateScaleValidate(request); err != nil {
+		return model.EstimateScaleResponse{}, err
+	}
+	if err := tu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return model.EstimateScaleResponse{}, err
+	}
+	team := model.Team{}
+	if err := tu.tr.UpdateEstimateScale(&team, teamId, request.Scale); err != nil {
+		return model.EstimateScaleResponse{}, err
+	}
+
+	return toEstimateScaleResponse(team), nil
+}
+
+func toEstimateScaleResponse(team model.Team) model.EstimateScaleResponse {
+	return model.EstimateScaleResponse{
+		TeamId: team.ID,
+		Scale: team.EstimateScale,
+		Options: model.EstimateScaleOptions[team.EstimateScale],
+	}
+}
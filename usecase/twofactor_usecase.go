@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodeCount = 10
+	loginChallengeTTL = 5 * time.Minute
+	// 1つのチャレンジでコードを間違えられる回数
+	loginChallengeMaxAttempts = 5
+	// ユーザーごとに2段階目のコードを試せる回数
+	twoFactorAttemptLimit  = 10
+	twoFactorAttemptWindow = 15 * time.Minute
+	defaultTOTPIssuer      = "go_echo_todo"
+)
+
+var ErrInvalidCode = errors.New("the code is incorrect")
+
+type ITwoFactorUseCase interface {
+	// 2段階認証の状態を取得する
+	GetStatus(userId uint) (model.TwoFactorStatusResponse, error)
+	// パスワードを確認して秘密鍵を作り、認証アプリに登録するためのURIを返す
+	Setup(userId uint, request model.TwoFactorSetupRequest) (model.TwoFactorSetupResponse, error)
+	// 認証アプリのコードを確認して2段階認証を有効にし、リカバリーコードを返す
+	Enable(userId uint, request model.TwoFactorCodeRequest) (model.RecoveryCodesResponse, error)
+	// パスワードとコードを確認して2段階認証を無効にする
+	Disable(userId uint, request model.TwoFactorReauthRequest) error
+	// パスワードとコードを確認してリカバリーコードを作り直す
+	RegenerateRecoveryCodes(userId uint, request model.TwoFactorReauthRequest) (model.RecoveryCodesResponse, error)
+	// 2段階認証が有効か確認する
+	IsEnabled(userId uint) (bool, error)
+	// パスワードを確認したユーザーのログインのチャレンジを作成する
+	CreateChallenge(userId uint) (string, error)
+	// チャレンジとコードを確認してセッションを作成する
+	CompleteLogin(request model.TwoFactorLoginRequest, client model.SessionClient) (model.AuthTokens, error)
+	// 期限の過ぎたチャレンジを削除する
+	PurgeExpired(now time.Time) error
+}
+
+type twoFactorUseCase struct {
+	tfr repository.ITwoFactorRepository
+	ur  repository.IUserRepository
+	rlr repository.IRateLimitRepository
+	su  ISessionUseCase
+}
+
+func NewTwoFactorUseCase(tfr repository.ITwoFactorRepository, ur repository.IUserRepository, rlr repository.IRateLimitRepository, su ISessionUseCase) ITwoFactorUseCase {
+	return &twoFactorUseCase{tfr, ur, rlr, su}
+}
+
+func (tfu *twoFactorUseCase) GetStatus(userId uint) (model.TwoFactorStatusResponse, error) {
+	twoFactor := model.TwoFactor{}
+	if err := tfu.tfr.GetTwoFactor(&twoFactor, userId); err != nil {
+		return model.TwoFactorStatusResponse{}, err
+	}
+	if twoFactor.EnabledAt == nil {
+		return model.TwoFactorStatusResponse{}, nil
+	}
+	remaining, err := tfu.tfr.CountRecoveryCodes(userId)
+	if err != nil {
+		return model.TwoFactorStatusResponse{}, err
+	}
+
+	return model.TwoFactorStatusResponse{
+		Enabled: true,
+		EnabledAt: twoFactor.EnabledAt,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+func (tfu *twoFactorUseCase) Setup(userId uint, request model.TwoFactorSetupRequest) (model.TwoFactorSetupResponse, error) {
+	user, err := checkPassword(tfu.ur, tfu.rlr, userId, request.CurrentPassword)
+	if err != nil {
+		return model.TwoFactorSetupResponse{}, err
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return model.TwoFactorSetupResponse{}, err
+	}
+	twoFactor := model.TwoFactor{UserId: userId, Secret: secret}
+	if err := tfu.tfr.SavePendingSecret(&twoFactor); err != nil {
+		return model.TwoFactorSetupResponse{}, err
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = defaultTOTPIssuer
+	}
+	return model.TwoFactorSetupResponse{
+		Secret: secret,
+		ProvisioningURI: totpProvisioningURI(issuer, user.Email, secret),
+	}, nil
+}
+
+func (tfu *twoFactorUseCase) Enable(userId uint, request model.TwoFactorCodeRequest) (model.RecoveryCodesResponse, error) {
+	twoFactor := model.TwoFactor{}
+	if err := tfu.tfr.GetTwoFactor(&twoFactor, userId); err != nil {
+		return model.RecoveryCodesResponse{}, err
+	}
+	if twoFactor.ID == 0 {
+		return model.RecoveryCodesResponse{}, fmt.Errorf("two-factor authentication has not been set up")
+	}
+	if twoFactor.EnabledAt != nil {
+		return model.RecoveryCodesResponse{}, fmt.Errorf("two-factor authentication is already enabled")
+	}
+	if err := tfu.checkAttempts(userId); err != nil {
+		return model.RecoveryCodesResponse{}, err
+	}
+	step, ok := verifyTOTP(twoFactor.Secret, request.Code, time.Now(), twoFactor.LastUsedStep)
+	if !ok {
+		return model.RecoveryCodesResponse{}, ErrInvalidCode
+	}
+
+	codes, recoveryCodes, err := generateRecoveryCodes(userId)
+	if err != nil {
+		return model.RecoveryCodesResponse{}, err
+	}
+	if err := tfu.tfr.EnableTwoFactor(userId, step, recoveryCodes); err != nil {
+		return model.RecoveryCodesResponse{}, err
+	}
+
+	return model.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (tfu *twoFactorUseCase) Disable(userId uint, request model.TwoFactorReauthRequest) error {
+	if err := tfu.reauthenticate(userId, request); err != nil {
+		return err
+	}
+	if err := tfu.tfr.DeleteTwoFactor(userId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tfu *twoFactorUseCase) RegenerateRecoveryCodes(userId uint, request model.TwoFactorReauthRequest) (model.RecoveryCodesResponse, error) {
+	if err := tfu.reauthenticate(userId, request); err != nil {
+		return model.RecoveryCodesResponse{}, err
+	}
+	codes, recoveryCodes, err := generateRecoveryCodes(userId)
+	if err != nil {
+		return model.RecoveryCodesResponse{}, err
+	}
+	if err := tfu.tfr.ReplaceRecoveryCodes(userId, recoveryCodes); err != nil {
+		return model.RecoveryCodesResponse{}, err
+	}
+
+	return model.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (tfu *twoFactorUseCase) IsEnabled(userId uint) (bool, error) {
+	status, err := tfu.GetStatus(userId)
+	if err != nil {
+		return false, err
+	}
+
+	return status.Enabled, nil
+}
+
+func (tfu *twoFactorUseCase) CreateChallenge(userId uint) (string, error) {
+	now := time.Now()
+	token, err := generateSecretToken()
+	if err != nil {
+		return "", err
+	}
+	challenge := model.LoginChallenge{
+		UserId: userId,
+		TokenHash: hashSecretToken(token),
+		ExpiresAt: now.Add(loginChallengeTTL),
+		CreatedAt: now,
+	}
+	if err := tfu.tfr.CreateLoginChallenge(&challenge); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (tfu *twoFactorUseCase) CompleteLogin(request model.TwoFactorLoginRequest, client model.SessionClient) (model.AuthTokens, error) {
+	now := time.Now()
+	challenge := model.LoginChallenge{}
+	if err := tfu.tfr.GetLoginChallengeByHash(&challenge, hashSecretToken(request.ChallengeToken)); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("the login challenge is invalid or has expired")
+	}
+	if challenge.UsedAt != nil || !now.Before(challenge.ExpiresAt) || challenge.Attempts >= loginChallengeMaxAttempts {
+		return model.AuthTokens{}, fmt.Errorf("the login challenge is invalid or has expired")
+	}
+	if err := tfu.verifyCode(challenge.UserId, request.Code); err != nil {
+		if errors.Is(err, ErrInvalidCode) {
+			if err := tfu.tfr.IncrementChallengeAttempts(challenge.ID); err != nil {
+				return model.AuthTokens{}, err
+			}
+		}
+		return model.AuthTokens{}, err
+	}
+	if err := tfu.tfr.UseLoginChallenge(challenge.ID, now); err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	return tfu.su.CreateSession(challenge.UserId, client)
+}
+
+func (tfu *twoFactorUseCase) PurgeExpired(now time.Time) error {
+	return tfu.tfr.DeleteExpiredLoginChallenges(now)
+}
+
+// パスワードと2段階目のコードの両方で本人確認する
+func (tfu *twoFactorUseCase) reauthenticate(userId uint, request model.TwoFactorReauthRequest) error {
+	if _, err := checkPassword(tfu.ur, tfu.rlr, userId, request.CurrentPassword); err != nil {
+		return err
+	}
+
+	return tfu.verifyCode(userId, request.Code)
+}
+
+// 認証アプリのコードかリカバリーコードを確認し、使用済みにする
+func (tfu *twoFactorUseCase) verifyCode(userId uint, code string) error {
+	twoFactor := model.TwoFactor{}
+	if err := tfu.tfr.GetTwoFactor(&twoFactor, userId); err != nil {
+		return err
+	}
+	if twoFactor.EnabledAt == nil {
+		return fmt.Errorf("two-factor authentication is not enabled")
+	}
+	if err := tfu.checkAttempts(userId); err != nil {
+		return err
+	}
+
+	if step, ok := verifyTOTP(twoFactor.Secret, code, time.Now(), twoFactor.LastUsedStep); ok {
+		// 同時に同じコードが使われた場合は先に記録した方だけを通す
+		if err := tfu.tfr.UpdateLastUsedStep(userId, step); err != nil {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+	if err := tfu.tfr.UseRecoveryCode(userId, hashSecretToken(normalizeRecoveryCode(code)), time.Now()); err != nil {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
+
+func (tfu *twoFactorUseCase) checkAttempts(userId uint) error {
+	return checkRateLimits(tfu.rlr, time.Now(),
+		model.RateLimit{Key: fmt.Sprintf("two_factor:user:%d", userId), Limit: twoFactorAttemptLimit, Window: twoFactorAttemptWindow},
+	)
+}
+
+// 80ビットのリカバリーコードを xxxx-xxxx-xxxx-xxxx の形で作り、ハッシュを保存用に返す
+func generateRecoveryCodes(userId uint) ([]string, []model.RecoveryCode, error) {
+	codes := make([]string, recoveryCodeCount)
+	recoveryCodes := make([]model.RecoveryCode, recoveryCodeCount)
+	now := time.Now()
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+		recoveryCodes[i] = model.RecoveryCode{UserId: userId, CodeHash: hashSecretToken(code), CreatedAt: now}
+	}
+	return codes, recoveryCodes, nil
+}
+
+// 区切りや大文字小文字の違いを無視する
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -5,17 +5,17 @@ import (
 	"go-rest-api/model"
 	"go-rest-api/repository"
 	"go-rest-api/validator"
-	"os"
-	"time"
+	"log"
 
 	"golang.org/x/crypto/bcrypt"
-
-	"github.com/golang-jwt/jwt/v4"
 )
 
 type IUserUseCase interface {
 	SignUp(user model.User) (model.UserResponse, error)
-	Login(user model.User) (string, error)
+	// ログインしてセッションを作成する。2段階認証が有効な場合はチャレンジを返す
+	Login(user model.User, client model.SessionClient) (model.LoginResult, error)
+	// アクセストークンを無効にし、リフレッシュトークンのセッションを終了する
+	LogOut(accessToken string, refreshToken string) error
 	// ログインしているユーザーの情報を取得
 	GetLoggedInUserDetails(user model.User, userId uint) (model.UserResponse, error)
 	// ユーザーの名前を更新する
@@ -34,10 +34,14 @@ type userUseCase struct {
 	ur repository.IUserRepository
 	uv validator.IUserValidator
 	tmr repository.ITeamMemberRepository
+	nu INotificationUseCase
+	su ISessionUseCase
+	vu IVerificationUseCase
+	tfu ITwoFactorUseCase
 }
 
-func NewUserUseCase(ur repository.IUserRepository, uv validator.IUserValidator, tmr repository.ITeamMemberRepository) IUserUseCase {
-	return &userUseCase{ur, uv, tmr}
+func NewUserUseCase(ur repository.IUserRepository, uv validator.IUserValidator, tmr repository.ITeamMemberRepository, nu INotificationUseCase, su ISessionUseCase, vu IVerificationUseCase, tfu ITwoFactorUseCase) IUserUseCase {
+	return &userUseCase{ur, uv, tmr, nu, su, vu, tfu}
 }
 
 func (uu *userUseCase) SignUp(user model.User) (model.UserResponse, error) {
@@ -52,38 +56,57 @@ func (uu *userUseCase) SignUp(user model.User) (model.UserResponse, error) {
 	if err := uu.ur.CreateUser(&newUser); err != nil {
 		return model.UserResponse{}, err
 	}
+	// 確認メールが送れなくても登録は完了させ、後から再送できるようにする
+	if err := uu.vu.SendVerification(newUser, newUser.Email); err != nil {
+		log.Printf("failed to send verification email to user %d: %v", newUser.ID, err)
+	}
+	verified := false
 	resUser := model.UserResponse{
 		ID: newUser.ID,
 		Email: newUser.Email,
 		Name: newUser.Name,
+		EmailVerified: &verified,
 	}
 
 	return resUser, err
 }
 
 
-func (uu *userUseCase) Login(user model.User) (string, error) {
+func (uu *userUseCase) Login(user model.User, client model.SessionClient) (model.LoginResult, error) {
 	if err := uu.uv.UserValidator(user); err != nil {
-		return "", err
+		return model.LoginResult{}, err
 	}
 	storedUser := model.User{}
 	if err := uu.ur.GetUserByEmail(&storedUser, user.Email); err != nil {
-		return "", err
+		return model.LoginResult{}, err
 	}
 	err := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(user.Password))
 	if err != nil {
-		return "", err
+		return model.LoginResult{}, err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": storedUser.ID,
-		"exp": time.Now().Add(time.Hour * 12).Unix(),
-	})
-	tokenString, err := token.SignedString([]byte(os.Getenv("SECRET")))
+
+	enabled, err := uu.tfu.IsEnabled(storedUser.ID)
 	if err != nil {
-		return "", err
+		return model.LoginResult{}, err
+	}
+	if enabled {
+		challengeToken, err := uu.tfu.CreateChallenge(storedUser.ID)
+		if err != nil {
+			return model.LoginResult{}, err
+		}
+		return model.LoginResult{ChallengeToken: challengeToken}, nil
+	}
+	tokens, err := uu.su.CreateSession(storedUser.ID, client)
+	if err != nil {
+		return model.LoginResult{}, err
 	}
 
-	return tokenString, nil
+	return model.LoginResult{Tokens: tokens}, nil
+}
+
+
+func (uu *userUseCase) LogOut(accessToken string, refreshToken string) error {
+	return uu.su.EndSession(accessToken, refreshToken)
 }
 
 
@@ -92,10 +115,12 @@ func (uu *userUseCase) GetLoggedInUserDetails(user model.User, userId uint) (mod
 		return model.UserResponse{}, nil
 	}
 
+	verified := user.EmailVerifiedAt != nil
 	resUser := model.UserResponse {
 		ID: user.ID,
 		Email: user.Email,
 		Name: user.Name,
+		EmailVerified: &verified,
 	}
 
 	return resUser, nil
@@ -117,7 +142,11 @@ func (uu *userUseCase) UpdateUserName(user model.User, userId uint) (model.UserR
 }
 
 
+// メールアドレスを確認していないユーザーは組織に加入できない
 func (uu *userUseCase) AssignUserToOrganization(user model.User, userId uint) (model.UserAssignResponse, error) {
+	if err := uu.vu.RequireVerified(userId); err != nil {
+		return model.UserAssignResponse{}, err
+	}
 	if err := uu.ur.AssignUserToOrganization(&user, userId); err != nil {
 		return model.UserAssignResponse{}, err
 	}
@@ -130,7 +159,11 @@ func (uu *userUseCase) AssignUserToOrganization(user model.User, userId uint) (m
 }
 
 
+// メールアドレスを確認していないユーザーはチームに加入できない
 func (uu *userUseCase) AssignUserToTeam(teamMember model.TeamMember, userId uint) (model.TeamMemberReponse, error) {
+	if err := uu.vu.RequireVerified(userId); err != nil {
+		return model.TeamMemberReponse{}, err
+	}
 	teamMembers := make([]model.TeamMember, 0)
 	uu.tmr.GetTeamMembersByTeamId(&teamMembers, userId)
 	for _, v := range teamMembers {
@@ -143,6 +176,21 @@ func (uu *userUseCase) AssignUserToTeam(teamMember model.TeamMember, userId uint
 	if err := uu.tmr.AssignToTeam(&teamMember); err != nil {
 		return model.TeamMemberReponse{}, err
 	}
+	// 参加したユーザーと、既に参加しているメンバーに通知する
+	uu.nu.Notify([]uint{userId}, model.Notification{
+		Type: model.NotificationTypeTeamJoined,
+		TeamId: teamMember.TeamID,
+		Message: "You joined the team",
+	})
+	memberIds := make([]uint, 0)
+	if err := uu.tmr.GetActiveTeamMemberIds(&memberIds, teamMember.TeamID); err == nil {
+		uu.nu.Notify(memberIds, model.Notification{
+			Type: model.NotificationTypeTeamJoined,
+			TeamId: teamMember.TeamID,
+			ActorId: userId,
+			Message: "A new member joined the team",
+		})
+	}
 
 	resTeamMember := model.TeamMemberReponse {
 		TeamID: teamMember.TeamID,
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+	"log"
+	"time"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+	// 確認メールを再送できる回数
+	emailVerificationResendLimit  = 3
+	emailVerificationResendWindow = time.Hour
+)
+
+var ErrEmailNotVerified = errors.New("the email address has not been verified")
+
+type IVerificationUseCase interface {
+	// email を確認するためのメールを送る。確認するとユーザーのメールアドレスになる
+	SendVerification(user model.User, email string) error
+	// トークンでメールアドレスを確認する
+	VerifyEmail(request model.VerifyEmailRequest) error
+	// ログインしているユーザーに確認メールを送り直す
+	ResendVerification(userId uint) error
+	// 現在のパスワードを確認し、新しいメールアドレスに確認メールを、今のメールアドレスに通知を送る
+	ChangeEmail(userId uint, request model.ChangeEmailRequest) error
+	// メールアドレスを確認済みか確認し、未確認なら ErrEmailNotVerified を返す
+	RequireVerified(userId uint) error
+	// 期限の過ぎたトークンを削除する
+	PurgeExpired(now time.Time) error
+}
+
+type verificationUseCase struct {
+	ur  repository.IUserRepository
+	vr  repository.IVerificationRepository
+	rlr repository.IRateLimitRepository
+	nu  INotificationUseCase
+	eu  IEmailUseCase
+	uv  validator.IUserValidator
+}
+
+func NewVerificationUseCase(ur repository.IUserRepository, vr repository.IVerificationRepository, rlr repository.IRateLimitRepository, nu INotificationUseCase, eu IEmailUseCase, uv validator.IUserValidator) IVerificationUseCase {
+	return &verificationUseCase{ur, vr, rlr, nu, eu, uv}
+}
+
+// メールアドレスの変更を今のメールアドレスに知らせるメールのテンプレートに渡す値
+type emailChangeEmail struct {
+	Name     string
+	NewEmail string
+}
+
+func (vu *verificationUseCase) SendVerification(user model.User, email string) error {
+	now := time.Now()
+	token, err := generateSecretToken()
+	if err != nil {
+		return err
+	}
+	verificationToken := model.EmailVerificationToken{
+		UserId: user.ID,
+		Email: email,
+		TokenHash: hashSecretToken(token),
+		ExpiresAt: now.Add(emailVerificationTTL),
+		CreatedAt: now,
+	}
+	if err := vu.vr.CreateVerificationToken(&verificationToken); err != nil {
+		return err
+	}
+	sendAccountEmail(vu.nu, vu.eu, user, email, "verify_email", "/verify-email", token, emailVerificationTTL)
+
+	return nil
+}
+
+func (vu *verificationUseCase) VerifyEmail(request model.VerifyEmailRequest) error {
+	if err := vu.vr.VerifyEmail(&model.EmailVerificationToken{}, hashSecretToken(request.Token), time.Now()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (vu *verificationUseCase) ResendVerification(userId uint) error {
+	user := model.User{}
+	if err := vu.ur.GetLoggedInUserDetails(&user, userId); err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return fmt.Errorf("the email address has already been verified")
+	}
+	if err := checkRateLimits(vu.rlr, time.Now(),
+		model.RateLimit{Key: fmt.Sprintf("email_verification:user:%d", userId), Limit: emailVerificationResendLimit, Window: emailVerificationResendWindow},
+	); err != nil {
+		return err
+	}
+
+	return vu.SendVerification(user, user.Email)
+}
+
+func (vu *verificationUseCase) ChangeEmail(userId uint, request model.ChangeEmailRequest) error {
+	if err := vu.uv.EmailValidate(request.Email); err != nil {
+		return err
+	}
+	user, err := checkPassword(vu.ur, vu.rlr, userId, request.CurrentPassword)
+	if err != nil {
+		return err
+	}
+	if request.Email == user.Email {
+		return fmt.Errorf("the email address is the same as the current one")
+	}
+	if err := vu.ur.GetUserByEmail(&model.User{}, request.Email); err == nil {
+		return fmt.Errorf("the email address is already in use")
+	}
+
+	if err := vu.SendVerification(user, request.Email); err != nil {
+		return err
+	}
+	setting, err := vu.nu.GetEmailSetting(user.ID)
+	if err != nil {
+		log.Printf("failed to get email setting of user %d: %v", user.ID, err)
+	}
+	if err := vu.eu.Enqueue(user.Email, setting.Language, "email_change_requested", emailChangeEmail{Name: user.Name, NewEmail: request.Email}); err != nil {
+		log.Printf("failed to enqueue email_change_requested email: %v", err)
+	}
+
+	return nil
+}
+
+func (vu *verificationUseCase) RequireVerified(userId uint) error {
+	user := model.User{}
+	if err := vu.ur.GetLoggedInUserDetails(&user, userId); err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		return ErrEmailNotVerified
+	}
+
+	return nil
+}
+
+func (vu *verificationUseCase) PurgeExpired(now time.Time) error {
+	return vu.vr.DeleteExpiredVerificationTokens(now)
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"log"
+)
+
+type IWatcherUseCase interface {
+	// タスクをフォローしているユーザーの一覧を取得する
+	GetWatchers(userId uint, taskId uint) (model.TaskWatchResponse, error)
+	// タスクをフォローする
+	Watch(userId uint, taskId uint) (model.TaskWatchResponse, error)
+	// タスクのフォローをやめる
+	Unwatch(userId uint, taskId uint) (model.TaskWatchResponse, error)
+	// 作成者・担当者・コメントした人を自動でフォローさせる。失敗しても呼び出し元の操作は失敗させない
+	AutoWatch(taskId uint, userIds ...uint)
+	// 通知先になる、チームに参加中のフォローしているユーザーのIDを取得する
+	GetWatcherIds(taskId uint) ([]uint, error)
+}
+
+type watcherUseCase struct {
+	wr repository.IWatcherRepository
+	tr repository.ITaskRepository
+}
+
+func NewWatcherUseCase(wr repository.IWatcherRepository, tr repository.ITaskRepository) IWatcherUseCase {
+	return &watcherUseCase{wr, tr}
+}
+
+func (wu *watcherUseCase) GetWatchers(userId uint, taskId uint) (model.TaskWatchResponse, error) {
+	if err := wu.tr.GetMemberTaskById(&model.Task{}, userId, taskId); err != nil {
+		return model.TaskWatchResponse{}, err
+	}
+
+	return wu.watchResponse(userId, taskId)
+}
+
+func (wu *watcherUseCase) Watch(userId uint, taskId uint) (model.TaskWatchResponse, error) {
+	if err := wu.tr.GetMemberTaskById(&model.Task{}, userId, taskId); err != nil {
+		return model.TaskWatchResponse{}, err
+	}
+	watchers := []model.TaskWatcher{{TaskId: taskId, UserId: userId}}
+	if err := wu.wr.AddWatchers(&watchers); err != nil {
+		return model.TaskWatchResponse{}, err
+	}
+
+	return wu.watchResponse(userId, taskId)
+}
+
+func (wu *watcherUseCase) Unwatch(userId uint, taskId uint) (model.TaskWatchResponse, error) {
+	if err := wu.tr.GetMemberTaskById(&model.Task{}, userId, taskId); err != nil {
+		return model.TaskWatchResponse{}, err
+	}
+	if err := wu.wr.RemoveWatcher(taskId, userId); err != nil {
+		return model.TaskWatchResponse{}, err
+	}
+
+	return wu.watchResponse(userId, taskId)
+}
+
+func (wu *watcherUseCase) AutoWatch(taskId uint, userIds ...uint) {
+	watchers := make([]model.TaskWatcher, 0, len(userIds))
+	for _, v := range userIds {
+		if v != 0 {
+			watchers = append(watchers, model.TaskWatcher{TaskId: taskId, UserId: v})
+		}
+	}
+	if len(watchers) == 0 {
+		return
+	}
+	if err := wu.wr.AddWatchers(&watchers); err != nil {
+		log.Printf("failed to add watchers to task %d: %v", taskId, err)
+	}
+}
+
+func (wu *watcherUseCase) GetWatcherIds(taskId uint) ([]uint, error) {
+	userIds := make([]uint, 0)
+	if err := wu.wr.GetWatcherIds(&userIds, taskId); err != nil {
+		return nil, err
+	}
+
+	return userIds, nil
+}
+
+func (wu *watcherUseCase) watchResponse(userId uint, taskId uint) (model.TaskWatchResponse, error) {
+	users := make([]model.User, 0)
+	if err := wu.wr.GetWatchers(&users, taskId); err != nil {
+		return model.TaskWatchResponse{}, err
+	}
+	resWatch := model.TaskWatchResponse{TaskId: taskId, Watchers: make([]model.UserResponse, len(users))}
+	for i, v := range users {
+		resWatch.Watchers[i] = model.UserResponse{ID: v.ID, Email: v.Email, Name: v.Name}
+		if v.ID == userId {
+			resWatch.Watching = true
+		}
+	}
+
+	return resWatch, nil
+}
@@ -0,0 +1,502 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-rest-api/model"
+	"go-rest-api/repository"
+	"go-rest-api/validator"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// 最初の送信を含めた最大試行回数
+	maxWebhookAttempts = 8
+	// 再送の間隔は 30秒, 1分, 2分, ... と倍にしていき、最大6時間
+	webhookRetryBase = 30 * time.Second
+	webhookRetryMax  = 6 * time.Hour
+	// 連続してこの回数失敗したWebhookは無効にする
+	maxWebhookFailures = 20
+	webhookTimeout     = 10 * time.Second
+	// 送信中の配信を他のインスタンスが取得しないようにする時間
+	webhookClaimLease = 2 * time.Minute
+	webhookBatchSize  = 20
+	// 配信履歴に残すレスポンス本文の最大バイト数
+	maxWebhookResponseBody = 1024
+
+	defaultDeliveriesPerPage = 20
+	maxDeliveriesPerPage     = 100
+)
+
+type IWebhookUseCase interface {
+	IEventPublisher
+	// Webhookを登録する。組織単位は組織の作成者、チーム単位はチームのメンバーが登録できる
+	CreateWebhook(userId uint, webhook model.Webhook) (model.WebhookResponse, error)
+	// 組織のWebhook一覧を取得する(組織の作成者のみ)
+	GetOrganizationWebhooks(userId uint, organizationId uint) ([]model.WebhookResponse, error)
+	// チーム単位のWebhook一覧を取得する
+	GetTeamWebhooks(userId uint, teamId uint) ([]model.WebhookResponse, error)
+	// URL・イベント・有効状態を更新する。有効に戻すと失敗回数をリセットする
+	UpdateWebhook(userId uint, webhookId uint, webhook model.Webhook) (model.WebhookResponse, error)
+	// Webhookを削除する
+	DeleteWebhook(userId uint, webhookId uint) error
+	// 配信履歴を取得する
+	GetDeliveries(userId uint, webhookId uint, page int, perPage int) ([]model.WebhookDeliveryResponse, error)
+	// 過去の配信と同じ内容をもう一度送る
+	Redeliver(userId uint, webhookId uint, deliveryId uint) (model.WebhookDeliveryResponse, error)
+	// 送信時刻になった配信を送る
+	ProcessDeliveries(now time.Time) error
+}
+
+type webhookUseCase struct {
+	wr     repository.IWebhookRepository
+	or     repository.IOrganizationRepository
+	ter    repository.ITeamRepository
+	tmr    repository.ITeamMemberRepository
+	wv     validator.IWebhookValidator
+	client *http.Client
+}
+
+func NewWebhookUseCase(wr repository.IWebhookRepository, or repository.IOrganizationRepository, ter repository.ITeamRepository, tmr repository.ITeamMemberRepository, wv validator.IWebhookValidator) IWebhookUseCase {
+	return &webhookUseCase{wr, or, ter, tmr, wv, newWebhookClient()}
+}
+
+// 送信先が内部のネットワークを指していないか、名前解決した後の接続先アドレスで確認するクライアント。
+// リダイレクト先は確認できないため追わず、3xxはそのまま失敗として記録する
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: webhookDialControl}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			// 環境変数のプロキシを使うと接続先の確認がプロキシに対してになるため使わない
+			Proxy: nil,
+			DialContext: dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConnsPerHost: 2,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func webhookDialControl(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !validator.IsPublicIP(ip) {
+		return fmt.Errorf("webhook url resolves to a non-public address %s", host)
+	}
+	return nil
+}
+
+func (wu *webhookUseCase) Publish(event model.Event) {
+	if err := wu.enqueue(event); err != nil {
+		log.Printf("failed to enqueue %s webhook: %v", event.Type, err)
+		return
+	}
+	go func() {
+		if err := wu.ProcessDeliveries(time.Now()); err != nil {
+			log.Printf("failed to deliver webhooks: %v", err)
+		}
+	}()
+}
+
+func (wu *webhookUseCase) enqueue(event model.Event) error {
+	if event.TeamId == 0 {
+		return nil
+	}
+	webhooks := make([]model.Webhook, 0)
+	if err := wu.wr.GetActiveWebhooksForTeam(&webhooks, event.TeamId); err != nil {
+		return err
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]model.WebhookDelivery, 0)
+	for _, v := range webhooks {
+		if !subscribes(v, event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			WebhookId: v.ID,
+			Event: event.Type,
+			Payload: string(payload),
+			Status: model.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return wu.wr.CreateDeliveries(&deliveries)
+}
+
+func (wu *webhookUseCase) CreateWebhook(userId uint, webhook model.Webhook) (model.WebhookResponse, error) {
+	if err := wu.wv.WebhookValidate(webhook); err != nil {
+		return model.WebhookResponse{}, err
+	}
+	if webhook.TeamId != 0 {
+		team := model.Team{}
+		if err := wu.ter.GetTeamById(&team, webhook.TeamId); err != nil {
+			return model.WebhookResponse{}, err
+		}
+		webhook.OrganizationId = team.OrganizationId
+	}
+	if err := wu.authorize(userId, webhook); err != nil {
+		return model.WebhookResponse{}, err
+	}
+
+	// 署名に使うため平文で保存し、登録時の応答でのみ返す
+	secret, err := generateSecretToken()
+	if err != nil {
+		return model.WebhookResponse{}, err
+	}
+	newWebhook := model.Webhook{
+		OrganizationId: webhook.OrganizationId,
+		TeamId: webhook.TeamId,
+		URL: webhook.URL,
+		Secret: secret,
+		Events: webhook.Events,
+		Active: true,
+		CreatedBy: userId,
+	}
+	if err := wu.wr.CreateWebhook(&newWebhook); err != nil {
+		return model.WebhookResponse{}, err
+	}
+
+	resWebhook := toWebhookResponse(newWebhook)
+	resWebhook.Secret = secret
+	return resWebhook, nil
+}
+
+func (wu *webhookUseCase) GetOrganizationWebhooks(userId uint, organizationId uint) ([]model.WebhookResponse, error) {
+	if err := wu.authorize(userId, model.Webhook{OrganizationId: organizationId}); err != nil {
+		return nil, err
+	}
+	webhooks := make([]model.Webhook, 0)
+	if err := wu.wr.GetWebhooksByOrganizationId(&webhooks, organizationId); err != nil {
+		return nil, err
+	}
+
+	return toWebhookResponses(webhooks), nil
+}
+
+func (wu *webhookUseCase) GetTeamWebhooks(userId uint, teamId uint) ([]model.WebhookResponse, error) {
+	if err := wu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, teamId); err != nil {
+		return nil, fmt.Errorf("the user is not a member of the team")
+	}
+	webhooks := make([]model.Webhook, 0)
+	if err := wu.wr.GetWebhooksByTeamId(&webhooks, teamId); err != nil {
+		return nil, err
+	}
+
+	return toWebhookResponses(webhooks), nil
+}
+
+func (wu *webhookUseCase) UpdateWebhook(userId uint, webhookId uint, webhook model.Webhook) (model.WebhookResponse, error) {
+	if err := wu.wv.WebhookValidate(webhook); err != nil {
+		return model.WebhookResponse{}, err
+	}
+	storedWebhook, err := wu.getManagedWebhook(userId, webhookId)
+	if err != nil {
+		return model.WebhookResponse{}, err
+	}
+
+	updatedWebhook := model.Webhook{
+		URL: webhook.URL,
+		Events: webhook.Events,
+		Active: webhook.Active,
+		FailureCount: storedWebhook.FailureCount,
+		DisabledAt: storedWebhook.DisabledAt,
+	}
+	if webhook.Active && !storedWebhook.Active {
+		updatedWebhook.FailureCount = 0
+		updatedWebhook.DisabledAt = nil
+	}
+	if err := wu.wr.UpdateWebhook(&updatedWebhook, webhookId); err != nil {
+		return model.WebhookResponse{}, err
+	}
+
+	return toWebhookResponse(updatedWebhook), nil
+}
+
+func (wu *webhookUseCase) DeleteWebhook(userId uint, webhookId uint) error {
+	if _, err := wu.getManagedWebhook(userId, webhookId); err != nil {
+		return err
+	}
+	if err := wu.wr.DeleteWebhook(webhookId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wu *webhookUseCase) GetDeliveries(userId uint, webhookId uint, page int, perPage int) ([]model.WebhookDeliveryResponse, error) {
+	if _, err := wu.getManagedWebhook(userId, webhookId); err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = defaultDeliveriesPerPage
+	}
+	if perPage > maxDeliveriesPerPage {
+		perPage = maxDeliveriesPerPage
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	if err := wu.wr.GetDeliveriesByWebhookId(&deliveries, webhookId, (page-1)*perPage, perPage); err != nil {
+		return nil, err
+	}
+	resDeliveries := make([]model.WebhookDeliveryResponse, len(deliveries))
+	for i, v := range deliveries {
+		resDeliveries[i] = toWebhookDeliveryResponse(v)
+	}
+
+	return resDeliveries, nil
+}
+
+func (wu *webhookUseCase) Redeliver(userId uint, webhookId uint, deliveryId uint) (model.WebhookDeliveryResponse, error) {
+	webhook, err := wu.getManagedWebhook(userId, webhookId)
+	if err != nil {
+		return model.WebhookDeliveryResponse{}, err
+	}
+	if !webhook.Active {
+		return model.WebhookDeliveryResponse{}, fmt.Errorf("the webhook is disabled, enable it before redelivering")
+	}
+	delivery := model.WebhookDelivery{}
+	if err := wu.wr.GetDeliveryById(&delivery, webhookId, deliveryId); err != nil {
+		return model.WebhookDeliveryResponse{}, err
+	}
+
+	now := time.Now()
+	deliveries := []model.WebhookDelivery{{
+		WebhookId: webhookId,
+		Event: delivery.Event,
+		Payload: delivery.Payload,
+		Status: model.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}}
+	if err := wu.wr.CreateDeliveries(&deliveries); err != nil {
+		return model.WebhookDeliveryResponse{}, err
+	}
+	go func() {
+		if err := wu.ProcessDeliveries(time.Now()); err != nil {
+			log.Printf("failed to deliver webhooks: %v", err)
+		}
+	}()
+
+	return toWebhookDeliveryResponse(deliveries[0]), nil
+}
+
+func (wu *webhookUseCase) ProcessDeliveries(now time.Time) error {
+	deliveries := make([]model.WebhookDelivery, 0)
+	if err := wu.wr.ClaimDueDeliveries(&deliveries, now, webhookClaimLease, webhookBatchSize); err != nil {
+		return err
+	}
+
+	webhooks := map[uint]model.Webhook{}
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookId]
+		if !ok {
+			if err := wu.wr.GetWebhookById(&webhook, delivery.WebhookId); err != nil {
+				return err
+			}
+			webhooks[delivery.WebhookId] = webhook
+		}
+		if !webhook.Active {
+			delivery.Status = model.WebhookDeliveryFailed
+			delivery.NextAttemptAt = nil
+			delivery.Error = "the webhook is disabled"
+			if err := wu.wr.UpdateDelivery(&delivery); err != nil {
+				return err
+			}
+			continue
+		}
+
+		succeeded := wu.send(webhook, &delivery)
+		if err := wu.wr.UpdateDelivery(&delivery); err != nil {
+			return err
+		}
+		if err := wu.wr.RecordWebhookResult(webhook.ID, succeeded, maxWebhookFailures); err != nil {
+			return err
+		}
+		if !succeeded {
+			// 無効になったかどうかを次の配信で確認できるよう読み直す
+			delete(webhooks, webhook.ID)
+		}
+	}
+
+	return nil
+}
+
+// 配信を1回送り、結果をdeliveryに書き込む
+func (wu *webhookUseCase) send(webhook model.Webhook, delivery *model.WebhookDelivery) bool {
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+
+	err := wu.post(webhook, delivery)
+	now := time.Now()
+	if err == nil {
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+		return true
+	}
+
+	delivery.Error = err.Error()
+	if delivery.Attempts >= maxWebhookAttempts {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		return false
+	}
+	next := now.Add(webhookRetryDelay(delivery.Attempts))
+	delivery.NextAttemptAt = &next
+	return false
+}
+
+func (wu *webhookUseCase) post(webhook model.Webhook, delivery *model.WebhookDelivery) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go_echo_todo-webhook")
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	res, err := wu.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxWebhookResponseBody))
+	delivery.ResponseStatus = res.StatusCode
+	delivery.ResponseBody = string(body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// 受信側は "{timestamp}.{body}" をシークレットでHMAC-SHA256して X-Webhook-Signature と比較する
+func signWebhookPayload(secret string, timestamp string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= webhookRetryMax {
+			return webhookRetryMax
+		}
+	}
+	return delay
+}
+
+func subscribes(webhook model.Webhook, eventType model.EventType) bool {
+	for _, v := range webhook.Events {
+		if v == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// 組織の作成者はすべて、チームのメンバーはチーム単位のWebhookを管理できる
+func (wu *webhookUseCase) authorize(userId uint, webhook model.Webhook) error {
+	organization := model.Organization{}
+	if err := wu.or.GetOrganizationById(&organization, webhook.OrganizationId); err != nil {
+		return err
+	}
+	if organization.Founder == userId {
+		return nil
+	}
+	if webhook.TeamId != 0 {
+		if err := wu.tmr.GetActiveTeamMember(&model.TeamMember{}, userId, webhook.TeamId); err == nil {
+			return nil
+		}
+		return fmt.Errorf("the user is not a member of the team")
+	}
+	return fmt.Errorf("only the founder can manage organization webhooks")
+}
+
+func (wu *webhookUseCase) getManagedWebhook(userId uint, webhookId uint) (model.Webhook, error) {
+	webhook := model.Webhook{}
+	if err := wu.wr.GetWebhookById(&webhook, webhookId); err != nil {
+		return model.Webhook{}, err
+	}
+	if err := wu.authorize(userId, webhook); err != nil {
+		return model.Webhook{}, err
+	}
+	return webhook, nil
+}
+
+func toWebhookResponse(webhook model.Webhook) model.WebhookResponse {
+	return model.WebhookResponse{
+		ID: webhook.ID,
+		OrganizationId: webhook.OrganizationId,
+		TeamId: webhook.TeamId,
+		URL: webhook.URL,
+		Events: webhook.Events,
+		Active: webhook.Active,
+		FailureCount: webhook.FailureCount,
+		DisabledAt: webhook.DisabledAt,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+}
+
+func toWebhookResponses(webhooks []model.Webhook) []model.WebhookResponse {
+	resWebhooks := make([]model.WebhookResponse, len(webhooks))
+	for i, v := range webhooks {
+		resWebhooks[i] = toWebhookResponse(v)
+	}
+	return resWebhooks
+}
+
+func toWebhookDeliveryResponse(delivery model.WebhookDelivery) model.WebhookDeliveryResponse {
+	return model.WebhookDeliveryResponse{
+		ID: delivery.ID,
+		WebhookId: delivery.WebhookId,
+		Event: delivery.Event,
+		Payload: delivery.Payload,
+		Status: delivery.Status,
+		Attempts: delivery.Attempts,
+		NextAttemptAt: delivery.NextAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody: delivery.ResponseBody,
+		Error: delivery.Error,
+		DeliveredAt: delivery.DeliveredAt,
+		CreatedAt: delivery.CreatedAt,
+	}
+}
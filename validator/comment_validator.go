@@ -0,0 +1,27 @@
+package validator
+
+import (
+	"go-rest-api/model"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+type ICommentValidator interface {
+	CommentValidate(comment model.TaskComment) error
+}
+
+type commentValidator struct{}
+
+func NewCommentValidator() ICommentValidator {
+	return &commentValidator{}
+}
+
+func (cv *commentValidator) CommentValidate(comment model.TaskComment) error {
+	return validation.ValidateStruct(&comment,
+		validation.Field(
+			&comment.Body,
+			validation.Required.Error("body is required"),
+			validation.RuneLength(1, 65535).Error("limited max 65535 char"),
+		),
+	)
+}
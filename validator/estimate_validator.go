@@ -0,0 +1,43 @@
+package validator
+
+import (
+	"errors"
+	"go-rest-api/model"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+type IEstimateValidator interface {
+	EstimateScaleValidate(request model.EstimateScaleRequest) error
+	TaskEstimateValidate(estimate model.TaskEstimate, scale model.EstimateScale) error
+}
+
+type estimateValidator struct{}
+
+func NewEstimateValidator() IEstimateValidator {
+	return &estimateValidator{}
+}
+
+func (ev *estimateValidator) EstimateScaleValidate(request model.EstimateScaleRequest) error {
+	return validation.ValidateStruct(&request,
+		validation.Field(
+			&request.Scale,
+			validation.Required.Error("scale is required"),
+			validation.In(model.EstimateScaleFibonacci, model.EstimateScaleTShirt).Error("scale must be fibonacci or tshirt"),
+		),
+	)
+}
+
+func (ev *estimateValidator) TaskEstimateValidate(estimate model.TaskEstimate, scale model.EstimateScale) error {
+	return validation.ValidateStruct(&estimate,
+		validation.Field(
+			&estimate.Estimate,
+			validation.By(func(value interface{}) error {
+				if _, ok := scale.Points(estimate.Estimate); estimate.Estimate != "" && !ok {
+					return errors.New("estimate must be one of the options of the team's scale")
+				}
+				return nil
+			}),
+		),
+	)
+}
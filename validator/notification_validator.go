@@ -0,0 +1,47 @@
+package validator
+
+import (
+	"go-rest-api/mailer"
+	"go-rest-api/model"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+type INotificationValidator interface {
+	NotificationPreferenceValidate(preference model.NotificationPreferenceUpdate) error
+	EmailSettingValidate(setting model.EmailSettingResponse) error
+}
+
+type notificationValidator struct{}
+
+func NewNotificationValidator() INotificationValidator {
+	return &notificationValidator{}
+}
+
+func (nv *notificationValidator) NotificationPreferenceValidate(preference model.NotificationPreferenceUpdate) error {
+	types := make([]interface{}, len(model.NotificationTypes))
+	for i, v := range model.NotificationTypes {
+		types[i] = v
+	}
+	return validation.ValidateStruct(&preference,
+		validation.Field(
+			&preference.Type,
+			validation.Required.Error("type is required"),
+			validation.In(types...).Error("unknown notification type"),
+		),
+	)
+}
+
+func (nv *notificationValidator) EmailSettingValidate(setting model.EmailSettingResponse) error {
+	languages := make([]interface{}, len(mailer.Languages))
+	for i, v := range mailer.Languages {
+		languages[i] = v
+	}
+	return validation.ValidateStruct(&setting,
+		validation.Field(
+			&setting.Language,
+			validation.Required.Error("language is required"),
+			validation.In(languages...).Error("unsupported language"),
+		),
+	)
+}
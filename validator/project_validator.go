@@ -0,0 +1,57 @@
+package validator
+
+import (
+	"errors"
+	"go-rest-api/model"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+type IProjectValidator interface {
+	ProjectValidate(project model.Project) error
+	ProjectTasksValidate(request model.ProjectTasksRequest) error
+}
+
+type projectValidator struct{}
+
+func NewProjectValidator() IProjectValidator {
+	return &projectValidator{}
+}
+
+func (pv *projectValidator) ProjectValidate(project model.Project) error {
+	statuses := make([]interface{}, len(model.ProjectStatuses))
+	for i, v := range model.ProjectStatuses {
+		statuses[i] = v
+	}
+	return validation.ValidateStruct(&project,
+		validation.Field(
+			&project.Name,
+			validation.Required.Error("name is required"),
+			validation.RuneLength(1, 50).Error("limited max 50 char"),
+		),
+		validation.Field(
+			&project.Status,
+			validation.In(statuses...).Error("status must be one of planned, active, on_hold, completed or cancelled"),
+		),
+		validation.Field(
+			&project.EndDate,
+			validation.By(func(value interface{}) error {
+				if project.StartDate != nil && project.EndDate != nil && project.EndDate.Before(*project.StartDate) {
+					return errors.New("end_date must not be before start_date")
+				}
+				return nil
+			}),
+		),
+	)
+}
+
+func (pv *projectValidator) ProjectTasksValidate(request model.ProjectTasksRequest) error {
+	return validation.ValidateStruct(&request,
+		validation.Field(
+			&request.TaskIds,
+			validation.Required.Error("task_ids is required"),
+			validation.Length(1, 500).Error("limited max 500 tasks"),
+			validation.Each(validation.Required.Error("task id is required")),
+		),
+	)
+}
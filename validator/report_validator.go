@@ -0,0 +1,49 @@
+package validator
+
+import (
+	"go-rest-api/model"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+type IReportValidator interface {
+	VelocityQueryValidate(query model.VelocityQuery) error
+	StatsQueryValidate(query model.StatsQuery) error
+}
+
+type reportValidator struct{}
+
+func NewReportValidator() IReportValidator {
+	return &reportValidator{}
+}
+
+func (rv *reportValidator) VelocityQueryValidate(query model.VelocityQuery) error {
+	return validation.ValidateStruct(&query,
+		validation.Field(
+			&query.Period,
+			validation.In(model.VelocityPeriodWeek, model.VelocityPeriodSprint).Error("period must be week or sprint"),
+		),
+		validation.Field(
+			&query.Count,
+			validation.Min(1).Error("count must be between 1 and 52"),
+			validation.Max(52).Error("count must be between 1 and 52"),
+		),
+	)
+}
+
+func (rv *reportValidator) StatsQueryValidate(query model.StatsQuery) error {
+	return validation.ValidateStruct(&query,
+		validation.Field(
+			&query.From,
+			validation.Date("2006-01-02").Error("from must be YYYY-MM-DD"),
+		),
+		validation.Field(
+			&query.To,
+			validation.Date("2006-01-02").Error("to must be YYYY-MM-DD"),
+		),
+		validation.Field(
+			&query.Interval,
+			validation.In(model.StatsIntervalDay, model.StatsIntervalWeek).Error("interval must be day or week"),
+		),
+	)
+}
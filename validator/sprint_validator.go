@@ -0,0 +1,57 @@
+package validator
+
+import (
+	"fmt"
+	"go-rest-api/model"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+type ISprintValidator interface {
+	SprintValidate(sprint model.Sprint) error
+	SprintTasksValidate(request model.SprintTasksRequest) error
+	ChartUnitValidate(unit string) error
+}
+
+type sprintValidator struct{}
+
+func NewSprintValidator() ISprintValidator {
+	return &sprintValidator{}
+}
+
+func (sv *sprintValidator) SprintValidate(sprint model.Sprint) error {
+	return validation.ValidateStruct(&sprint,
+		validation.Field(
+			&sprint.Name,
+			validation.Required.Error("name is required"),
+			validation.RuneLength(1, 50).Error("limited max 50 char"),
+		),
+		validation.Field(
+			&sprint.StartDate,
+			validation.Required.Error("start_date is required"),
+		),
+		validation.Field(
+			&sprint.EndDate,
+			validation.Required.Error("end_date is required"),
+			validation.Min(sprint.StartDate).Error("end_date must not be before start_date"),
+			validation.Max(sprint.StartDate.AddDate(0, 0, model.MaxSprintDays-1)).Error(fmt.Sprintf("the sprint must be within %d days", model.MaxSprintDays)),
+		),
+	)
+}
+
+func (sv *sprintValidator) SprintTasksValidate(request model.SprintTasksRequest) error {
+	return validation.ValidateStruct(&request,
+		validation.Field(
+			&request.TaskIds,
+			validation.Required.Error("task_ids is required"),
+			validation.Length(1, 500).Error("limited max 500 tasks"),
+			validation.Each(validation.Required.Error("task id is required")),
+		),
+	)
+}
+
+func (sv *sprintValidator) ChartUnitValidate(unit string) error {
+	return validation.Validate(unit,
+		validation.In(model.ChartUnitTasks, model.ChartUnitPoints).Error("unit must be tasks or points"),
+	)
+}
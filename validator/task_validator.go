@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"fmt"
 	"go-rest-api/model"
 
 	validation "github.com/go-ozzo/ozzo-validation"
@@ -9,6 +10,7 @@ import (
 type ITaskValidator interface {
 	TaskValidate(task model.Task) error
 	TaskStatusValidate(task model.Task) error
+	TaskQueryValidate(query model.TaskQuery) error
 }
 
 type taskValidator struct {}
@@ -22,7 +24,7 @@ func (tv *taskValidator) TaskValidate(task model.Task) error {
 		validation.Field(
 			&task.Title,
 			validation.Required.Error("title is required"),
-			validation.RuneLength(1, 10).Error("limited max 10 char"),
+			validation.RuneLength(1, model.MaxTaskTitleLength).Error(fmt.Sprintf("limited max %d char", model.MaxTaskTitleLength)),
 		),
 	)
 }
@@ -35,3 +37,28 @@ func (tv *taskValidator) TaskStatusValidate(task model.Task) error {
 		),
 	)
 }
+
+func (tv *taskValidator) TaskQueryValidate(query model.TaskQuery) error {
+	return validation.ValidateStruct(&query,
+		validation.Field(
+			&query.Statuses,
+			validation.Each(validation.In(model.TaskStatusUnstarted, model.TaskStatusStarted, model.TaskStatusCompleted).Error("The status must be one of the following: TaskStatusUnstarted, TaskStatusStarted, or TaskStatusCompleted.")),
+		),
+		validation.Field(
+			&query.DeadlineFrom,
+			validation.Date("2006-01-02").Error("deadline_from must be YYYY-MM-DD"),
+		),
+		validation.Field(
+			&query.DeadlineTo,
+			validation.Date("2006-01-02").Error("deadline_to must be YYYY-MM-DD"),
+		),
+		validation.Field(
+			&query.SortBy,
+			validation.In("created_at", "updated_at", "dead_line", "title", "status").Error("sort_by must be one of created_at, updated_at, dead_line, title or status"),
+		),
+		validation.Field(
+			&query.Order,
+			validation.In("asc", "desc").Error("order must be asc or desc"),
+		),
+	)
+}
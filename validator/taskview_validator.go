@@ -0,0 +1,27 @@
+package validator
+
+import (
+	"go-rest-api/model"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+type ITaskViewValidator interface {
+	TaskViewValidate(taskView model.TaskView) error
+}
+
+type taskViewValidator struct{}
+
+func NewTaskViewValidator() ITaskViewValidator {
+	return &taskViewValidator{}
+}
+
+func (tvv *taskViewValidator) TaskViewValidate(taskView model.TaskView) error {
+	return validation.ValidateStruct(&taskView,
+		validation.Field(
+			&taskView.Name,
+			validation.Required.Error("name is required"),
+			validation.RuneLength(1, 50).Error("limited max 50 char"),
+		),
+	)
+}
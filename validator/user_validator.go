@@ -9,6 +9,8 @@ import (
 
 type IUserValidator interface {
 	UserValidator(user model.User) error
+	EmailValidate(email string) error
+	PasswordValidate(password string) error
 }
 
 type userValidator struct {}
@@ -33,5 +35,17 @@ func (uv *userValidator) UserValidator(user model.User) error {
 	)
 }
 
+func (uv *userValidator) EmailValidate(email string) error {
+	return validation.Validate(email,
+		validation.Required.Error("email is required"),
+		validation.RuneLength(1, 30).Error("limited max 30 char"),
+		is.Email.Error("is not valid email format"),
+	)
+}
 
-
+func (uv *userValidator) PasswordValidate(password string) error {
+	return validation.Validate(password,
+		validation.Required.Error("password is required"),
+		validation.RuneLength(6, 30).Error("limited min 6 max 30 char"),
+	)
+}
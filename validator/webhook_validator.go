@@ -0,0 +1,83 @@
+package validator
+
+import (
+	"errors"
+	"go-rest-api/model"
+	"net"
+	"net/url"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+type IWebhookValidator interface {
+	WebhookValidate(webhook model.Webhook) error
+}
+
+type webhookValidator struct{}
+
+func NewWebhookValidator() IWebhookValidator {
+	return &webhookValidator{}
+}
+
+func (wv *webhookValidator) WebhookValidate(webhook model.Webhook) error {
+	events := make([]interface{}, len(model.EventTypes))
+	for i, v := range model.EventTypes {
+		events[i] = v
+	}
+	return validation.ValidateStruct(&webhook,
+		validation.Field(
+			&webhook.URL,
+			validation.Required.Error("url is required"),
+			validation.RuneLength(1, 2048).Error("limited max 2048 char"),
+			validation.By(httpURL),
+		),
+		validation.Field(
+			&webhook.Events,
+			validation.Required.Error("events is required"),
+			validation.Each(validation.In(events...).Error("unknown event")),
+		),
+	)
+}
+
+func httpURL(value interface{}) error {
+	s, _ := value.(string)
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be an absolute http or https url")
+	}
+	// 名前解決後のアドレスは送信時に確認する。ここでは明らかな内部向けの指定だけを弾く
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("must not point to an internal address")
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublicIP(ip) {
+		return errors.New("must not point to an internal address")
+	}
+	return nil
+}
+
+// 外部に公開されていない範囲(共有アドレス・0.0.0.0/8)
+var nonPublicNetworks = func() []*net.IPNet {
+	networks := make([]*net.IPNet, 0)
+	for _, v := range []string{"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "64:ff9b::/96"} {
+		_, network, _ := net.ParseCIDR(v)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// ループバック・プライベート・リンクローカル・未指定などの内部向けのアドレスでなければ true
+// Webhookの送信先がサーバー内部のネットワークに届かないようにするために使う
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, v := range nonPublicNetworks {
+		if v.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}